@@ -0,0 +1,251 @@
+// Command fake-oidc is a minimal OpenID Connect issuer for trying social
+// login offline. It approves every authorization request without a login
+// page, as the user given by -email (or the login_hint parameter), and
+// issues RS256 ID tokens from a key generated at startup.
+//
+// Never use it outside development.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/oidc"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+const (
+	keyID   = "fake-oidc"
+	codeTTL = time.Minute
+)
+
+// grant is an issued, not yet redeemed authorization code.
+type grant struct {
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+	email         string
+	expires       time.Time
+}
+
+type issuer struct {
+	url           string
+	clientID      string
+	clientSecret  string
+	name          string
+	email         string
+	emailVerified bool
+	key           *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]grant
+}
+
+func main() {
+	addr := flag.String("addr", ":9000", "listen address")
+	issuerURL := flag.String("issuer", "http://localhost:9000", "issuer URL, as configured in OIDC_<NAME>_ISSUER")
+	clientID := flag.String("client-id", "local-go", "accepted client ID")
+	clientSecret := flag.String("client-secret", "secret", "accepted client secret")
+	email := flag.String("email", "alice@example.com", "email of the user who logs in (login_hint overrides it)")
+	name := flag.String("name", "Alice Example", "name of the user who logs in")
+	emailVerified := flag.Bool("email-verified", true, "whether ID tokens say the email is verified")
+	flag.Parse()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatal("❌ Failed to generate signing key: ", err)
+	}
+
+	iss := &issuer{
+		url:           strings.TrimSuffix(*issuerURL, "/"),
+		clientID:      *clientID,
+		clientSecret:  *clientSecret,
+		name:          *name,
+		email:         *email,
+		emailVerified: *emailVerified,
+		key:           key,
+		codes:         map[string]grant{},
+	}
+
+	log.Printf("🔑 Fake OIDC issuer %s listening on %s", iss.url, *addr)
+	log.Fatal(http.ListenAndServe(*addr, iss.routes()))
+}
+
+func (iss *issuer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", iss.discovery)
+	mux.HandleFunc("GET /authorize", iss.authorize)
+	mux.HandleFunc("POST /token", iss.token)
+	mux.HandleFunc("GET /jwks", iss.jwks)
+	return mux
+}
+
+func (iss *issuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                iss.url,
+		"authorization_endpoint":                iss.url + "/authorize",
+		"token_endpoint":                        iss.url + "/token",
+		"jwks_uri":                              iss.url + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// authorize approves the request straight away and redirects back with a code.
+func (iss *issuer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI := q.Get("redirect_uri")
+	switch {
+	case q.Get("client_id") != iss.clientID:
+		http.Error(w, "unknown client_id", http.StatusBadRequest)
+		return
+	case redirectURI == "":
+		http.Error(w, "redirect_uri is required", http.StatusBadRequest)
+		return
+	case q.Get("response_type") != "code":
+		http.Error(w, "only response_type=code is supported", http.StatusBadRequest)
+		return
+	case q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256":
+		http.Error(w, "PKCE with S256 is required", http.StatusBadRequest)
+		return
+	}
+
+	email := iss.email
+	if hint := q.Get("login_hint"); hint != "" {
+		email = hint
+	}
+
+	code, err := oidc.RandomString(16)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	iss.mu.Lock()
+	iss.codes[code] = grant{
+		redirectURI:   redirectURI,
+		codeChallenge: q.Get("code_challenge"),
+		nonce:         q.Get("nonce"),
+		email:         email,
+		expires:       time.Now().Add(codeTTL),
+	}
+	iss.mu.Unlock()
+
+	back, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	params := back.Query()
+	params.Set("code", code)
+	params.Set("state", q.Get("state"))
+	back.RawQuery = params.Encode()
+	http.Redirect(w, r, back.String(), http.StatusFound)
+}
+
+// token redeems a code (once) for an ID token.
+func (iss *issuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request", err.Error())
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != iss.clientID || clientSecret != iss.clientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type", "")
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	iss.mu.Lock()
+	g, found := iss.codes[code]
+	delete(iss.codes, code)
+	iss.mu.Unlock()
+
+	switch {
+	case !found || time.Now().After(g.expires):
+		tokenError(w, "invalid_grant", "unknown, used or expired code")
+		return
+	case r.PostForm.Get("redirect_uri") != g.redirectURI:
+		tokenError(w, "invalid_grant", "redirect_uri mismatch")
+		return
+	case oidc.S256Challenge(r.PostForm.Get("code_verifier")) != g.codeChallenge:
+		tokenError(w, "invalid_grant", "PKCE verification failed")
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            iss.url,
+		"aud":            iss.clientID,
+		"sub":            "fake|" + g.email,
+		"email":          g.email,
+		"email_verified": iss.emailVerified,
+		"name":           iss.name,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	}
+	if g.nonce != "" {
+		claims["nonce"] = g.nonce
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	idToken, err := token.SignedString(iss.key)
+	if err != nil {
+		tokenError(w, "server_error", err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": idToken,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (iss *issuer) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := iss.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func tokenError(w http.ResponseWriter, code, description string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code, "error_description": description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/FiraBro/local-go/internal/oidc"
+)
+
+const testRedirect = "http://localhost:8080/api/v1/auth/oidc/fake/callback"
+
+func newTestIssuer(t *testing.T, emailVerified bool) *issuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iss := &issuer{
+		clientID:      "local-go",
+		clientSecret:  "secret",
+		name:          "Alice Example",
+		email:         "alice@example.com",
+		emailVerified: emailVerified,
+		key:           key,
+		codes:         map[string]grant{},
+	}
+	srv := httptest.NewServer(iss.routes())
+	t.Cleanup(srv.Close)
+	iss.url = srv.URL
+	return iss
+}
+
+// authorize follows the provider's AuthURL and returns the code from the
+// redirect back to the app.
+func authorize(t *testing.T, authURL string) string {
+	t.Helper()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorize answered %s", resp.Status)
+	}
+	back, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return back.Query().Get("code")
+}
+
+// The issuer is exercised through the app's own OIDC client, so a change on
+// either side that breaks the login flow shows up here.
+func TestLoginFlow(t *testing.T) {
+	tests := []struct {
+		name          string
+		emailVerified bool
+		loginHint     string
+		clientSecret  string
+		wrongVerifier bool
+		wrongNonce    bool
+		redeemTwice   bool
+		wantErr       error
+		wantEmail     string
+	}{
+		{name: "default user", emailVerified: true, clientSecret: "secret", wantEmail: "alice@example.com"},
+		{name: "login_hint picks the user", emailVerified: true, loginHint: "bob@example.com", clientSecret: "secret", wantEmail: "bob@example.com"},
+		{name: "unverified email", clientSecret: "secret", wantEmail: "alice@example.com"},
+		{name: "wrong client secret", clientSecret: "nope", wantErr: oidc.ErrExchange},
+		{name: "wrong PKCE verifier", clientSecret: "secret", wrongVerifier: true, wantErr: oidc.ErrExchange},
+		{name: "wrong nonce", clientSecret: "secret", wrongNonce: true, wantErr: oidc.ErrInvalidToken},
+		{name: "code redeemed twice", clientSecret: "secret", redeemTwice: true, wantErr: oidc.ErrExchange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			iss := newTestIssuer(t, tt.emailVerified)
+			provider := oidc.New(oidc.Config{
+				Name:         "fake",
+				Issuer:       iss.url,
+				ClientID:     "local-go",
+				ClientSecret: tt.clientSecret,
+				RedirectURL:  testRedirect,
+			})
+			ctx := context.Background()
+
+			verifier, challenge, err := oidc.NewPKCE()
+			if err != nil {
+				t.Fatal(err)
+			}
+			authURL, err := provider.AuthURL(ctx, "state", "nonce", challenge)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.loginHint != "" {
+				authURL += "&login_hint=" + url.QueryEscape(tt.loginHint)
+			}
+			code := authorize(t, authURL)
+
+			if tt.wrongVerifier {
+				verifier += "x"
+			}
+			nonce := "nonce"
+			if tt.wrongNonce {
+				nonce = "other"
+			}
+			if tt.redeemTwice {
+				if _, err := provider.Exchange(ctx, code, verifier, nonce); err != nil {
+					t.Fatalf("first exchange: %v", err)
+				}
+			}
+
+			claims, err := provider.Exchange(ctx, code, verifier, nonce)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Exchange error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if claims.Email != tt.wantEmail || claims.Subject != "fake|"+tt.wantEmail ||
+				claims.EmailVerified != tt.emailVerified || claims.Name != "Alice Example" {
+				t.Errorf("claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func TestAuthorizeRejects(t *testing.T) {
+	iss := newTestIssuer(t, true)
+	valid := url.Values{
+		"client_id":             {"local-go"},
+		"redirect_uri":          {testRedirect},
+		"response_type":         {"code"},
+		"code_challenge":        {oidc.S256Challenge("verifier")},
+		"code_challenge_method": {"S256"},
+	}
+
+	tests := []struct {
+		name  string
+		param string
+		value string // empty removes the parameter
+	}{
+		{"unknown client", "client_id", "other"},
+		{"no redirect_uri", "redirect_uri", ""},
+		{"implicit flow", "response_type", "token"},
+		{"no PKCE", "code_challenge", ""},
+		{"plain PKCE", "code_challenge_method", "plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := url.Values{}
+			for k, v := range valid {
+				q[k] = v
+			}
+			if tt.value == "" {
+				q.Del(tt.param)
+			} else {
+				q.Set(tt.param, tt.value)
+			}
+
+			resp, err := http.Get(iss.url + "/authorize?" + q.Encode())
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("authorize answered %s, want 400", resp.Status)
+			}
+		})
+	}
+}
@@ -1,7 +0,0 @@
--- Re-create it with service_id instead of service_name
-CREATE TABLE staff_services (
-    id UUID PRIMARY KEY,
-    staff_id UUID NOT NULL REFERENCES staff(id) ON DELETE CASCADE,
-    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
-    UNIQUE(staff_id, service_id)
-);
\ No newline at end of file
@@ -1,14 +1,25 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log"
+	"os"
+	"time"
 
+	"github.com/FiraBro/local-go/internal/bus"
 	"github.com/FiraBro/local-go/internal/config"
 	"github.com/FiraBro/local-go/internal/db"
 	"github.com/FiraBro/local-go/internal/handlers"
+	"github.com/FiraBro/local-go/internal/mailer"
+	"github.com/FiraBro/local-go/internal/middlewares"
+	"github.com/FiraBro/local-go/internal/oidc"
+	"github.com/FiraBro/local-go/internal/passwords"
 	"github.com/FiraBro/local-go/internal/repositories"
 	"github.com/FiraBro/local-go/internal/routes"
+	"github.com/FiraBro/local-go/internal/scheduler"
 	"github.com/FiraBro/local-go/internal/services"
+	"github.com/FiraBro/local-go/internal/tokens"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
@@ -23,8 +34,47 @@ func main() {
 
 	// 2. Configuration & Database
 	config.ValidateConfig()
+
+	// `server migrate up|down|status` manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		runMigrate(os.Args[2:])
+		return
+	}
+
+	// `server set-role <email> <role>` bootstraps admins and exits
+	if len(os.Args) > 1 && os.Args[1] == "set-role" {
+		runSetRole(os.Args[2:])
+		return
+	}
+
+	autoMigrate := flag.Bool("auto-migrate", config.AutoMigrate, "apply pending migrations before serving (env AUTO_MIGRATE)")
+	flag.Parse()
+
+	if *autoMigrate {
+		if err := db.MigrateUp(); err != nil {
+			log.Fatal("❌ Failed to apply migrations:", err)
+		}
+	}
+	if err := db.CheckSchema(); err != nil {
+		log.Fatal("❌ Refusing to start: ", err)
+	}
+
 	dbConn := db.InitDB()
 
+	// Signing keys: load (or create) them, then keep rotating in the background
+	if err := tokens.Init(); err != nil {
+		log.Fatal("❌ Failed to load JWT signing keys: ", err)
+	}
+	tokens.StartRotation(time.Hour)
+
+	if config.PasswordBreachedFile != "" {
+		n, err := passwords.LoadBreached(config.PasswordBreachedFile)
+		if err != nil {
+			log.Fatal("❌ Failed to load breached password list: ", err)
+		}
+		log.Printf("🔒 Loaded %d breached password hashes", n)
+	}
+
 	// ------------------------
 	// 3. Repositories
 	// ------------------------
@@ -33,23 +83,110 @@ func main() {
 	serviceRepo := repositories.NewServiceRepository(dbConn)
 	refreshRepo := repositories.NewRefreshTokenRepository(dbConn)
 	resetRepo := repositories.NewResetTokenRepository(dbConn)
+	bookingRepo := repositories.NewBookingRepository(dbConn)
+	eventRepo := repositories.NewEventRepository(dbConn)
+	mfaRepo := repositories.NewMFARepository(dbConn)
+	roleRepo := repositories.NewRoleRepository(dbConn)
+	attemptRepo := repositories.NewLoginAttemptRepository(dbConn)
+	apiKeyRepo := repositories.NewAPIKeyRepository(dbConn)
+	identityRepo := repositories.NewIdentityRepository(dbConn)
+	impersonationRepo := repositories.NewImpersonationRepository(dbConn)
+	auditRepo := repositories.NewAuditRepository(dbConn)
+	outboxRepo := repositories.NewEmailOutboxRepository(dbConn)
+	notificationRepo := repositories.NewNotificationRepository(dbConn)
+	webhookRepo := repositories.NewWebhookRepository(dbConn)
+	eventOutboxRepo := repositories.NewEventOutboxRepository(dbConn)
+	transactor := repositories.NewTransactor(dbConn)
+
+	// Outgoing mail driver, from MAIL_DRIVER
+	mail, err := mailer.New()
+	if err != nil {
+		log.Fatal("❌ Failed to set up mail: ", err)
+	}
+
+	// Social login providers (OIDC), from OIDC_PROVIDERS
+	var providers []oidc.Provider
+	for _, p := range config.OIDCProviders {
+		providers = append(providers, oidc.New(oidc.Config{
+			Name:         p.Name,
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		}))
+	}
 
 	// ------------------------
 	// 4. Services (FIXED DEPENDENCIES)
 	// ------------------------
-	authService := services.NewAuthService(userRepo, refreshRepo, resetRepo)
+	// Every service that changes configuration or accounts writes the audit log
+	auditService := services.NewAuditService(auditRepo)
+	// Email goes through the outbox, delivered in the background
+	mailService := services.NewMailService(outboxRepo, mail)
+	mailService.StartWorker(config.MailPollInterval)
+
+	// Services publish domain events on the bus once a change is committed;
+	// with EVENT_OUTBOX they are stored with the change and relayed from there
+	eventOutbox := services.NewEventOutbox(eventOutboxRepo)
+	busOptions := bus.Options{AfterCommit: repositories.AfterCommit}
+	if config.EventOutbox {
+		busOptions.Outbox = eventOutbox
+	}
+	eventBus := bus.New(busOptions)
+	// Relay whatever is stored, even if the outbox was switched off since
+	eventOutbox.StartRelay(eventBus, config.EventRelayInterval)
+
+	// Domain events go out to webhook endpoints, delivered in the background
+	webhookService := services.NewWebhookService(webhookRepo, auditService)
+	webhookService.Subscribe(eventBus)
+	webhookService.StartWorker(config.WebhookPollInterval)
+
+	authService := services.NewAuthService(userRepo, refreshRepo, resetRepo, mfaRepo, roleRepo, attemptRepo, identityRepo, transactor, providers, auditService, mailService, eventBus)
+	roleService := services.NewRoleService(roleRepo, auditService)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, roleRepo, auditService)
+	impersonationService := services.NewImpersonationService(impersonationRepo, userRepo, roleRepo, auditService)
 	
 	// StaffService needs BOTH staffRepo and serviceRepo to manage relationships
-	staffService := services.NewStaffService(staffRepo, serviceRepo) 
+	staffService := services.NewStaffService(staffRepo, serviceRepo, transactor, auditService, eventBus) 
 	
-	serviceService := services.NewServiceService(serviceRepo)
+	serviceService := services.NewServiceService(serviceRepo, transactor, auditService, eventBus)
+
+	// Availability subtracts live bookings from the staff schedule
+	availabilityService := services.NewAvailabilityService(staffRepo, bookingRepo)
+	bookingService := services.NewBookingService(bookingRepo, staffRepo, serviceRepo, availabilityService, transactor, eventBus)
+	eventService := services.NewEventService(eventRepo, transactor, eventBus)
+	// Live updates for /stream, recomputed as domain events come in
+	streamService := services.NewStreamService(availabilityService, serviceRepo, bookingRepo)
+	streamService.Listen(eventBus)
+	reminderService := services.NewReminderService(notificationRepo, mailService, auditService, config.ReminderOffsets)
+
+	// Background jobs
+	jobs := scheduler.New()
+	// Deleted accounts: reminders before the deadline, purge after it
+	jobs.Every("account-deletion", config.AccountPurgeInterval, authService.RunDeletionJobs)
+	// Booking and ticket reminders, REMINDER_OFFSETS before the start
+	jobs.Every("reminders", config.ReminderInterval, reminderService.SendDue)
+	jobs.Every("reminders-cleanup", 24*time.Hour, reminderService.CleanupSent)
+	jobs.Every("webhook-deliveries-cleanup", 24*time.Hour, webhookService.CleanupDeliveries)
+	jobs.Every("event-outbox-cleanup", 24*time.Hour, eventOutbox.Cleanup)
+	jobs.Start(context.Background())
 
 	// ------------------------
 	// 5. Handlers
 	// ------------------------
 	authHandler := handlers.NewAuthHandler(authService)
-	staffHandler := handlers.NewStaffHandler(staffService)
+	staffHandler := handlers.NewStaffHandler(staffService, availabilityService)
 	serviceHandler := handlers.NewServiceHandler(serviceService)
+	bookingHandler := handlers.NewBookingHandler(bookingService)
+	eventHandler := handlers.NewEventHandler(eventService)
+	roleHandler := handlers.NewRoleHandler(roleService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	impersonationHandler := handlers.NewImpersonationHandler(impersonationService)
+	auditHandler := handlers.NewAuditHandler(auditService)
+	notificationHandler := handlers.NewNotificationHandler(reminderService)
+	webhookHandler := handlers.NewWebhookHandler(webhookService)
+	streamHandler := handlers.NewStreamHandler(streamService)
 
 	// ------------------------
 	// 6. Router Setup
@@ -59,13 +196,32 @@ func main() {
 	// Apply Global Middlewares (CORS, etc.) if you have them
 	// r.Use(middlewares.CORS())
 
+	// Route groups apply their own rate limits (see internal/ratelimit). The
+	// default store is in-memory, i.e. per instance; when running several,
+	// call middlewares.SetRateLimitStore with a shared store here.
+
+	// Public keys for verifying our tokens (outside the versioned API)
+	r.GET("/.well-known/jwks.json", handlers.JWKS)
+
 	api := r.Group(version)
 
+	// One authentication middleware for every protected route group
+	authMW := middlewares.AuthMiddleware(userRepo, roleRepo, apiKeyRepo, impersonationRepo, refreshRepo)
+
 	// Initialize Routes
-	routes.AuthRoutes(api, authHandler, userRepo)
-	routes.UserRoutes(api, authHandler, userRepo)
-	routes.StaffRoutes(api, staffHandler, userRepo)
-	routes.ServiceRoutes(api, serviceHandler, userRepo)
+	routes.AuthRoutes(api, authHandler, authMW)
+	routes.UserRoutes(api, authHandler, authMW)
+	routes.APIKeyRoutes(api, apiKeyHandler, authMW)
+	routes.ImpersonationRoutes(api, impersonationHandler, authMW)
+	routes.AuditRoutes(api, auditHandler, authMW)
+	routes.NotificationRoutes(api, notificationHandler, authMW)
+	routes.WebhookRoutes(api, webhookHandler, authMW)
+	routes.RoleRoutes(api, roleHandler, authMW)
+	routes.StaffRoutes(api, staffHandler, authMW)
+	routes.ServiceRoutes(api, serviceHandler, authMW)
+	routes.BookingRoutes(api, bookingHandler, authMW)
+	routes.SetupEventRoutes(api, eventHandler, authMW)
+	routes.StreamRoutes(api, streamHandler, authMW)
 
 	// ------------------------
 	// 7. Start Server
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/FiraBro/local-go/internal/db"
+)
+
+const migrateUsage = `usage: server migrate <command>
+
+commands:
+  up         apply all pending migrations
+  down [N]   roll back the last N migrations (default 1)
+  status     show applied and latest schema version`
+
+// runMigrate implements the `server migrate` subcommand.
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "up":
+		if err := db.MigrateUp(); err != nil {
+			log.Fatal("❌ Migration failed: ", err)
+		}
+		printStatus()
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatalf("❌ Invalid step count %q", args[1])
+			}
+			steps = n
+		}
+		if err := db.MigrateDown(steps); err != nil {
+			log.Fatal("❌ Rollback failed: ", err)
+		}
+		printStatus()
+
+	case "status":
+		printStatus()
+
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+}
+
+func printStatus() {
+	status, err := db.GetMigrationStatus()
+	if err != nil {
+		log.Fatal("❌ Failed to read schema version: ", err)
+	}
+
+	fmt.Printf("current version: %d\n", status.Current)
+	fmt.Printf("latest version:  %d\n", status.Latest)
+	fmt.Printf("pending:         %d\n", status.Pending())
+	if status.Dirty {
+		fmt.Println("⚠ schema is dirty: the last migration did not finish")
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/FiraBro/local-go/internal/db"
+	"github.com/FiraBro/local-go/internal/repositories"
+)
+
+const setRoleUsage = `usage: server set-role <email> <role>
+
+Assigns a role to an existing account, e.g. to create the first admin.`
+
+// runSetRole implements the `server set-role` subcommand.
+func runSetRole(args []string) {
+	if len(args) != 2 {
+		fmt.Fprintln(os.Stderr, setRoleUsage)
+		os.Exit(2)
+	}
+	email, role := args[0], args[1]
+
+	if err := db.CheckSchema(); err != nil {
+		log.Fatal("❌ ", err)
+	}
+	dbConn := db.InitDB()
+	defer dbConn.Close()
+
+	userRepo := repositories.NewUserRepository(dbConn)
+	roleRepo := repositories.NewRoleRepository(dbConn)
+
+	r, err := roleRepo.GetRole(role)
+	if err != nil {
+		log.Fatal("❌ Failed to look up role: ", err)
+	}
+	if r == nil {
+		log.Fatalf("❌ Unknown role %q", role)
+	}
+
+	user, err := userRepo.GetByEmail(email)
+	if err != nil {
+		log.Fatalf("❌ No active account for %s", email)
+	}
+
+	if err := userRepo.UpdateUserRole(context.Background(), user.ID, role); err != nil {
+		log.Fatal("❌ Failed to update role: ", err)
+	}
+	fmt.Printf("%s is now %s\n", user.Email, role)
+}
@@ -0,0 +1,97 @@
+// Package audit carries who is acting through a request's context and
+// computes the before/after diffs stored with audit events.
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"slices"
+)
+
+// Actor is who performs an action. UserID is the effective user; when an
+// admin is impersonating, ImpersonatorID is the admin. APIKeyID is set for
+// requests made with an API key.
+type Actor struct {
+	UserID         string
+	ImpersonatorID string
+	APIKeyID       string
+	IP             string
+}
+
+type actorKey struct{}
+
+// WithActor returns ctx carrying actor; AuthMiddleware sets it for every
+// authenticated request.
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFrom returns the actor in ctx, if any.
+func ActorFrom(ctx context.Context) (Actor, bool) {
+	actor, ok := ctx.Value(actorKey{}).(Actor)
+	return actor, ok
+}
+
+// Change is one field's value before and after an action.
+type Change struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// Fields never written to the audit log, and bookkeeping that would only
+// add noise to every diff.
+var skippedFields = []string{"password", "key_hash", "secret", "updated_at"}
+
+// Diff compares the JSON forms of before and after (either may be nil, for
+// creations and deletions) and returns the fields that differ. It returns
+// nil when nothing changed.
+func Diff(before, after any) (map[string]Change, error) {
+	b, err := fields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := fields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]Change{}
+	for name, value := range b {
+		if !reflect.DeepEqual(value, a[name]) {
+			changes[name] = Change{Before: value, After: a[name]}
+		}
+	}
+	for name, value := range a {
+		if _, seen := b[name]; !seen && value != nil {
+			changes[name] = Change{Before: nil, After: value}
+		}
+	}
+
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	return changes, nil
+}
+
+// fields flattens v to its top-level JSON fields.
+func fields(v any) (map[string]any, error) {
+	out := map[string]any{}
+	if v == nil {
+		return out, nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil, err
+	}
+	for name := range out {
+		if slices.Contains(skippedFields, name) {
+			delete(out, name)
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,177 @@
+// Package bus is an in-process publish/subscribe bus for domain events.
+// Services publish an event once a change is saved; subscribers react to it
+// without the service knowing about them.
+//
+// Synchronous subscribers run in the publisher's goroutine, in subscription
+// order, before Publish returns. Asynchronous subscribers each get their own
+// goroutine and queue, so a slow one holds up neither the request nor the
+// other subscribers; they see events in publish order.
+//
+// Inside a database transaction (see repositories.Transactor) delivery waits
+// for the commit and is dropped on rollback. With an Outbox the event is
+// instead stored in the transaction and relayed after the commit, so it
+// survives a crash between the two.
+package bus
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"sync"
+)
+
+// AllEvents subscribes to every event.
+const AllEvents = "*"
+
+const defaultAsyncBuffer = 256
+
+// Event is a domain event. Events are published by value and named
+// "<resource>.<change>", e.g. "service.created".
+type Event interface {
+	EventName() string
+}
+
+// Handler reacts to an event. Its error is logged: by the time an event is
+// published the change already happened.
+type Handler func(ctx context.Context, e Event) error
+
+// Outbox stores events published inside a transaction, to be relayed once
+// the transaction commits.
+type Outbox interface {
+	// Store saves e in ctx's transaction. It reports false, without storing
+	// anything, if ctx carries no transaction.
+	Store(ctx context.Context, e Event) (bool, error)
+}
+
+type Options struct {
+	// AfterCommit, if set, defers fn until ctx's transaction commits and
+	// reports true, or reports false if ctx carries no transaction.
+	AfterCommit func(ctx context.Context, fn func()) bool
+
+	// Outbox, if set, takes events published inside a transaction.
+	Outbox Outbox
+
+	// AsyncBuffer is how many events an asynchronous subscriber may fall
+	// behind before Publish waits for it. Defaults to 256.
+	AsyncBuffer int
+}
+
+type delivery struct {
+	ctx   context.Context
+	event Event
+}
+
+type Bus struct {
+	opts Options
+
+	mu          sync.RWMutex
+	handlers    map[string][]Handler
+	subscribers map[string][]chan delivery
+}
+
+func New(opts Options) *Bus {
+	if opts.AsyncBuffer <= 0 {
+		opts.AsyncBuffer = defaultAsyncBuffer
+	}
+	return &Bus{
+		opts:        opts,
+		handlers:    make(map[string][]Handler),
+		subscribers: make(map[string][]chan delivery),
+	}
+}
+
+// Subscribe runs h synchronously for every event called name (AllEvents for
+// all of them).
+func (b *Bus) Subscribe(name string, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = append(b.handlers[name], h)
+}
+
+// SubscribeAsync runs h in the background for every event called name
+// (AllEvents for all of them). Events still queued when the process exits
+// are lost; subscribers that must not miss any should record their work in
+// the database synchronously instead.
+func (b *Bus) SubscribeAsync(name string, h Handler) {
+	queue := make(chan delivery, b.opts.AsyncBuffer)
+	go func() {
+		for d := range queue {
+			call(d.ctx, h, d.event)
+		}
+	}()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers[name] = append(b.subscribers[name], queue)
+}
+
+// On subscribes a synchronous handler to events of type E.
+func On[E Event](b *Bus, h func(ctx context.Context, e E) error) {
+	var zero E
+	b.Subscribe(zero.EventName(), typed(h))
+}
+
+// OnAsync subscribes an asynchronous handler to events of type E.
+func OnAsync[E Event](b *Bus, h func(ctx context.Context, e E) error) {
+	var zero E
+	b.SubscribeAsync(zero.EventName(), typed(h))
+}
+
+func typed[E Event](h func(ctx context.Context, e E) error) Handler {
+	return func(ctx context.Context, e Event) error {
+		if e, ok := e.(E); ok {
+			return h(ctx, e)
+		}
+		return nil
+	}
+}
+
+// Publish delivers e to its subscribers, or, inside a transaction, once the
+// transaction commits. The error is only ever that of storing e in the
+// outbox; return it from the transaction so the change rolls back with it.
+func (b *Bus) Publish(ctx context.Context, e Event) error {
+	if b.opts.Outbox != nil {
+		stored, err := b.opts.Outbox.Store(ctx, e)
+		if stored || err != nil {
+			return err
+		}
+	}
+	if b.opts.AfterCommit != nil && b.opts.AfterCommit(ctx, func() { b.Dispatch(ctx, e) }) {
+		return nil
+	}
+	b.Dispatch(ctx, e)
+	return nil
+}
+
+// Dispatch delivers e to its subscribers now, bypassing the outbox. It is
+// meant for relaying stored events; services call Publish.
+func (b *Bus) Dispatch(ctx context.Context, e Event) {
+	name := e.EventName()
+
+	b.mu.RLock()
+	handlers := append(append([]Handler(nil), b.handlers[name]...), b.handlers[AllEvents]...)
+	queues := append(append([]chan delivery(nil), b.subscribers[name]...), b.subscribers[AllEvents]...)
+	b.mu.RUnlock()
+
+	for _, h := range handlers {
+		call(ctx, h, e)
+	}
+
+	// Asynchronous subscribers outlive the request
+	ctx = context.WithoutCancel(ctx)
+	for _, queue := range queues {
+		queue <- delivery{ctx: ctx, event: e}
+	}
+}
+
+// call runs h, logging its error or panic.
+func call(ctx context.Context, h Handler, e Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("❌ Subscriber to %s panicked: %v\n%s", e.EventName(), r, debug.Stack())
+		}
+	}()
+	if err := h(ctx, e); err != nil {
+		log.Printf("⚠️ Subscriber to %s failed: %v", e.EventName(), err)
+	}
+}
@@ -2,26 +2,162 @@ package config
 
 import (
 	"log"
+	"net/netip"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// Supported storage backends
+const (
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// Password hashing algorithms
+const (
+	PasswordHashBcrypt   = "bcrypt"
+	PasswordHashArgon2id = "argon2id"
+)
+
+// Mail drivers
+const (
+	MailDriverSMTP = "smtp"
+	MailDriverFile = "file"
+	MailDriverLog  = "log"
+)
+
+// Email verification policies
+const (
+	VerifyPolicyNone    = "none"    // verification is optional
+	VerifyPolicyBooking = "booking" // unverified users cannot book or register for events
+	VerifyPolicyLogin   = "login"   // unverified users cannot log in (implies booking)
+)
+
 var (
 	// Server
 	ServerPort = getEnv("SERVER_PORT", "8080")
 
-	// Database
-	DBAddr = getDBAddr()
+	// Database: DB_DRIVER selects the storage backend ("postgres" or "sqlite")
+	DBDriver = getEnv("DB_DRIVER", DriverPostgres)
+	DBAddr   = getDBAddr()
+
+	// Apply pending migrations on start (also the -auto-migrate flag)
+	AutoMigrate = getEnv("AUTO_MIGRATE", "false") == "true"
+
+	// JWT: PEM signing keys live in JWTKeyDir; a new key is generated once
+	// the active one is older than JWTKeyRotation (0 disables rotation)
+	JWTKeyDir      = getEnv("JWT_KEY_DIR", "keys")
+	JWTKeyRotation = getEnvDuration("JWT_KEY_ROTATION", 30*24*time.Hour)
+	JWTIssuer      = getEnv("JWT_ISSUER", AppBaseURL)
+	JWTAudience    = getEnv("JWT_AUDIENCE", "local-go-api")
+
+	// Login throttling: failures allowed per account and per client IP before
+	// a lockout of LoginLockout (failures older than that are forgotten), and
+	// wrong OTPs allowed per password reset before the code is burned
+	LoginMaxFailures    = getEnvInt("LOGIN_MAX_FAILURES", 5)
+	LoginIPMaxFailures  = getEnvInt("LOGIN_IP_MAX_FAILURES", 50)
+	LoginLockout        = getEnvDuration("LOGIN_LOCKOUT", 15*time.Minute)
+	ResetOTPMaxAttempts = getEnvInt("RESET_OTP_MAX_ATTEMPTS", 3)
+
+	// Password policy: length bounds, how many of the four character classes
+	// (lower, upper, digit, symbol) a password needs, and an optional file of
+	// breached password SHA-1 hashes to refuse
+	PasswordMinLength    = getEnvInt("PASSWORD_MIN_LENGTH", 10)
+	PasswordMaxLength    = getEnvInt("PASSWORD_MAX_LENGTH", 128)
+	PasswordMinClasses   = getEnvInt("PASSWORD_MIN_CLASSES", 2)
+	PasswordBreachedFile = getEnv("PASSWORD_BREACHED_FILE", "")
+
+	// Password hashing (PasswordHashBcrypt or PasswordHashArgon2id); stored
+	// hashes made with another algorithm or cost are upgraded at login
+	PasswordHash          = getEnv("PASSWORD_HASH", PasswordHashBcrypt)
+	PasswordBcryptCost    = getEnvInt("PASSWORD_BCRYPT_COST", 12)
+	PasswordArgon2Memory  = getEnvInt("PASSWORD_ARGON2_MEMORY", 64*1024) // KiB
+	PasswordArgon2Time    = getEnvInt("PASSWORD_ARGON2_TIME", 3)
+	PasswordArgon2Threads = getEnvInt("PASSWORD_ARGON2_THREADS", 2)
 
-	// JWT
-	JWTSecret = []byte(getEnv("JWT_SECRET", "my-first-go-jwt-secret-for-api-i-really-excited"))
-	JWTExpiry = 24 * time.Hour
+	// Rate limits in requests per minute per user (or per IP when anonymous)
+	// for each route group; 0 disables a group's limit
+	RateLimitPublic = getEnvInt("RATE_LIMIT_PUBLIC", 120)
+	RateLimitAuth   = getEnvInt("RATE_LIMIT_AUTH", 20)
+	RateLimitUser   = getEnvInt("RATE_LIMIT_USER", 120)
+	RateLimitAdmin  = getEnvInt("RATE_LIMIT_ADMIN", 300)
+
+	// Account deletion: a deleted account can be restored for
+	// AccountDeletionGrace, its owner is reminded AccountDeletionReminder
+	// before it is purged (0 disables the reminder), and the purge job runs
+	// every AccountPurgeInterval
+	AccountDeletionGrace    = getEnvDuration("ACCOUNT_DELETION_GRACE", 14*24*time.Hour)
+	AccountDeletionReminder = getEnvDuration("ACCOUNT_DELETION_REMINDER", 48*time.Hour)
+	AccountPurgeInterval    = getEnvDuration("ACCOUNT_PURGE_INTERVAL", time.Hour)
+
+	// Lifetime of the access token issued when an admin impersonates a user
+	ImpersonationTTL = getEnvDuration("IMPERSONATION_TTL", 15*time.Minute)
+
+	// MFA: issuer shown in authenticator apps
+	MFAIssuer = getEnv("MFA_ISSUER", "Local Go")
+
+	// Email verification: what an unverified account may not do
+	// (VerifyPolicyNone, VerifyPolicyBooking or VerifyPolicyLogin)
+	EmailVerificationPolicy = getEnv("EMAIL_VERIFICATION_POLICY", VerifyPolicyNone)
+
+	// Public URL of the API, used to build links in emails
+	AppBaseURL = getEnv("APP_BASE_URL", "http://localhost:"+ServerPort)
+
+	// OIDC social login providers, from OIDC_PROVIDERS=google,fake and
+	// OIDC_<NAME>_ISSUER / _CLIENT_ID / _CLIENT_SECRET / _SCOPES / _REDIRECT_URL
+	OIDCProviders = getOIDCProviders()
 
 	// SMTP
 	SMTPHost = getEnv("SMTP_HOST", "smtp.gmail.com")
 	SMTPPort = getEnv("SMTP_PORT", "587")
 	SMTPUser = getEnv("SMTP_USER", "")
 	SMTPPass = getEnv("SMTP_PASS", "")
+
+	// Outgoing mail: MailDriver picks how it is delivered (MailDriverSMTP,
+	// MailDriverFile into MailFileDir, or MailDriverLog); undelivered mail is
+	// retried with backoff up to MailMaxAttempts times
+	MailDriver       = getEnv("MAIL_DRIVER", MailDriverSMTP)
+	MailFrom         = getEnv("MAIL_FROM", "Event Booking <no-reply@localhost>")
+	MailFileDir      = getEnv("MAIL_FILE_DIR", "mail")
+	MailMaxAttempts  = getEnvInt("MAIL_MAX_ATTEMPTS", 8)
+	MailPollInterval = getEnvDuration("MAIL_POLL_INTERVAL", 10*time.Second)
+
+	// Reminders for upcoming bookings and event tickets go out at each of
+	// ReminderOffsets before the start (none disables them); the job checks
+	// every ReminderInterval
+	ReminderOffsets  = getEnvDurations("REMINDER_OFFSETS", []time.Duration{24 * time.Hour, time.Hour})
+	ReminderInterval = getEnvDuration("REMINDER_INTERVAL", time.Minute)
+
+	// Outbound webhooks: each delivery attempt times out after
+	// WebhookTimeout; failed deliveries are retried with backoff up to
+	// WebhookMaxAttempts times, and the queue is polled every
+	// WebhookPollInterval
+	WebhookTimeout      = getEnvDuration("WEBHOOK_TIMEOUT", 10*time.Second)
+	WebhookMaxAttempts  = getEnvInt("WEBHOOK_MAX_ATTEMPTS", 10)
+	WebhookPollInterval = getEnvDuration("WEBHOOK_POLL_INTERVAL", 5*time.Second)
+
+	// Webhooks are only sent to public addresses, plus these networks
+	// (e.g. "10.1.2.0/24,fd00::/8") for receivers inside our own network
+	WebhookAllowedNetworks = getEnvPrefixes("WEBHOOK_ALLOWED_NETWORKS")
+
+	// Domain events: with EventOutbox, events published inside a transaction
+	// are stored by it and relayed to the bus after the commit, so none is
+	// lost to a crash in between; stored events are polled every
+	// EventRelayInterval
+	EventOutbox        = getEnv("EVENT_OUTBOX", "false") == "true"
+	EventRelayInterval = getEnvDuration("EVENT_RELAY_INTERVAL", 5*time.Second)
+
+	// Live updates (GET /stream): a heartbeat comment every StreamHeartbeat
+	// keeps idle connections open through proxies; after StreamMaxAge a stream
+	// is closed, so the client reconnects and authenticates again
+	StreamHeartbeat = getEnvDuration("STREAM_HEARTBEAT", 25*time.Second)
+	StreamMaxAge    = getEnvDuration("STREAM_MAX_AGE", time.Hour)
+
+	// Availability: step between candidate start times, also the slot
+	// length for services without a duration
+	SlotIntervalMinutes = getEnvInt("SLOT_INTERVAL_MINUTES", 30)
 )
 
 // ----------------------------
@@ -34,10 +170,130 @@ func getEnv(key, fallback string) string {
 	return fallback
 }
 
+// ----------------------------
+// Helper to get integer environment variable or fallback
+// ----------------------------
+func getEnvInt(key string, fallback int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("⚠ Invalid %s=%q, using %d", key, value, fallback)
+		return fallback
+	}
+	return n
+}
+
+// ----------------------------
+// Helper to get duration environment variable (e.g. "720h") or fallback
+// ----------------------------
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("⚠ Invalid %s=%q, using %s", key, value, fallback)
+		return fallback
+	}
+	return d
+}
+
+// ----------------------------
+// Helper to get a comma-separated duration list (e.g. "24h,1h") or fallback
+// ----------------------------
+func getEnvDurations(key string, fallback []time.Duration) []time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return fallback
+	}
+	durations := []time.Duration{}
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		d, err := time.ParseDuration(part)
+		if err != nil {
+			log.Printf("⚠ Invalid %s=%q, using %v", key, value, fallback)
+			return fallback
+		}
+		durations = append(durations, d)
+	}
+	return durations
+}
+
+// ----------------------------
+// Helper to get a comma-separated list of networks (e.g. "10.0.0.0/8"); a
+// bare address is a network of one
+// ----------------------------
+func getEnvPrefixes(key string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, part := range strings.Split(getEnv(key, ""), ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(part)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(part)
+			if addrErr != nil {
+				log.Printf("⚠ Invalid network %q in %s, ignoring it", part, key)
+				continue
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes
+}
+
+// ----------------------------
+// OIDC provider list helper
+// ----------------------------
+
+// OIDCProvider configures one OpenID Connect identity provider.
+type OIDCProvider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	RedirectURL  string
+}
+
+func getOIDCProviders() []OIDCProvider {
+	var providers []OIDCProvider
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providers = append(providers, OIDCProvider{
+			Name:         name,
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", AppBaseURL+"/api/v1/auth/oidc/"+name+"/callback"),
+		})
+	}
+	return providers
+}
+
 // ----------------------------
 // Database address helper
 // ----------------------------
 func getDBAddr() string {
+	if DBDriver == DriverSQLite {
+		return getEnv("DB_ADDR", "file:local_go.db?_foreign_keys=on&_busy_timeout=5000")
+	}
+
 	dbHost := "localhost"
 	if os.Getenv("DOCKER_ENV") == "true" {
 		dbHost = "db"
@@ -49,15 +305,108 @@ func getDBAddr() string {
 // Validate critical config
 // ----------------------------
 func ValidateConfig() {
-	if len(JWTSecret) == 0 {
-		log.Panic("❌ JWT_SECRET is not set")
+	if JWTKeyDir == "" {
+		log.Panic("❌ JWT_KEY_DIR is not set")
+	}
+
+	if JWTKeyRotation < 0 {
+		log.Panic("❌ JWT_KEY_ROTATION must not be negative")
 	}
 
 	if DBAddr == "" {
 		log.Panic("❌ DB_ADDR is not set")
 	}
 
-	if SMTPUser == "" || SMTPPass == "" {
+	if DBDriver != DriverPostgres && DBDriver != DriverSQLite {
+		log.Panicf("❌ DB_DRIVER must be %q or %q, got %q", DriverPostgres, DriverSQLite, DBDriver)
+	}
+
+	if LoginMaxFailures <= 0 || LoginIPMaxFailures <= 0 || ResetOTPMaxAttempts <= 0 {
+		log.Panic("❌ LOGIN_MAX_FAILURES, LOGIN_IP_MAX_FAILURES and RESET_OTP_MAX_ATTEMPTS must be positive")
+	}
+
+	if LoginLockout <= 0 {
+		log.Panic("❌ LOGIN_LOCKOUT must be positive")
+	}
+
+	if PasswordMinLength < 1 || PasswordMaxLength < PasswordMinLength {
+		log.Panic("❌ PASSWORD_MIN_LENGTH must be positive and at most PASSWORD_MAX_LENGTH")
+	}
+
+	if PasswordMinClasses < 0 || PasswordMinClasses > 4 {
+		log.Panic("❌ PASSWORD_MIN_CLASSES must be between 0 and 4")
+	}
+
+	switch PasswordHash {
+	case PasswordHashBcrypt:
+		if PasswordBcryptCost < 10 || PasswordBcryptCost > 31 {
+			log.Panic("❌ PASSWORD_BCRYPT_COST must be between 10 and 31")
+		}
+	case PasswordHashArgon2id:
+		if PasswordArgon2Memory < 19*1024 || PasswordArgon2Time < 1 || PasswordArgon2Threads < 1 || PasswordArgon2Threads > 255 {
+			log.Panic("❌ PASSWORD_ARGON2_MEMORY must be at least 19456 (KiB), PASSWORD_ARGON2_TIME at least 1 and PASSWORD_ARGON2_THREADS between 1 and 255")
+		}
+	default:
+		log.Panicf("❌ PASSWORD_HASH must be %q or %q, got %q", PasswordHashBcrypt, PasswordHashArgon2id, PasswordHash)
+	}
+
+	if RateLimitPublic < 0 || RateLimitAuth < 0 || RateLimitUser < 0 || RateLimitAdmin < 0 {
+		log.Panic("❌ RATE_LIMIT_* must not be negative")
+	}
+
+	if AccountDeletionGrace < time.Hour || AccountDeletionReminder < 0 || AccountDeletionReminder >= AccountDeletionGrace {
+		log.Panic("❌ ACCOUNT_DELETION_GRACE must be at least 1h and ACCOUNT_DELETION_REMINDER between 0 and the grace period")
+	}
+
+	if AccountPurgeInterval < time.Minute {
+		log.Panic("❌ ACCOUNT_PURGE_INTERVAL must be at least 1m")
+	}
+
+	if ImpersonationTTL < time.Minute || ImpersonationTTL > time.Hour {
+		log.Panic("❌ IMPERSONATION_TTL must be between 1m and 1h")
+	}
+
+	for _, p := range OIDCProviders {
+		if p.Issuer == "" || p.ClientID == "" {
+			log.Panicf("❌ OIDC provider %q needs an issuer and a client ID", p.Name)
+		}
+	}
+
+	if SlotIntervalMinutes <= 0 {
+		log.Panic("❌ SLOT_INTERVAL_MINUTES must be positive")
+	}
+
+	if MailDriver != MailDriverSMTP && MailDriver != MailDriverFile && MailDriver != MailDriverLog {
+		log.Panicf("❌ MAIL_DRIVER must be %q, %q or %q, got %q", MailDriverSMTP, MailDriverFile, MailDriverLog, MailDriver)
+	}
+
+	if MailMaxAttempts <= 0 || MailPollInterval < time.Second {
+		log.Panic("❌ MAIL_MAX_ATTEMPTS must be positive and MAIL_POLL_INTERVAL at least 1s")
+	}
+
+	for _, offset := range ReminderOffsets {
+		if offset < time.Minute || offset%time.Minute != 0 {
+			log.Panicf("❌ REMINDER_OFFSETS must be whole minutes of at least 1m, got %s", offset)
+		}
+	}
+	if ReminderInterval < 10*time.Second {
+		log.Panic("❌ REMINDER_INTERVAL must be at least 10s")
+	}
+
+	if WebhookTimeout < time.Second || WebhookTimeout > time.Minute {
+		log.Panic("❌ WEBHOOK_TIMEOUT must be between 1s and 1m")
+	}
+	if WebhookMaxAttempts <= 0 || WebhookPollInterval < time.Second {
+		log.Panic("❌ WEBHOOK_MAX_ATTEMPTS must be positive and WEBHOOK_POLL_INTERVAL at least 1s")
+	}
+	if EventRelayInterval < time.Second {
+		log.Panic("❌ EVENT_RELAY_INTERVAL must be at least 1s")
+	}
+	if StreamHeartbeat < time.Second || StreamMaxAge < time.Minute {
+		log.Panic("❌ STREAM_HEARTBEAT must be at least 1s and STREAM_MAX_AGE at least 1m")
+	}
+
+	if MailDriver == MailDriverSMTP && (SMTPUser == "" || SMTPPass == "") {
 		log.Println("⚠ Warning: SMTP credentials are not set")
 	}
 }
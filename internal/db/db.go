@@ -6,20 +6,49 @@ import (
 
 	"github.com/FiraBro/local-go/internal/config"
 	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
 )
 
+// InitDB opens the database selected by config.DBDriver.
 func InitDB() *sql.DB {
-	log.Println("📦 Connecting to PostgreSQL:", config.DBAddr)
+	if config.DBDriver == config.DriverSQLite {
+		log.Println("📦 Opening SQLite database:", config.DBAddr)
+	} else {
+		log.Println("📦 Connecting to PostgreSQL:", config.DBAddr)
+	}
 
-	db, err := sql.Open("postgres", config.DBAddr)
+	db, err := open()
 	if err != nil {
-		log.Fatal("❌ Failed to connect to PostgreSQL:", err)
+		log.Fatal("❌ Failed to connect to database:", err)
 	}
 
-	if err := db.Ping(); err != nil {
-		log.Fatal("❌ PostgreSQL ping failed:", err)
+	log.Println("✅ Database connected successfully")
+	return db
+}
+
+// open returns a pinged handle for config.DBDriver / config.DBAddr.
+func open() (*sql.DB, error) {
+	driverName := "postgres"
+	if config.DBDriver == config.DriverSQLite {
+		driverName = "sqlite3"
 	}
 
-	log.Println("✅ PostgreSQL connected successfully")
-	return db
+	db, err := sql.Open(driverName, config.DBAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.DBDriver == config.DriverSQLite {
+		// SQLite allows a single writer; one shared connection serialises
+		// writes instead of failing with "database is locked". Migrations
+		// run on a connection of their own, so the database must be a file:
+		// a ":memory:" one would be a different, empty database each time.
+		db.SetMaxOpenConns(1)
+	}
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
 }
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/FiraBro/local-go/internal/config"
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+)
+
+// Migrations for both backends are compiled into the binary. The version
+// table is golang-migrate's schema_migrations, so databases migrated with the
+// migrate CLI are picked up as-is.
+//
+//go:embed migrations/postgres/*.sql migrations/sqlite/*.sql
+var migrationFiles embed.FS
+
+// MigrationStatus describes where the database schema stands relative to the
+// migrations embedded in this binary.
+type MigrationStatus struct {
+	Current uint // 0 when no migration has been applied
+	Latest  uint
+	Dirty   bool
+}
+
+// Pending is the number of embedded migrations not yet applied.
+func (s MigrationStatus) Pending() int {
+	if s.Current >= s.Latest {
+		return 0
+	}
+	return int(s.Latest - s.Current)
+}
+
+// MigrateUp applies every pending migration.
+func MigrateUp() error {
+	return withMigrator(func(m *migrate.Migrate) error {
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			return err
+		}
+		return nil
+	})
+}
+
+// MigrateDown rolls back the last steps migrations.
+func MigrateDown(steps int) error {
+	if steps < 1 {
+		return fmt.Errorf("steps must be at least 1, got %d", steps)
+	}
+	return withMigrator(func(m *migrate.Migrate) error {
+		return m.Steps(-steps)
+	})
+}
+
+// GetMigrationStatus reports the applied and the latest embedded version.
+func GetMigrationStatus() (MigrationStatus, error) {
+	var status MigrationStatus
+
+	latest, err := latestVersion()
+	if err != nil {
+		return status, err
+	}
+	status.Latest = latest
+
+	err = withMigrator(func(m *migrate.Migrate) error {
+		version, dirty, err := m.Version()
+		if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+			return err
+		}
+		status.Current, status.Dirty = version, dirty
+		return nil
+	})
+	return status, err
+}
+
+// CheckSchema refuses to go on when the database is behind (or ahead of) the
+// embedded migrations, or a previous migration failed half-way.
+func CheckSchema() error {
+	status, err := GetMigrationStatus()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case status.Dirty:
+		return fmt.Errorf("schema version %d is dirty: a migration failed, fix it and force the version with the migrate CLI", status.Current)
+	case status.Current < status.Latest:
+		return fmt.Errorf("schema is at version %d but this build needs %d: run `server migrate up` or start with -auto-migrate", status.Current, status.Latest)
+	case status.Current > status.Latest:
+		return fmt.Errorf("schema version %d is newer than this build (%d): deploy a newer binary", status.Current, status.Latest)
+	}
+	return nil
+}
+
+// withMigrator runs fn against a dedicated connection that is closed
+// afterwards, so the application pool is never handed to golang-migrate.
+func withMigrator(fn func(m *migrate.Migrate) error) error {
+	conn, err := open()
+	if err != nil {
+		return err
+	}
+
+	var driver database.Driver
+	if config.DBDriver == config.DriverSQLite {
+		driver, err = sqlite3.WithInstance(conn, &sqlite3.Config{})
+	} else {
+		driver, err = postgres.WithInstance(conn, &postgres.Config{})
+	}
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	source, err := iofs.New(migrationFiles, migrationDir())
+	if err != nil {
+		conn.Close()
+		return err
+	}
+
+	m, err := migrate.NewWithInstance("iofs", source, config.DBDriver, driver)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	m.Log = migrateLogger{}
+
+	defer func() {
+		m.Close()
+		conn.Close() // the postgres driver only releases its own connection
+	}()
+	return fn(m)
+}
+
+func migrationDir() string {
+	if config.DBDriver == config.DriverSQLite {
+		return "migrations/sqlite"
+	}
+	return "migrations/postgres"
+}
+
+// latestVersion is the highest version among the embedded migration files.
+func latestVersion() (uint, error) {
+	entries, err := fs.ReadDir(migrationFiles, migrationDir())
+	if err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	for _, e := range entries {
+		prefix, _, found := strings.Cut(e.Name(), "_")
+		if !found {
+			continue
+		}
+		v, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		if uint(v) > latest {
+			latest = uint(v)
+		}
+	}
+	return latest, nil
+}
+
+type migrateLogger struct{}
+
+func (migrateLogger) Printf(format string, v ...interface{}) {
+	log.Printf("🗄️  "+format, v...)
+}
+
+func (migrateLogger) Verbose() bool { return false }
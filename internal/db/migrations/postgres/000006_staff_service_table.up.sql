@@ -0,0 +1,20 @@
+-- staff_services references services, which used to be created only in
+-- 000009; create it here so a fresh database migrates in order. 000009 keeps
+-- its IF NOT EXISTS and is a no-op on new installs.
+CREATE TABLE IF NOT EXISTS services (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    category VARCHAR(100),
+    price NUMERIC(10,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Re-create it with service_id instead of service_name
+CREATE TABLE staff_services (
+    id UUID PRIMARY KEY,
+    staff_id UUID NOT NULL REFERENCES staff(id) ON DELETE CASCADE,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    UNIQUE(staff_id, service_id)
+);
@@ -0,0 +1,3 @@
+-- services is created (and dropped) by 000006 now; staff_services still
+-- references it at this version.
+SELECT 1;
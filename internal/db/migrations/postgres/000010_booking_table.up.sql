@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS bookings (
+    id UUID PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    staff_id UUID NOT NULL REFERENCES staff(id) ON DELETE CASCADE,
+    booking_date DATE NOT NULL,
+    start_time TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'confirmed',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Only one live booking per staff slot; cancelled rows free the slot again.
+CREATE UNIQUE INDEX IF NOT EXISTS bookings_staff_slot_unique
+    ON bookings (staff_id, booking_date, start_time)
+    WHERE status <> 'cancelled';
+
+CREATE INDEX IF NOT EXISTS bookings_user_idx ON bookings (user_id);
@@ -0,0 +1,15 @@
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS bookings_no_overlap;
+
+CREATE UNIQUE INDEX IF NOT EXISTS bookings_staff_slot_unique
+    ON bookings (staff_id, booking_date, start_time)
+    WHERE status <> 'cancelled';
+
+ALTER TABLE bookings
+    DROP COLUMN IF EXISTS end_time,
+    DROP COLUMN IF EXISTS block_start_minute,
+    DROP COLUMN IF EXISTS block_end_minute;
+
+ALTER TABLE services
+    DROP COLUMN IF EXISTS duration_minutes,
+    DROP COLUMN IF EXISTS buffer_before_minutes,
+    DROP COLUMN IF EXISTS buffer_after_minutes;
@@ -0,0 +1,35 @@
+ALTER TABLE services
+    ADD COLUMN IF NOT EXISTS duration_minutes INT NOT NULL DEFAULT 30,
+    ADD COLUMN IF NOT EXISTS buffer_before_minutes INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS buffer_after_minutes INT NOT NULL DEFAULT 0;
+
+-- Bookings now occupy a time range instead of a single start time
+ALTER TABLE bookings
+    ADD COLUMN IF NOT EXISTS end_time TEXT,
+    ADD COLUMN IF NOT EXISTS block_start_minute INT,
+    ADD COLUMN IF NOT EXISTS block_end_minute INT;
+
+UPDATE bookings
+SET block_start_minute = split_part(start_time, ':', 1)::INT * 60 + split_part(start_time, ':', 2)::INT,
+    block_end_minute   = split_part(start_time, ':', 1)::INT * 60 + split_part(start_time, ':', 2)::INT + 30,
+    end_time           = to_char(start_time::TIME + INTERVAL '30 minutes', 'HH24:MI')
+WHERE block_start_minute IS NULL;
+
+ALTER TABLE bookings
+    ALTER COLUMN end_time SET NOT NULL,
+    ALTER COLUMN block_start_minute SET NOT NULL,
+    ALTER COLUMN block_end_minute SET NOT NULL;
+
+-- Replace the exact-slot unique index with an overlap check so a 90 minute
+-- booking also blocks the slots it runs into.
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+DROP INDEX IF EXISTS bookings_staff_slot_unique;
+
+ALTER TABLE bookings
+    ADD CONSTRAINT bookings_no_overlap
+    EXCLUDE USING gist (
+        staff_id WITH =,
+        booking_date WITH =,
+        int4range(block_start_minute, block_end_minute) WITH &&
+    ) WHERE (status <> 'cancelled');
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS tickets;
+DROP TABLE IF EXISTS ticket_types;
+
+ALTER TABLE events
+    DROP COLUMN IF EXISTS capacity,
+    DROP COLUMN IF EXISTS tickets_sold;
@@ -0,0 +1,35 @@
+-- capacity = 0 means unlimited
+ALTER TABLE events
+    ADD COLUMN IF NOT EXISTS capacity INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS tickets_sold INT NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS ticket_types (
+    id UUID PRIMARY KEY,
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    price NUMERIC(10,2) NOT NULL DEFAULT 0,
+    quantity INT NOT NULL DEFAULT 0, -- 0 = limited only by event capacity
+    sold INT NOT NULL DEFAULT 0,
+    sales_start TIMESTAMP NULL,
+    sales_end TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS ticket_types_event_idx ON ticket_types (event_id);
+
+CREATE TABLE IF NOT EXISTS tickets (
+    id UUID PRIMARY KEY,
+    event_id UUID NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    ticket_type_id UUID NOT NULL REFERENCES ticket_types(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code VARCHAR(32) NOT NULL UNIQUE,
+    price NUMERIC(10,2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'issued',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- One live ticket per user per event
+CREATE UNIQUE INDEX IF NOT EXISTS tickets_event_user_unique
+    ON tickets (event_id, user_id)
+    WHERE status = 'issued';
@@ -0,0 +1,2 @@
+-- StaffRepository has always read and written staff.role
+ALTER TABLE staff ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,13 @@
+-- Hashes cannot be turned back into tokens; everyone has to log in again.
+DELETE FROM refresh_tokens;
+
+DROP INDEX IF EXISTS refresh_tokens_family_idx;
+DROP INDEX IF EXISTS refresh_tokens_user_idx;
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN IF EXISTS family_id,
+    DROP COLUMN IF EXISTS created_at,
+    DROP COLUMN IF EXISTS revoked_at,
+    DROP COLUMN IF EXISTS replaced_by;
+
+ALTER TABLE refresh_tokens RENAME COLUMN token_hash TO token;
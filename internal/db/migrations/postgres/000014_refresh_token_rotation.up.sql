@@ -0,0 +1,19 @@
+-- Refresh tokens are stored as SHA-256 hex digests and grouped into
+-- families: every refresh rotates to a new token in the same family.
+ALTER TABLE refresh_tokens RENAME COLUMN token TO token_hash;
+
+UPDATE refresh_tokens SET token_hash = encode(sha256(token_hash::bytea), 'hex');
+
+ALTER TABLE refresh_tokens
+    ADD COLUMN IF NOT EXISTS family_id UUID,
+    ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    ADD COLUMN IF NOT EXISTS revoked_at TIMESTAMP NULL,
+    ADD COLUMN IF NOT EXISTS replaced_by TEXT NULL;
+
+-- Existing tokens each start their own family
+UPDATE refresh_tokens SET family_id = gen_random_uuid() WHERE family_id IS NULL;
+
+ALTER TABLE refresh_tokens ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_idx ON refresh_tokens (family_id);
+CREATE INDEX IF NOT EXISTS refresh_tokens_user_idx ON refresh_tokens (user_id);
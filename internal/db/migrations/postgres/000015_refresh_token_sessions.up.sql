@@ -0,0 +1,6 @@
+-- Each refresh token family is a session; keep enough about the device to
+-- show it back to the user.
+ALTER TABLE refresh_tokens
+    ADD COLUMN IF NOT EXISTS user_agent TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS ip_address TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMP NOT NULL DEFAULT NOW();
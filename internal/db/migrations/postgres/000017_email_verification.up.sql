@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS verified_at TIMESTAMP NULL;
+
+-- Accounts created before verification existed are trusted as-is
+UPDATE users SET verified_at = NOW() WHERE verified_at IS NULL;
@@ -0,0 +1,7 @@
+ALTER TABLE staff RENAME COLUMN title TO role;
+
+UPDATE users SET role = 'user' WHERE role NOT IN ('admin');
+
+DROP TABLE IF EXISTS role_permissions;
+DROP TABLE IF EXISTS permissions;
+DROP TABLE IF EXISTS roles;
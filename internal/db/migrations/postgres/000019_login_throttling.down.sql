@@ -0,0 +1,2 @@
+ALTER TABLE reset_tokens DROP COLUMN IF EXISTS attempts;
+DROP TABLE IF EXISTS login_attempts;
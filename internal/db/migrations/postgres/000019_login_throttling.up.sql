@@ -0,0 +1,15 @@
+-- Failed sign-in attempts per account (lower-cased email) and per client IP.
+-- Backoff and lockout are derived from failures and last_failure_at; a row
+-- whose last failure is older than the lockout window counts as clean.
+CREATE TABLE IF NOT EXISTS login_attempts (
+    scope VARCHAR(20) NOT NULL,           -- 'account' or 'ip'
+    identifier VARCHAR(255) NOT NULL,
+    failures INTEGER NOT NULL,
+    last_failure_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (scope, identifier)
+);
+
+-- Wrong OTPs per reset token; the token is burned after too many. otp holds
+-- a bcrypt hash, which never fit the original VARCHAR(10).
+ALTER TABLE reset_tokens ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE reset_tokens ALTER COLUMN otp TYPE TEXT;
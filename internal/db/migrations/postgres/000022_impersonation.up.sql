@@ -0,0 +1,34 @@
+-- Support staff can act as a customer. Each impersonation and every request
+-- made with it is kept; user ids are not foreign keys so the trail outlives
+-- purged accounts.
+INSERT INTO permissions (name, description) VALUES
+    ('users:impersonate', 'Act as another user for support; every request is recorded');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'users:impersonate');
+
+CREATE TABLE IF NOT EXISTS impersonations (
+    id UUID PRIMARY KEY,
+    actor_id UUID NOT NULL,
+    target_id UUID NOT NULL,
+    reason TEXT NOT NULL,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP NOT NULL,
+    ended_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS impersonations_actor_idx ON impersonations (actor_id);
+CREATE INDEX IF NOT EXISTS impersonations_target_idx ON impersonations (target_id);
+
+CREATE TABLE IF NOT EXISTS impersonation_requests (
+    id UUID PRIMARY KEY,
+    impersonation_id UUID NOT NULL REFERENCES impersonations(id) ON DELETE CASCADE,
+    method VARCHAR(10) NOT NULL,
+    path TEXT NOT NULL,
+    status INT NOT NULL,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS impersonation_requests_session_idx ON impersonation_requests (impersonation_id, created_at);
@@ -0,0 +1,34 @@
+-- Append-only log of administrative and security-relevant actions. changes
+-- holds a JSON object of {"field": {"before": ..., "after": ...}}. User ids
+-- are not foreign keys so entries outlive purged accounts.
+INSERT INTO permissions (name, description) VALUES
+    ('audit:read', 'View the audit log');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'audit:read');
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id UUID PRIMARY KEY,
+    actor_id UUID NULL, -- NULL for actions by the system or anonymous requests
+    impersonator_id UUID NULL,
+    api_key_id UUID NULL,
+    action VARCHAR(100) NOT NULL,
+    target_type VARCHAR(100) NOT NULL,
+    target_id TEXT NOT NULL DEFAULT '',
+    changes TEXT NULL,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_created_idx ON audit_events (created_at);
+CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON audit_events (actor_id, created_at);
+CREATE INDEX IF NOT EXISTS audit_events_target_idx ON audit_events (target_type, target_id, created_at);
+
+CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update BEFORE UPDATE OR DELETE ON audit_events
+    FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
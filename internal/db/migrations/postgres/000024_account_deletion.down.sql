@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS account_restore_tokens;
+DROP INDEX IF EXISTS users_delete_deadline_idx;
+ALTER TABLE users DROP COLUMN IF EXISTS deletion_reminded_at;
@@ -0,0 +1,15 @@
+-- When the owner of a deleted account was reminded that it is about to be
+-- purged; cleared on restore so a later deletion reminds again.
+ALTER TABLE users ADD COLUMN deletion_reminded_at TIMESTAMP NULL;
+
+CREATE INDEX IF NOT EXISTS users_delete_deadline_idx ON users (delete_deadline);
+
+-- Restore links sent to (or handed at login to) the owner of a deleted
+-- account; only hashes are stored, and all of them go once it is restored.
+CREATE TABLE IF NOT EXISTS account_restore_tokens (
+    token_hash TEXT PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS account_restore_tokens_user_idx ON account_restore_tokens (user_id);
@@ -0,0 +1,19 @@
+-- Outgoing email, rendered when queued and delivered by a background worker.
+-- Pending messages are retried with backoff until they are sent or run out
+-- of attempts; bodies are cleared once a message is sent or given up on.
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id UUID PRIMARY KEY,
+    template VARCHAR(100) NOT NULL,
+    recipient VARCHAR(254) NOT NULL,
+    subject TEXT NOT NULL,
+    text_body TEXT NOT NULL,
+    html_body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL,          -- 'pending', 'sent' or 'failed'
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    sent_at TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS email_outbox_due_idx ON email_outbox (status, next_attempt_at);
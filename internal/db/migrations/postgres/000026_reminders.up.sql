@@ -0,0 +1,21 @@
+-- Reminders already sent, one row per item (booking or ticket), offset
+-- before its start and channel. Inserting the row claims the reminder, so it
+-- goes out once even with several instances or after a restart.
+CREATE TABLE IF NOT EXISTS reminders_sent (
+    kind VARCHAR(20) NOT NULL,
+    item_id TEXT NOT NULL,
+    offset_minutes INTEGER NOT NULL,
+    channel VARCHAR(20) NOT NULL,
+    sent_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (kind, item_id, offset_minutes, channel)
+);
+
+CREATE INDEX IF NOT EXISTS reminders_sent_sent_at_idx ON reminders_sent (sent_at);
+
+-- Channels a user does not want reminders on; no row means opted in.
+CREATE TABLE IF NOT EXISTS notification_opt_outs (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    channel VARCHAR(20) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, channel)
+);
@@ -0,0 +1,14 @@
+-- Domain events written in the same transaction as the change that raised
+-- them and relayed to the in-process bus once committed. Relayed rows keep
+-- published_at set until they are cleaned up.
+CREATE TABLE IF NOT EXISTS event_outbox (
+    id UUID PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    payload TEXT NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    published_at TIMESTAMP NULL
+);
+
+CREATE INDEX IF NOT EXISTS event_outbox_due_idx ON event_outbox (published_at, next_attempt_at);
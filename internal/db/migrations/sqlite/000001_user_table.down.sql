@@ -0,0 +1,2 @@
+-- Down migration: drop users table
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,9 @@
+CREATE TABLE users (
+    id TEXT PRIMARY KEY,
+    username TEXT NOT NULL,
+    email TEXT NOT NULL UNIQUE,
+    password TEXT NOT NULL,
+    role TEXT NOT NULL,
+    deleted_at TIMESTAMP NULL,
+    delete_deadline TIMESTAMP NULL
+);
@@ -0,0 +1,8 @@
+CREATE TABLE events (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL,
+    description TEXT,
+    location TEXT,
+    user_id TEXT REFERENCES users(id),
+    date_time TIMESTAMP NOT NULL
+);
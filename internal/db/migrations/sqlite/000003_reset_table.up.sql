@@ -0,0 +1,6 @@
+CREATE TABLE reset_tokens (
+    email TEXT NOT NULL,
+    otp TEXT NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (email, otp)
+);
@@ -0,0 +1,5 @@
+CREATE TABLE refresh_tokens (
+    token TEXT PRIMARY KEY,
+    user_id TEXT REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL
+);
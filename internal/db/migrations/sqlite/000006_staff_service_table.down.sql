@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS staff_services;
+DROP TABLE IF EXISTS services;
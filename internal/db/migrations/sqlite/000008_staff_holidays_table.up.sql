@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS staff_holidays (
+    id TEXT PRIMARY KEY,
+    staff_id TEXT NOT NULL REFERENCES staff(id) ON DELETE CASCADE,
+    date DATE NOT NULL,
+    reason TEXT
+);
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS bookings (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    service_id TEXT NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    staff_id TEXT NOT NULL REFERENCES staff(id) ON DELETE CASCADE,
+    booking_date DATE NOT NULL,
+    start_time TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'confirmed',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Only one live booking per staff slot; cancelled rows free the slot again.
+CREATE UNIQUE INDEX IF NOT EXISTS bookings_staff_slot_unique
+    ON bookings (staff_id, booking_date, start_time)
+    WHERE status <> 'cancelled';
+
+CREATE INDEX IF NOT EXISTS bookings_user_idx ON bookings (user_id);
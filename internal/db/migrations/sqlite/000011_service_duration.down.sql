@@ -0,0 +1,14 @@
+DROP TRIGGER IF EXISTS bookings_no_overlap_insert;
+DROP TRIGGER IF EXISTS bookings_no_overlap_update;
+
+CREATE UNIQUE INDEX IF NOT EXISTS bookings_staff_slot_unique
+    ON bookings (staff_id, booking_date, start_time)
+    WHERE status <> 'cancelled';
+
+ALTER TABLE bookings DROP COLUMN end_time;
+ALTER TABLE bookings DROP COLUMN block_start_minute;
+ALTER TABLE bookings DROP COLUMN block_end_minute;
+
+ALTER TABLE services DROP COLUMN duration_minutes;
+ALTER TABLE services DROP COLUMN buffer_before_minutes;
+ALTER TABLE services DROP COLUMN buffer_after_minutes;
@@ -0,0 +1,46 @@
+ALTER TABLE services ADD COLUMN duration_minutes INTEGER NOT NULL DEFAULT 30;
+ALTER TABLE services ADD COLUMN buffer_before_minutes INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE services ADD COLUMN buffer_after_minutes INTEGER NOT NULL DEFAULT 0;
+
+-- Bookings now occupy a time range instead of a single start time
+ALTER TABLE bookings ADD COLUMN end_time TEXT NOT NULL DEFAULT '';
+ALTER TABLE bookings ADD COLUMN block_start_minute INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE bookings ADD COLUMN block_end_minute INTEGER NOT NULL DEFAULT 0;
+
+UPDATE bookings
+SET block_start_minute = CAST(substr(start_time, 1, 2) AS INTEGER) * 60 + CAST(substr(start_time, 4, 2) AS INTEGER),
+    block_end_minute   = CAST(substr(start_time, 1, 2) AS INTEGER) * 60 + CAST(substr(start_time, 4, 2) AS INTEGER) + 30,
+    end_time           = strftime('%H:%M', start_time, '+30 minutes');
+
+DROP INDEX IF EXISTS bookings_staff_slot_unique;
+
+-- SQLite has no exclusion constraints; these triggers play the part of
+-- bookings_no_overlap. Writes are serialised, so the check cannot race.
+CREATE TRIGGER bookings_no_overlap_insert
+BEFORE INSERT ON bookings
+WHEN NEW.status <> 'cancelled' AND EXISTS (
+    SELECT 1 FROM bookings b
+    WHERE b.staff_id = NEW.staff_id
+      AND b.booking_date = NEW.booking_date
+      AND b.status <> 'cancelled'
+      AND b.block_start_minute < NEW.block_end_minute
+      AND NEW.block_start_minute < b.block_end_minute
+)
+BEGIN
+    SELECT RAISE(ABORT, 'bookings_no_overlap');
+END;
+
+CREATE TRIGGER bookings_no_overlap_update
+BEFORE UPDATE OF status, booking_date, block_start_minute, block_end_minute ON bookings
+WHEN NEW.status <> 'cancelled' AND EXISTS (
+    SELECT 1 FROM bookings b
+    WHERE b.id <> NEW.id
+      AND b.staff_id = NEW.staff_id
+      AND b.booking_date = NEW.booking_date
+      AND b.status <> 'cancelled'
+      AND b.block_start_minute < NEW.block_end_minute
+      AND NEW.block_start_minute < b.block_end_minute
+)
+BEGIN
+    SELECT RAISE(ABORT, 'bookings_no_overlap');
+END;
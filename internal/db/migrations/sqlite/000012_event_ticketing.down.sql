@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS tickets;
+DROP TABLE IF EXISTS ticket_types;
+
+ALTER TABLE events DROP COLUMN capacity;
+ALTER TABLE events DROP COLUMN tickets_sold;
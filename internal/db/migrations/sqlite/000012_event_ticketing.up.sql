@@ -0,0 +1,34 @@
+-- capacity = 0 means unlimited
+ALTER TABLE events ADD COLUMN capacity INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE events ADD COLUMN tickets_sold INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS ticket_types (
+    id TEXT PRIMARY KEY,
+    event_id TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    price NUMERIC NOT NULL DEFAULT 0,
+    quantity INTEGER NOT NULL DEFAULT 0, -- 0 = limited only by event capacity
+    sold INTEGER NOT NULL DEFAULT 0,
+    sales_start TIMESTAMP NULL,
+    sales_end TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS ticket_types_event_idx ON ticket_types (event_id);
+
+CREATE TABLE IF NOT EXISTS tickets (
+    id TEXT PRIMARY KEY,
+    event_id TEXT NOT NULL REFERENCES events(id) ON DELETE CASCADE,
+    ticket_type_id TEXT NOT NULL REFERENCES ticket_types(id) ON DELETE CASCADE,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code TEXT NOT NULL UNIQUE,
+    price NUMERIC NOT NULL DEFAULT 0,
+    status TEXT NOT NULL DEFAULT 'issued',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One live ticket per user per event
+CREATE UNIQUE INDEX IF NOT EXISTS tickets_event_user_unique
+    ON tickets (event_id, user_id)
+    WHERE status = 'issued';
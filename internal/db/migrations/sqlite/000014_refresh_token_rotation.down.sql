@@ -0,0 +1,12 @@
+-- Hashes cannot be turned back into tokens; everyone has to log in again.
+DELETE FROM refresh_tokens;
+
+DROP INDEX IF EXISTS refresh_tokens_family_idx;
+DROP INDEX IF EXISTS refresh_tokens_user_idx;
+
+ALTER TABLE refresh_tokens DROP COLUMN family_id;
+ALTER TABLE refresh_tokens DROP COLUMN created_at;
+ALTER TABLE refresh_tokens DROP COLUMN revoked_at;
+ALTER TABLE refresh_tokens DROP COLUMN replaced_by;
+
+ALTER TABLE refresh_tokens RENAME COLUMN token_hash TO token;
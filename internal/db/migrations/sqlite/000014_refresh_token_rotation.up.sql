@@ -0,0 +1,14 @@
+-- Refresh tokens are stored as SHA-256 hex digests and grouped into
+-- families: every refresh rotates to a new token in the same family.
+-- SQLite has no sha256(), so existing plain-text tokens are dropped.
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens RENAME COLUMN token TO token_hash;
+
+ALTER TABLE refresh_tokens ADD COLUMN family_id TEXT NOT NULL DEFAULT '';
+ALTER TABLE refresh_tokens ADD COLUMN created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE refresh_tokens ADD COLUMN revoked_at TIMESTAMP NULL;
+ALTER TABLE refresh_tokens ADD COLUMN replaced_by TEXT NULL;
+
+CREATE INDEX IF NOT EXISTS refresh_tokens_family_idx ON refresh_tokens (family_id);
+CREATE INDEX IF NOT EXISTS refresh_tokens_user_idx ON refresh_tokens (user_id);
@@ -0,0 +1,56 @@
+-- Roles and permissions are data: routes check permissions, users get a role.
+CREATE TABLE IF NOT EXISTS roles (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT '',
+    is_system BOOLEAN NOT NULL DEFAULT FALSE, -- seeded with the app
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name TEXT PRIMARY KEY,
+    description TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role TEXT NOT NULL REFERENCES roles(name) ON DELETE CASCADE,
+    permission TEXT NOT NULL REFERENCES permissions(name) ON DELETE CASCADE,
+    PRIMARY KEY (role, permission)
+);
+
+INSERT INTO permissions (name, description) VALUES
+    ('users:read', 'List and view user accounts'),
+    ('users:write', 'Create, update, delete users and change their role'),
+    ('roles:manage', 'Create roles and grant or revoke permissions'),
+    ('security:manage', 'Change authentication policy such as required MFA'),
+    ('services:write', 'Create, update and delete services'),
+    ('staff:write', 'Create, update and delete staff members and their services'),
+    ('staff:schedule', 'Set staff working hours and holidays'),
+    ('bookings:manage', 'View and cancel any booking'),
+    ('events:manage', 'Edit, delete and manage tickets of any event');
+
+INSERT INTO roles (name, description, is_system) VALUES
+    ('admin', 'Full access', TRUE),
+    ('manager', 'Runs day-to-day operations', TRUE),
+    ('staff', 'Staff member', TRUE),
+    ('customer', 'Books services and registers for events', TRUE);
+
+-- admin gets every permission; later migrations that add permissions grant
+-- them to admin as well
+INSERT INTO role_permissions (role, permission)
+    SELECT 'admin', name FROM permissions;
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('manager', 'users:read'),
+    ('manager', 'services:write'),
+    ('manager', 'staff:write'),
+    ('manager', 'staff:schedule'),
+    ('manager', 'bookings:manage'),
+    ('manager', 'events:manage'),
+    ('staff', 'staff:schedule'),
+    ('staff', 'bookings:manage');
+
+-- "user" was the old default; anything else unknown becomes a customer too
+UPDATE users SET role = 'customer' WHERE role NOT IN (SELECT name FROM roles);
+
+-- staff.role was a free-text job title, unrelated to user roles
+ALTER TABLE staff RENAME COLUMN role TO title;
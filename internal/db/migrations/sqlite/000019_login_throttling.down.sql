@@ -0,0 +1,2 @@
+ALTER TABLE reset_tokens DROP COLUMN attempts;
+DROP TABLE IF EXISTS login_attempts;
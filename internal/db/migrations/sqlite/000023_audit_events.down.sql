@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS audit_events;
+
+DELETE FROM permissions WHERE name = 'audit:read';
@@ -0,0 +1,35 @@
+-- Append-only log of administrative and security-relevant actions. changes
+-- holds a JSON object of {"field": {"before": ..., "after": ...}}. User ids
+-- are not foreign keys so entries outlive purged accounts.
+INSERT INTO permissions (name, description) VALUES
+    ('audit:read', 'View the audit log');
+
+INSERT INTO role_permissions (role, permission) VALUES
+    ('admin', 'audit:read');
+
+CREATE TABLE IF NOT EXISTS audit_events (
+    id TEXT PRIMARY KEY,
+    actor_id TEXT NULL, -- NULL for actions by the system or anonymous requests
+    impersonator_id TEXT NULL,
+    api_key_id TEXT NULL,
+    action TEXT NOT NULL,
+    target_type TEXT NOT NULL,
+    target_id TEXT NOT NULL DEFAULT '',
+    changes TEXT NULL,
+    ip TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS audit_events_created_idx ON audit_events (created_at);
+CREATE INDEX IF NOT EXISTS audit_events_actor_idx ON audit_events (actor_id, created_at);
+CREATE INDEX IF NOT EXISTS audit_events_target_idx ON audit_events (target_type, target_id, created_at);
+
+CREATE TRIGGER IF NOT EXISTS audit_events_no_update BEFORE UPDATE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_events_no_delete BEFORE DELETE ON audit_events
+BEGIN
+    SELECT RAISE(ABORT, 'audit_events is append-only');
+END;
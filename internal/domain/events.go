@@ -0,0 +1,173 @@
+// Package domain defines the events services publish on the bus once a
+// change is saved. Each event marshals to the JSON that webhooks carry as
+// "data" and that the outbox stores.
+package domain
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/bus"
+	"github.com/FiraBro/local-go/internal/models"
+)
+
+// ---- USERS ----
+
+// User is what user events tell about an account; never the password.
+type User struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+}
+
+func UserOf(u *models.User) User {
+	return User{ID: u.ID, Username: u.Username, Email: u.Email, Role: u.Role}
+}
+
+type UserCreated struct{ User }
+
+type UserUpdated struct{ User }
+
+// UserDeleted is a soft deletion; the account is purged after
+// DeleteDeadline unless restored.
+type UserDeleted struct {
+	ID             string    `json:"id"`
+	DeleteDeadline time.Time `json:"delete_deadline"`
+}
+
+type UserRestored struct {
+	ID string `json:"id"`
+}
+
+type UserPurged struct {
+	ID string `json:"id"`
+}
+
+func (UserCreated) EventName() string  { return "user.created" }
+func (UserUpdated) EventName() string  { return "user.updated" }
+func (UserDeleted) EventName() string  { return "user.deleted" }
+func (UserRestored) EventName() string { return "user.restored" }
+func (UserPurged) EventName() string   { return "user.purged" }
+
+// ---- SERVICES ----
+
+type ServiceCreated struct{ models.Service }
+
+type ServiceUpdated struct{ models.Service }
+
+type ServiceDeleted struct{ models.Service }
+
+func (ServiceCreated) EventName() string { return "service.created" }
+func (ServiceUpdated) EventName() string { return "service.updated" }
+func (ServiceDeleted) EventName() string { return "service.deleted" }
+
+// ---- STAFF ----
+
+type StaffCreated struct{ models.Staff }
+
+type StaffUpdated struct{ models.Staff }
+
+type StaffDeleted struct{ models.Staff }
+
+// StaffServicesAssigned replaced the services a staff member offers.
+type StaffServicesAssigned struct {
+	StaffID  string   `json:"staff_id"`
+	Services []string `json:"services"`
+}
+
+// StaffScheduleChanged replaced a staff member's weekly schedule.
+type StaffScheduleChanged struct {
+	StaffID  string              `json:"staff_id"`
+	Schedule []map[string]string `json:"schedule"`
+}
+
+type StaffHolidayAdded struct {
+	StaffID string `json:"staff_id"`
+	Date    string `json:"holiday"` // YYYY-MM-DD
+	Reason  string `json:"reason"`
+}
+
+func (StaffCreated) EventName() string          { return "staff.created" }
+func (StaffUpdated) EventName() string          { return "staff.updated" }
+func (StaffDeleted) EventName() string          { return "staff.deleted" }
+func (StaffServicesAssigned) EventName() string { return "staff.services_assigned" }
+func (StaffScheduleChanged) EventName() string  { return "staff.schedule_changed" }
+func (StaffHolidayAdded) EventName() string     { return "staff.holiday_added" }
+
+// ---- EVENTS & TICKETS ----
+
+type EventCreated struct{ models.Event }
+
+type EventUpdated struct{ models.Event }
+
+type EventDeleted struct{ models.Event }
+
+type TicketIssued struct{ models.Ticket }
+
+type TicketCancelled struct {
+	EventID string `json:"event_id"`
+	UserID  string `json:"user_id"`
+}
+
+func (EventCreated) EventName() string    { return "event.created" }
+func (EventUpdated) EventName() string    { return "event.updated" }
+func (EventDeleted) EventName() string    { return "event.deleted" }
+func (TicketIssued) EventName() string    { return "ticket.issued" }
+func (TicketCancelled) EventName() string { return "ticket.cancelled" }
+
+// ---- BOOKINGS ----
+
+type BookingCreated struct{ models.Booking }
+
+type BookingCancelled struct{ models.Booking }
+
+func (BookingCreated) EventName() string   { return "booking.created" }
+func (BookingCancelled) EventName() string { return "booking.cancelled" }
+
+// ---- DECODING ----
+
+var decoders = map[string]func([]byte) (bus.Event, error){}
+
+func register[E bus.Event]() {
+	var zero E
+	decoders[zero.EventName()] = func(payload []byte) (bus.Event, error) {
+		var e E
+		err := json.Unmarshal(payload, &e)
+		return e, err
+	}
+}
+
+func init() {
+	register[UserCreated]()
+	register[UserUpdated]()
+	register[UserDeleted]()
+	register[UserRestored]()
+	register[UserPurged]()
+	register[ServiceCreated]()
+	register[ServiceUpdated]()
+	register[ServiceDeleted]()
+	register[StaffCreated]()
+	register[StaffUpdated]()
+	register[StaffDeleted]()
+	register[StaffServicesAssigned]()
+	register[StaffScheduleChanged]()
+	register[StaffHolidayAdded]()
+	register[EventCreated]()
+	register[EventUpdated]()
+	register[EventDeleted]()
+	register[TicketIssued]()
+	register[TicketCancelled]()
+	register[BookingCreated]()
+	register[BookingCancelled]()
+}
+
+// Decode turns an event stored by name back into its type.
+func Decode(name string, payload []byte) (bus.Event, error) {
+	decode, ok := decoders[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown event %q", name)
+	}
+	return decode(payload)
+}
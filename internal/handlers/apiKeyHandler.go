@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/FiraBro/local-go/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyHandler struct {
+	service *services.APIKeyService
+}
+
+func NewAPIKeyHandler(service *services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+// GET /auth/api-keys
+func (h *APIKeyHandler) List(c *gin.Context) {
+	keys, err := h.service.List(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to fetch API keys"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": keys})
+}
+
+// POST /auth/api-keys
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var body struct {
+		Name          string   `json:"name" binding:"required"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "name is required"})
+		return
+	}
+
+	raw, key, err := h.service.Create(c.Request.Context(), c.GetString("user_id"), c.GetString("role"), body.Name, body.Scopes, body.ExpiresInDays)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrInvalidAPIKeyName),
+			errors.Is(err, services.ErrAPIKeyExpiry),
+			errors.Is(err, services.ErrTooManyAPIKeys):
+			status = http.StatusBadRequest
+		case errors.Is(err, services.ErrAPIKeyScope):
+			status = http.StatusForbidden
+		}
+		c.JSON(status, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "API key created. Store it now, it will not be shown again",
+		"data":    gin.H{"key": raw, "api_key": key},
+	})
+}
+
+// DELETE /auth/api-keys/:id
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	err := h.service.Revoke(c.Request.Context(), c.GetString("user_id"), c.Param("id"))
+	if errors.Is(err, services.ErrAPIKeyNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to revoke API key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "API key revoked"})
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type AuditHandler struct {
+	service *services.AuditService
+}
+
+func NewAuditHandler(service *services.AuditService) *AuditHandler {
+	return &AuditHandler{service: service}
+}
+
+// GET /audit?actor_id=&target_type=&target_id=&action=&from=&to=&limit=&offset=
+// from and to are RFC 3339 times; to is exclusive.
+func (h *AuditHandler) List(c *gin.Context) {
+	filter := models.AuditFilter{
+		ActorID:    c.Query("actor_id"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		Action:     c.Query("action"),
+	}
+
+	var err error
+	if from := c.Query("from"); from != "" {
+		if filter.From, err = time.Parse(time.RFC3339, from); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "from must be an RFC 3339 time"})
+			return
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		if filter.To, err = time.Parse(time.RFC3339, to); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "to must be an RFC 3339 time"})
+			return
+		}
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	events, err := h.service.List(filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to fetch audit events"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": events})
+}
@@ -1,9 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"github.com/FiraBro/local-go/internal/middlewares"
 	"github.com/FiraBro/local-go/internal/models"
 	"github.com/FiraBro/local-go/internal/services"
 	"github.com/gin-gonic/gin"
@@ -31,14 +36,12 @@ func (h *AuthHandler) Register(c *gin.Context) {
 		return
 	}
 
-	// Assign ID and default role
+	// Assign ID; self-registered accounts always get the default role
 	input.ID = uuid.New().String()
-	if input.Role == "" {
-		input.Role = "user"
-	}
+	input.Role = models.DefaultRole
 
 	// Call service
-	if err := h.authService.Register(&input); err != nil {
+	if err := h.authService.Register(c.Request.Context(), &input); err != nil {
 		// Log for server-side debugging
 		log.Println("Register error:", err)
 
@@ -79,7 +82,14 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
-	accessToken, refreshToken, user, err := h.authService.Login(input.Email, input.Password)
+	result, err := h.authService.Login(input.Email, input.Password, clientInfo(c))
+	if tooManyAttempts(c, err) {
+		return
+	}
+	if errors.Is(err, services.ErrEmailNotVerified) {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": err.Error()})
+		return
+	}
 	if err != nil {
 		log.Println("❌ Login failed:", err)
 		c.JSON(http.StatusUnauthorized, gin.H{
@@ -89,19 +99,107 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	loginResponse(c, result)
+}
+
+// loginResponse answers a successful first login step: either the session,
+// or the MFA challenge still to be completed, or for an account scheduled
+// for deletion, the token to restore it with.
+func loginResponse(c *gin.Context, result *services.LoginResult) {
+	if result.RestoreToken != "" {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "This account is scheduled for deletion; restore it to log in again",
+			"data": gin.H{
+				"pending_deletion": true,
+				"delete_deadline":  result.DeleteDeadline,
+				"restore_token":    result.RestoreToken,
+			},
+		})
+		return
+	}
+
+	if result.MFAToken != "" {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "MFA required",
+			"data": gin.H{
+				"mfa_required":            true,
+				"mfa_enrollment_required": result.MFAEnrollment,
+				"mfa_token":               result.MFAToken,
+			},
+		})
+		return
+	}
+
+	loginSuccess(c, result)
+}
+
+// ----------------------------
+// LOGIN: MFA SECOND STEP
+// ----------------------------
+func (h *AuthHandler) LoginMFA(c *gin.Context) {
+	var body struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "mfa_token and code are required"})
+		return
+	}
+
+	result, err := h.authService.CompleteMFALogin(body.MFAToken, body.Code, clientInfo(c))
+	if tooManyAttempts(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	loginSuccess(c, result)
+}
+
+// LoginMFASetup starts enrollment for users whose role requires MFA
+func (h *AuthHandler) LoginMFASetup(c *gin.Context) {
+	var body struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "mfa_token is required"})
+		return
+	}
+
+	setup, err := h.authService.BeginMFALoginSetup(body.MFAToken)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": setup})
+}
+
+func loginSuccess(c *gin.Context, result *services.LoginResult) {
+	data := gin.H{
+		"access_token":  result.AccessToken,
+		"refresh_token": result.RefreshToken,
+		"user": gin.H{
+			"user_id":  result.User.ID,
+			"username": result.User.Username,
+			"email":    result.User.Email,
+			"role":     result.User.Role,
+		},
+	}
+	if result.RecoveryCodes != nil {
+		data["recovery_codes"] = result.RecoveryCodes
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Login successful",
-		"data": gin.H{
-			"access_token":  accessToken,
-			"refresh_token": refreshToken,
-			"user": gin.H{
-				"user_id":  user.ID,
-				"username": user.Username,
-				"email":    user.Email,
-				"role":     user.Role,
-			},
-		},
+		"data":    data,
 	})
 }
 
@@ -118,13 +216,14 @@ func (h *AuthHandler) Refresh(c *gin.Context) {
 		return
 	}
 
-	accessToken, err := h.authService.RefreshToken(body.RefreshToken)
+	accessToken, refreshToken, err := h.authService.RefreshToken(body.RefreshToken, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"success": true, "access_token": accessToken})
+	// The presented refresh token is now spent; clients must keep the new one
+	c.JSON(http.StatusOK, gin.H{"success": true, "access_token": accessToken, "refresh_token": refreshToken})
 }
 
 // ----------------------------
@@ -140,7 +239,7 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.Logout(body.RefreshToken); err != nil {
+	if err := h.authService.Logout(body.RefreshToken, clientInfo(c)); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to logout"})
 		return
 	}
@@ -148,6 +247,371 @@ func (h *AuthHandler) Logout(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Logged out successfully"})
 }
 
+// ----------------------------
+// SESSIONS
+// ----------------------------
+func (h *AuthHandler) ListSessions(c *gin.Context) {
+	sessions, err := h.authService.ListSessions(c.GetString("user_id"), c.GetString("session_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to fetch sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": sessions})
+}
+
+func (h *AuthHandler) RevokeSession(c *gin.Context) {
+	err := h.authService.RevokeSession(c.Request.Context(), c.GetString("user_id"), c.Param("id"))
+	if errors.Is(err, services.ErrSessionNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to revoke session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Session revoked"})
+}
+
+func (h *AuthHandler) LogoutAll(c *gin.Context) {
+	if err := h.authService.LogoutAll(c.Request.Context(), c.GetString("user_id")); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to logout"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Logged out of all sessions"})
+}
+
+// ----------------------------
+// MFA
+// ----------------------------
+func (h *AuthHandler) GetMFAStatus(c *gin.Context) {
+	status, err := h.authService.MFAStatus(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to fetch MFA status"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": status})
+}
+
+func (h *AuthHandler) SetupMFA(c *gin.Context) {
+	setup, err := h.authService.BeginMFASetup(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": setup})
+}
+
+func (h *AuthHandler) ConfirmMFA(c *gin.Context) {
+	var body struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Code is required"})
+		return
+	}
+
+	codes, err := h.authService.ConfirmMFA(c.Request.Context(), c.GetString("user_id"), body.Code)
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "MFA enabled. Store these recovery codes somewhere safe; they will not be shown again.",
+		"data":    gin.H{"recovery_codes": codes},
+	})
+}
+
+func (h *AuthHandler) DisableMFA(c *gin.Context) {
+	var body struct {
+		Password string `json:"password" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Password and code are required"})
+		return
+	}
+
+	err := h.authService.DisableMFA(c.Request.Context(), c.GetString("user_id"), body.Password, body.Code, clientInfo(c))
+	if tooManyAttempts(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "MFA disabled"})
+}
+
+func (h *AuthHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	var body struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Code is required"})
+		return
+	}
+
+	codes, err := h.authService.RegenerateRecoveryCodes(c.Request.Context(), c.GetString("user_id"), body.Code, clientInfo(c))
+	if tooManyAttempts(c, err) {
+		return
+	}
+	if err != nil {
+		c.JSON(mfaErrorStatus(err), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"recovery_codes": codes}})
+}
+
+// GET /auth/mfa/policy (admin)
+func (h *AuthHandler) GetMFAPolicy(c *gin.Context) {
+	roles, err := h.authService.GetMFARequiredRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to fetch MFA policy"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{"required_roles": roles}})
+}
+
+// PUT /auth/mfa/policy (admin)
+func (h *AuthHandler) SetMFAPolicy(c *gin.Context) {
+	var body struct {
+		Role     string `json:"role" binding:"required"`
+		Required *bool  `json:"required" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "role and required are required"})
+		return
+	}
+
+	if err := h.authService.SetMFARequiredForRole(c.Request.Context(), body.Role, *body.Required); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "MFA policy updated"})
+}
+
+func mfaErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrMFAInvalidCode), errors.Is(err, services.ErrMFATokenInvalid):
+		return http.StatusUnauthorized
+	case errors.Is(err, services.ErrMFAAlreadyEnabled),
+		errors.Is(err, services.ErrMFANotEnabled),
+		errors.Is(err, services.ErrMFANotSetUp):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrMFARequired):
+		return http.StatusForbidden
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// ----------------------------
+// LOGIN THROTTLING (admin)
+// ----------------------------
+
+// GET /auth/lockouts
+func (h *AuthHandler) ListLoginAttempts(c *gin.Context) {
+	attempts, err := h.authService.ListLoginAttempts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to fetch failed login attempts"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": attempts})
+}
+
+// DELETE /auth/lockouts/:scope/:identifier (scope is account or ip)
+func (h *AuthHandler) ClearLoginAttempts(c *gin.Context) {
+	err := h.authService.ClearLoginAttempts(c.Request.Context(), c.Param("scope"), c.Param("identifier"))
+	switch {
+	case errors.Is(err, services.ErrInvalidScope):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	case errors.Is(err, services.ErrLockoutNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to clear lockout"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Lockout cleared"})
+}
+
+// tooManyAttempts answers 429 with Retry-After when err is a throttling
+// refusal, and reports whether it did.
+func tooManyAttempts(c *gin.Context, err error) bool {
+	var throttled *services.TooManyAttemptsError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{"success": false, "message": throttled.Error()})
+	return true
+}
+
+// ----------------------------
+// SOCIAL LOGIN (OIDC)
+// ----------------------------
+
+// GET /auth/oidc/providers
+func (h *AuthHandler) OIDCProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": h.authService.OIDCProviders()})
+}
+
+// GET /auth/oidc/:provider/login redirects the browser to the provider.
+func (h *AuthHandler) OIDCLogin(c *gin.Context) {
+	authURL, err := h.authService.BeginOIDCLogin(c.Request.Context(), c.Param("provider"))
+	if errors.Is(err, services.ErrUnknownProvider) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrOIDCFailed) {
+		c.JSON(http.StatusBadGateway, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if err != nil {
+		log.Println("❌ OIDC login failed:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to start login"})
+		return
+	}
+
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// GET /auth/oidc/:provider/callback?code=&state= answers like Login.
+func (h *AuthHandler) OIDCCallback(c *gin.Context) {
+	if providerErr := c.Query("error"); providerErr != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Login was cancelled or denied: " + providerErr})
+		return
+	}
+	if c.Query("code") == "" || c.Query("state") == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "code and state are required"})
+		return
+	}
+
+	result, err := h.authService.CompleteOIDCLogin(c.Request.Context(), c.Param("provider"), c.Query("state"), c.Query("code"), clientInfo(c))
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrUnknownProvider):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrOIDCStateInvalid),
+			errors.Is(err, services.ErrOIDCFailed),
+			errors.Is(err, services.ErrOIDCNoEmail):
+			status = http.StatusUnauthorized
+		case errors.Is(err, services.ErrOIDCEmailTaken):
+			status = http.StatusConflict
+		case errors.Is(err, services.ErrOIDCAccountDeleted),
+			errors.Is(err, services.ErrEmailNotVerified):
+			status = http.StatusForbidden
+		default:
+			log.Println("❌ OIDC callback failed:", err)
+			err = errors.New("failed to log in")
+		}
+		c.JSON(status, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	loginResponse(c, result)
+}
+
+// GET /auth/identities
+func (h *AuthHandler) ListIdentities(c *gin.Context) {
+	identities, err := h.authService.ListIdentities(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to fetch linked accounts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": identities})
+}
+
+// DELETE /auth/identities/:id
+func (h *AuthHandler) UnlinkIdentity(c *gin.Context) {
+	err := h.authService.UnlinkIdentity(c.Request.Context(), c.GetString("user_id"), c.Param("id"))
+	if errors.Is(err, services.ErrIdentityNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to unlink account"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Account unlinked"})
+}
+
+func clientInfo(c *gin.Context) models.ClientInfo {
+	return models.ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	}
+}
+
+// ----------------------------
+// EMAIL VERIFICATION
+// ----------------------------
+// Accepts the token from the emailed link (?token=) or a JSON body.
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		var body struct {
+			Token string `json:"token"`
+		}
+		_ = c.ShouldBindJSON(&body)
+		token = body.Token
+	}
+
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Verification token is required"})
+		return
+	}
+
+	if err := h.authService.VerifyEmail(token); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrVerificationTokenInvalid) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Email verified successfully"})
+}
+
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var body struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Valid email is required"})
+		return
+	}
+
+	h.authService.ResendVerification(body.Email)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "If the email exists and is unverified, a verification link has been sent",
+	})
+}
+
 // ----------------------------
 // FORGOT PASSWORD
 // ----------------------------
@@ -161,7 +625,8 @@ func (h *AuthHandler) ForgotPassword(c *gin.Context) {
 		return
 	}
 
-	_ = h.authService.ForgotPassword(body.Email)
+	// Always the same answer, so the endpoint can't be used to probe accounts
+	_ = h.authService.ForgotPassword(body.Email, clientInfo(c))
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
@@ -176,7 +641,7 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 	var body struct {
 		Email       string `json:"email" binding:"required,email"`
 		OTP         string `json:"otp" binding:"required"`
-		NewPassword string `json:"new_password" binding:"required,min=6"`
+		NewPassword string `json:"new_password" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&body); err != nil {
@@ -184,14 +649,29 @@ func (h *AuthHandler) ResetPassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.ResetPassword(body.Email, body.OTP, body.NewPassword); err != nil {
+	err := h.authService.ResetPassword(body.Email, body.OTP, body.NewPassword, clientInfo(c))
+	if tooManyAttempts(c, err) {
+		return
+	}
+	if errors.Is(err, services.ErrInvalidOTP) || errors.Is(err, services.ErrOTPBurned) ||
+		errors.Is(err, services.ErrWeakPassword) || errors.Is(err, services.ErrBreachedPassword) {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
 		return
 	}
+	if err != nil {
+		log.Println("❌ Reset password failed:", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to reset password"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Password reset successfully"})
 }
 
+// GET /auth/password-policy lets clients check new passwords before sending them.
+func (h *AuthHandler) PasswordPolicy(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": h.authService.PasswordPolicy()})
+}
+
 // ----------------------------
 // GET PROFILE
 // ----------------------------
@@ -204,10 +684,11 @@ func (h *AuthHandler) GetProfile(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{"success": true, "data": gin.H{
-		"user_id":  user.ID,
-		"username": user.Username,
-		"email":    user.Email,
-		"role":     user.Role,
+		"user_id":        user.ID,
+		"username":       user.Username,
+		"email":          user.Email,
+		"role":           user.Role,
+		"email_verified": user.VerifiedAt != nil,
 	}})
 }
 
@@ -244,7 +725,20 @@ func (h *AuthHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.UpdateProfile(userID, body.Username, body.Email); err != nil {
+	// Support staff may fix a name for a customer, but not take over their login
+	if c.GetString("impersonation_id") != "" && strings.TrimSpace(body.Email) != "" {
+		current, err := h.authService.FetchUser(userID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "User not found"})
+			return
+		}
+		if !strings.EqualFold(strings.TrimSpace(body.Email), current.Email) {
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Email cannot be changed while impersonating"})
+			return
+		}
+	}
+
+	if err := h.authService.UpdateProfile(c.Request.Context(), userID, body.Username, body.Email); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to update profile"})
 		return
 	}
@@ -260,7 +754,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 
 	var body struct {
 		OldPassword string `json:"old_password" binding:"required"`
-		NewPassword string `json:"new_password" binding:"required,min=6"`
+		NewPassword string `json:"new_password" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&body); err != nil {
@@ -268,7 +762,7 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.ChangePassword(userID, body. OldPassword, body.NewPassword); err != nil {
+	if err := h.authService.ChangePassword(c.Request.Context(), userID, body.OldPassword, body.NewPassword, c.GetString("session_id")); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
 		return
 	}
@@ -277,12 +771,12 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 }
 
 // ----------------------------
-// SOFT DELETE USER (14-day restore)
+// SOFT DELETE USER (restorable until the deadline)
 // ----------------------------
 func (h *AuthHandler) DeleteUser(c *gin.Context) {
 	userID := c.GetString("user_id")
 
-	err := h.authService.SoftDeleteUser(userID)
+	deadline, err := h.authService.SoftDeleteUser(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to delete user"})
 		return
@@ -290,35 +784,81 @@ func (h *AuthHandler) DeleteUser(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Your account has been scheduled for deletion. You have 14 days to restore it.",
+		"message": "Your account has been scheduled for deletion. Log in or use the emailed link to restore it before the deadline.",
+		"data":    gin.H{"delete_deadline": deadline},
 	})
 }
 
+// DELETE /users/:id schedules another user's account for deletion.
+func (h *AuthHandler) DeleteUserByID(c *gin.Context) {
+	_, err := h.authService.SoftDeleteUser(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, services.ErrUserNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user scheduled for deletion"})
+}
+
 // ----------------------------
-// RESTORE USER
+// RESTORE ACCOUNT
 // ----------------------------
-func (h *AuthHandler) RestoreUser(c *gin.Context) {
-	userID := c.GetString("user_id")
+// GET (emailed link) or POST /auth/restore-account with the restore token
+// from the deletion emails or from logging in to a deleted account.
+func (h *AuthHandler) RestoreAccount(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		var body struct {
+			Token string `json:"token"`
+		}
+		_ = c.ShouldBindJSON(&body)
+		token = body.Token
+	}
+
+	if token == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Restore token is required"})
+		return
+	}
 
-	err := h.authService.RestoreUser(userID)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+	if err := h.authService.RestoreAccount(c.Request.Context(), token, clientInfo(c)); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrRestoreTokenInvalid) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"success": false, "message": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"message": "Account restored successfully",
+		"message": "Account restored successfully, you can log in again",
 	})
 }
 
+// POST /users/:id/restore restores another user's deleted account.
+func (h *AuthHandler) RestoreUserByID(c *gin.Context) {
+	err := h.authService.RestoreUser(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, services.ErrUserNotDeleted) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to restore user"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "user restored"})
+}
+
 // ----------------------------
 // FETCH ALL USERS (Admin only)
 // ----------------------------
 func (h *AuthHandler) FetchAllUsers(c *gin.Context) {
-	role := c.GetString("role")
-
-	if role != "admin" {
+	if !middlewares.HasPermission(c, models.PermUsersRead) {
 		c.JSON(http.StatusForbidden, gin.H{
 			"success": false,
 			"message": "Access denied",
@@ -374,8 +914,15 @@ func (h *AuthHandler) UpdateUserRole(c *gin.Context) {
 		return
 	}
 
-	if err := h.authService.UpdateUserRole(id, body.Role); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.authService.UpdateUserRole(c.Request.Context(), id, body.Role); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrRoleNotFound) {
+			status = http.StatusBadRequest
+		}
+		if errors.Is(err, services.ErrUserNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -394,7 +941,7 @@ func (h *AuthHandler) CreateUserHandler(c *gin.Context) {
 		return
 	}
 
-	err := h.authService.CreateUser(&input)
+	err := h.authService.CreateUser(c.Request.Context(), &input)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -416,7 +963,7 @@ func (h *AuthHandler) UpdateUserHandler(c *gin.Context) {
 	}
 
 	input.ID = userID
-	err := h.authService.UpdateUser(&input)
+	err := h.authService.UpdateUser(c.Request.Context(), &input)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/FiraBro/local-go/internal/middlewares"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type BookingHandler struct {
+	service *services.BookingService
+}
+
+func NewBookingHandler(service *services.BookingService) *BookingHandler {
+	return &BookingHandler{service: service}
+}
+
+// POST /bookings
+func (h *BookingHandler) Create(c *gin.Context) {
+	var req models.CreateBookingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "service_id, staff_id, date and start_time are required"})
+		return
+	}
+
+	booking, err := h.service.Create(c.Request.Context(), c.GetString("user_id"), &req)
+	if err != nil {
+		c.JSON(bookingErrorStatus(err), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "data": booking})
+}
+
+// GET /bookings
+func (h *BookingHandler) ListMine(c *gin.Context) {
+	bookings, err := h.service.ListForUser(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to fetch bookings"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": bookings})
+}
+
+// GET /bookings/:id
+func (h *BookingHandler) GetByID(c *gin.Context) {
+	booking, err := h.service.GetByID(c.Request.Context(), c.Param("id"), c.GetString("user_id"), middlewares.HasPermission(c, models.PermBookingsManage))
+	if err != nil {
+		c.JSON(bookingErrorStatus(err), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": booking})
+}
+
+// POST /bookings/:id/cancel
+func (h *BookingHandler) Cancel(c *gin.Context) {
+	booking, err := h.service.Cancel(c.Request.Context(), c.Param("id"), c.GetString("user_id"), middlewares.HasPermission(c, models.PermBookingsManage))
+	if err != nil {
+		c.JSON(bookingErrorStatus(err), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": booking})
+}
+
+func bookingErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrBookingNotFound), errors.Is(err, services.ErrServiceNotFound),
+		errors.Is(err, services.ErrStaffNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrBookingForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrSlotUnavailable):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,7 +1,10 @@
 package handlers
 
 import (
+	"errors"
+	"github.com/FiraBro/local-go/internal/middlewares"
 	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/repositories"
 	"github.com/FiraBro/local-go/internal/services"
 	"net/http"
 
@@ -25,7 +28,12 @@ func (h *EventHandler) CreateEvent(c *gin.Context) {
 	}
 
 	e.ID = uuid.New().String()
-	if err := h.service.CreateEvent(&e); err != nil {
+	e.UserId = c.GetString("user_id") // owner always comes from the JWT
+	if err := h.service.CreateEvent(c.Request.Context(), &e); err != nil {
+		if errors.Is(err, services.ErrInvalidInput) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create event"})
 		return
 	}
@@ -33,7 +41,7 @@ func (h *EventHandler) CreateEvent(c *gin.Context) {
 }
 
 func (h *EventHandler) GetEvents(c *gin.Context) {
-	events, err := h.service.GetAllEvents()
+	events, err := h.service.GetAllEvents(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch events"})
 		return
@@ -43,7 +51,7 @@ func (h *EventHandler) GetEvents(c *gin.Context) {
 
 func (h *EventHandler) GetEventByID(c *gin.Context) {
 	id := c.Param("id")
-	event, err := h.service.GetEventByID(id)
+	event, err := h.service.GetEventByID(c.Request.Context(), id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
 		return
@@ -62,7 +70,11 @@ func (h *EventHandler) UpdateEvent(c *gin.Context) {
 
 	e.ID = id // Ensure we update the correct ID
 
-	if err := h.service.UpdateEvent(&e); err != nil {
+	if err := h.service.UpdateEvent(c.Request.Context(), &e); err != nil {
+		if errors.Is(err, services.ErrInvalidInput) || errors.Is(err, services.ErrEventNotFound) {
+			c.JSON(ticketErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update event"})
 		return
 	}
@@ -73,10 +85,114 @@ func (h *EventHandler) UpdateEvent(c *gin.Context) {
 func (h *EventHandler) DeleteEvent(c *gin.Context) {
 	id := c.Param("id")
 
-	if err := h.service.DeleteEvent(id); err != nil {
+	if err := h.service.DeleteEvent(c.Request.Context(), id); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete event"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Event deleted successfully"})
 }
+
+// EventOwnerID resolves the owner of the :id event for middlewares.OwnerOrPermission.
+// Unknown events resolve to "", which only an event manager gets past.
+func (h *EventHandler) EventOwnerID(c *gin.Context) string {
+	event, err := h.service.GetEventByID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		return ""
+	}
+	return event.UserId
+}
+
+// ---------- TICKETING ----------
+
+// POST /events/:id/ticket-types
+func (h *EventHandler) CreateTicketType(c *gin.Context) {
+	var tt models.TicketType
+	if err := c.ShouldBindJSON(&tt); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.AddTicketType(c.Request.Context(), c.Param("id"), c.GetString("user_id"), middlewares.HasPermission(c, models.PermEventsManage), &tt); err != nil {
+		c.JSON(ticketErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Ticket type created", "ticket_type": tt})
+}
+
+// GET /events/:id/ticket-types
+func (h *EventHandler) GetTicketTypes(c *gin.Context) {
+	types, err := h.service.GetTicketTypes(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(ticketErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ticket_types": types})
+}
+
+// POST /events/:id/register
+func (h *EventHandler) Register(c *gin.Context) {
+	var body struct {
+		TicketTypeID string `json:"ticket_type_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ticket_type_id is required"})
+		return
+	}
+
+	ticket, err := h.service.Register(c.Request.Context(), c.Param("id"), body.TicketTypeID, c.GetString("user_id"))
+	if err != nil {
+		c.JSON(ticketErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Registered for event", "ticket": ticket})
+}
+
+// DELETE /events/:id/register
+func (h *EventHandler) CancelRegistration(c *gin.Context) {
+	if err := h.service.CancelRegistration(c.Request.Context(), c.Param("id"), c.GetString("user_id")); err != nil {
+		c.JSON(ticketErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Registration cancelled"})
+}
+
+// GET /events/:id/attendees
+func (h *EventHandler) GetAttendees(c *gin.Context) {
+	attendees, err := h.service.GetAttendees(c.Request.Context(), c.Param("id"), c.GetString("user_id"), middlewares.HasPermission(c, models.PermEventsManage))
+	if err != nil {
+		c.JSON(ticketErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"attendees": attendees, "count": len(attendees)})
+}
+
+// GET /tickets
+func (h *EventHandler) GetMyTickets(c *gin.Context) {
+	tickets, err := h.service.GetMyTickets(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tickets"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"tickets": tickets})
+}
+
+func ticketErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrEventNotFound),
+		errors.Is(err, services.ErrTicketTypeNotFound),
+		errors.Is(err, services.ErrTicketNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrNotEventOwner):
+		return http.StatusForbidden
+	case errors.Is(err, repositories.ErrEventSoldOut),
+		errors.Is(err, repositories.ErrAlreadyRegistered),
+		errors.Is(err, services.ErrTicketNotOnSale),
+		errors.Is(err, services.ErrEventPast):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
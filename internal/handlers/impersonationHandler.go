@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/FiraBro/local-go/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ImpersonationHandler struct {
+	service *services.ImpersonationService
+}
+
+func NewImpersonationHandler(service *services.ImpersonationService) *ImpersonationHandler {
+	return &ImpersonationHandler{service: service}
+}
+
+// POST /users/:id/impersonate
+func (h *ImpersonationHandler) Start(c *gin.Context) {
+	var body struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "reason is required"})
+		return
+	}
+
+	token, imp, err := h.service.Start(c.Request.Context(), c.GetString("user_id"), c.GetString("role"), c.Param("id"), body.Reason, c.ClientIP())
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrImpersonateSelf),
+			errors.Is(err, services.ErrImpersonationReason):
+			status = http.StatusBadRequest
+		case errors.Is(err, services.ErrImpersonationTarget):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrImpersonationEscalation):
+			status = http.StatusForbidden
+		default:
+			err = errors.New("failed to start impersonation")
+		}
+		c.JSON(status, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Impersonation started; every request made with this token is recorded",
+		"data": gin.H{
+			"access_token":  token,
+			"impersonation": imp,
+		},
+	})
+}
+
+// DELETE /auth/impersonation ends the impersonation the token belongs to.
+func (h *ImpersonationHandler) End(c *gin.Context) {
+	id := c.GetString("impersonation_id")
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "This token is not impersonating anyone"})
+		return
+	}
+
+	err := h.service.End(c.Request.Context(), id)
+	if errors.Is(err, services.ErrImpersonationAlreadyOver) {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to end impersonation"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Impersonation ended"})
+}
+
+// GET /impersonations?actor_id=&target_id=
+func (h *ImpersonationHandler) List(c *gin.Context) {
+	list, err := h.service.List(c.Query("actor_id"), c.Query("target_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to fetch impersonations"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": list})
+}
+
+// GET /impersonations/:id/requests
+func (h *ImpersonationHandler) Requests(c *gin.Context) {
+	imp, requests, err := h.service.Requests(c.Param("id"))
+	if errors.Is(err, services.ErrImpersonationNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to fetch impersonation requests"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    gin.H{"impersonation": imp, "requests": requests},
+	})
+}
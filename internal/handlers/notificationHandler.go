@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/FiraBro/local-go/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type NotificationHandler struct {
+	service *services.ReminderService
+}
+
+func NewNotificationHandler(service *services.ReminderService) *NotificationHandler {
+	return &NotificationHandler{service: service}
+}
+
+// GET /auth/profile/notifications
+// Whether the user gets reminders on each channel, e.g. {"email": true}.
+func (h *NotificationHandler) GetPreferences(c *gin.Context) {
+	prefs, err := h.service.Preferences(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to fetch notification preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": prefs})
+}
+
+// PUT /auth/profile/notifications
+// Body: {"email": false} turns email reminders off; channels left out keep
+// their setting.
+func (h *NotificationHandler) UpdatePreferences(c *gin.Context) {
+	var body map[string]bool
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Body must map channels to true or false"})
+		return
+	}
+
+	prefs, err := h.service.UpdatePreferences(c.Request.Context(), c.GetString("user_id"), body)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownChannel) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to update notification preferences"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": prefs})
+}
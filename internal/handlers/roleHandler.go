@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/repositories"
+	"github.com/FiraBro/local-go/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+	service *services.RoleService
+}
+
+func NewRoleHandler(service *services.RoleService) *RoleHandler {
+	return &RoleHandler{service: service}
+}
+
+// GET /roles
+func (h *RoleHandler) ListRoles(c *gin.Context) {
+	roles, err := h.service.ListRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to fetch roles"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": roles})
+}
+
+// GET /permissions
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	perms, err := h.service.ListPermissions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to fetch permissions"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": perms})
+}
+
+// POST /roles
+func (h *RoleHandler) CreateRole(c *gin.Context) {
+	var role models.Role
+	if err := c.ShouldBindJSON(&role); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request payload"})
+		return
+	}
+
+	if err := h.service.CreateRole(c.Request.Context(), &role); err != nil {
+		c.JSON(roleErrorStatus(err), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Role created", "data": role})
+}
+
+// PUT /roles/:name/permissions/:permission
+func (h *RoleHandler) Grant(c *gin.Context) {
+	if err := h.service.Grant(c.Request.Context(), c.Param("name"), c.Param("permission")); err != nil {
+		c.JSON(roleErrorStatus(err), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Permission granted"})
+}
+
+// DELETE /roles/:name/permissions/:permission
+func (h *RoleHandler) Revoke(c *gin.Context) {
+	if err := h.service.Revoke(c.Request.Context(), c.Param("name"), c.Param("permission")); err != nil {
+		c.JSON(roleErrorStatus(err), gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Permission revoked"})
+}
+
+func roleErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrRoleNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, repositories.ErrRoleExists):
+		return http.StatusConflict
+	case errors.Is(err, services.ErrProtectedRole):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrUnknownPermission), errors.Is(err, services.ErrInvalidRoleName):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/FiraBro/local-go/internal/models"
@@ -82,6 +83,10 @@ func (h *ServiceHandler) Update(c *gin.Context) {
 func (h *ServiceHandler) Delete(c *gin.Context) {
     id := c.Param("id")
     if err := h.service.Delete(c.Request.Context(), id); err != nil {
+        if errors.Is(err, services.ErrServiceNotFound) {
+            c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error()})
+            return
+        }
         c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Could not delete service"})
         return
     }
@@ -1,8 +1,8 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
-	"sort"
 
 	"github.com/FiraBro/local-go/internal/models"
 	"github.com/FiraBro/local-go/internal/services"
@@ -79,6 +79,10 @@ func (h *StaffHandler) Update(c *gin.Context) {
 func (h *StaffHandler) Delete(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.service.Delete(c.Request.Context(), id); err != nil {
+		if errors.Is(err, services.ErrStaffNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete staff"})
 		return
 	}
@@ -181,7 +185,8 @@ func (h *StaffHandler) AddHoliday(c *gin.Context) {
 
 // ---------- AVAILABILITY ----------
 
-// GET /availability/staff/:staffId?date=2025-12-25
+// GET /availability/staff/:staffId?date=2025-12-25&service_id=...
+// service_id is optional; when given, slots are sized by the service duration.
 func (h *StaffHandler) GetStaffAvailability(c *gin.Context) {
 	staffID := c.Param("staffId")
 	date := c.Query("date")
@@ -191,7 +196,17 @@ func (h *StaffHandler) GetStaffAvailability(c *gin.Context) {
 		return
 	}
 
-	slots, err := h.availabilityService.GetStaffSlots(c.Request.Context(), staffID, date)
+	var service *models.Service
+	if serviceID := c.Query("service_id"); serviceID != "" {
+		svc, err := h.service.GetService(c.Request.Context(), serviceID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+			return
+		}
+		service = svc
+	}
+
+	slots, err := h.availabilityService.GetStaffSlots(c.Request.Context(), staffID, date, service)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to calculate slots"})
 		return
@@ -209,29 +224,16 @@ func (h *StaffHandler) GetServiceAvailability(c *gin.Context) {
 		return
 	}
 
-	// Fetch staff members capable of performing this service via the service layer
-	staffList, err := h.service.GetStaffByService(c.Request.Context(), serviceID)
+	service, err := h.service.GetService(c.Request.Context(), serviceID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch qualified staff"})
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
 		return
 	}
 
-	uniqueSlots := make(map[string]bool)
-	for _, staff := range staffList {
-		slots, err := h.availabilityService.GetStaffSlots(c.Request.Context(), staff.ID, date)
-		if err != nil {
-			continue // Skip individual staff errors to provide partial results if possible
-		}
-		for _, slot := range slots {
-			uniqueSlots[slot] = true
-		}
-	}
-
-	result := []string{}
-	for slot := range uniqueSlots {
-		result = append(result, slot)
+	slots, err := h.availabilityService.GetServiceSlots(c.Request.Context(), service, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch qualified staff"})
+		return
 	}
-	sort.Strings(result)
-
-	c.JSON(http.StatusOK, gin.H{"service_id": serviceID, "date": date, "available_slots": result})
+	c.JSON(http.StatusOK, gin.H{"service_id": serviceID, "date": date, "available_slots": slots})
 }
\ No newline at end of file
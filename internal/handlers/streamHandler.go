@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/config"
+	"github.com/FiraBro/local-go/internal/services"
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+type StreamHandler struct {
+	service *services.StreamService
+}
+
+func NewStreamHandler(service *services.StreamService) *StreamHandler {
+	return &StreamHandler{service: service}
+}
+
+// GET /stream?topics=availability:<serviceId>:<date>,staff:<staffId>:<date>,bookings
+// Server-sent events: the current state of each topic, then every change to
+// it. Reconnecting with Last-Event-ID (or ?last_event_id=) sends only what
+// changed in between.
+func (h *StreamHandler) Stream(c *gin.Context) {
+	var names []string
+	for _, value := range c.QueryArray("topics") {
+		names = append(names, strings.Split(value, ",")...)
+	}
+	topics, err := h.service.ParseTopics(names, c.GetString("user_id"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidStreamTopic) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to open stream"})
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+
+	ctx := c.Request.Context()
+	client := h.service.Subscribe(ctx, topics, lastEventID)
+	defer h.service.Unsubscribe(client)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // keep proxies from buffering the stream
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(config.StreamHeartbeat)
+	defer heartbeat.Stop()
+	// Streams end now and then so the client reconnects, and so authenticates again
+	maxAge := time.NewTimer(config.StreamMaxAge)
+	defer maxAge.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case msg := <-client.Messages():
+			c.Render(-1, sse.Event{Id: msg.ID, Event: msg.Event, Data: msg})
+			return true
+		case <-heartbeat.C:
+			_, err := io.WriteString(w, ": heartbeat\n\n")
+			return err == nil
+		case <-client.Dropped():
+			return false
+		case <-maxAge.C:
+			return false
+		case <-ctx.Done():
+			return false
+		}
+	})
+}
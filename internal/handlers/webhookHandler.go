@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type WebhookHandler struct {
+	service *services.WebhookService
+}
+
+func NewWebhookHandler(service *services.WebhookService) *WebhookHandler {
+	return &WebhookHandler{service: service}
+}
+
+// GET /webhooks/event-types
+func (h *WebhookHandler) EventTypes(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": models.WebhookEventTypes})
+}
+
+// GET /webhooks
+func (h *WebhookHandler) List(c *gin.Context) {
+	endpoints, err := h.service.ListEndpoints()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to fetch webhooks"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": endpoints})
+}
+
+// GET /webhooks/:id
+func (h *WebhookHandler) Get(c *gin.Context) {
+	endpoint, err := h.service.GetEndpoint(c.Param("id"))
+	if err != nil {
+		webhookError(c, err, "Failed to fetch webhook")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": endpoint})
+}
+
+// POST /webhooks
+// Body: {"url": "https://...", "event_types": ["booking.created"], "description": "..."}
+func (h *WebhookHandler) Create(c *gin.Context) {
+	var body models.WebhookEndpointRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input"})
+		return
+	}
+
+	endpoint, secret, err := h.service.CreateEndpoint(c.Request.Context(), &body, c.GetString("user_id"))
+	if err != nil {
+		webhookError(c, err, "Failed to create webhook")
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Webhook created. Store the secret now, it will not be shown again",
+		"data":    gin.H{"secret": secret, "webhook": endpoint},
+	})
+}
+
+// PATCH /webhooks/:id
+func (h *WebhookHandler) Update(c *gin.Context) {
+	var body models.WebhookEndpointRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid input"})
+		return
+	}
+
+	endpoint, err := h.service.UpdateEndpoint(c.Request.Context(), c.Param("id"), &body)
+	if err != nil {
+		webhookError(c, err, "Failed to update webhook")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": endpoint})
+}
+
+// DELETE /webhooks/:id
+func (h *WebhookHandler) Delete(c *gin.Context) {
+	if err := h.service.DeleteEndpoint(c.Request.Context(), c.Param("id")); err != nil {
+		webhookError(c, err, "Failed to delete webhook")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Webhook deleted"})
+}
+
+// POST /webhooks/:id/rotate-secret
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	secret, err := h.service.RotateSecret(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		webhookError(c, err, "Failed to rotate webhook secret")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Secret rotated. Store it now, it will not be shown again",
+		"data":    gin.H{"secret": secret},
+	})
+}
+
+// GET /webhooks/:id/deliveries?status=&event_type=&limit=&offset=
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	filter := models.WebhookDeliveryFilter{
+		EndpointID: c.Param("id"),
+		Status:     c.Query("status"),
+		EventType:  c.Query("event_type"),
+	}
+	filter.Limit, _ = strconv.Atoi(c.Query("limit"))
+	filter.Offset, _ = strconv.Atoi(c.Query("offset"))
+
+	deliveries, err := h.service.ListDeliveries(filter)
+	if err != nil {
+		webhookError(c, err, "Failed to fetch webhook deliveries")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "data": deliveries})
+}
+
+// POST /webhooks/:id/deliveries/:deliveryId/redeliver
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	delivery, err := h.service.Redeliver(c.Request.Context(), c.Param("id"), c.Param("deliveryId"))
+	if err != nil {
+		webhookError(c, err, "Failed to redeliver webhook")
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"success": true, "message": "Delivery queued", "data": delivery})
+}
+
+func webhookError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, services.ErrWebhookNotFound), errors.Is(err, services.ErrWebhookDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": err.Error()})
+	case errors.Is(err, services.ErrInvalidWebhook):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": fallback})
+	}
+}
@@ -0,0 +1,158 @@
+// Package mailer renders transactional emails from templates and delivers
+// them through a pluggable Mailer: SMTP in production, a directory of .eml
+// files or the log in development, and memory in tests.
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/config"
+)
+
+// Message is one rendered email to a single recipient.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer delivers messages. Send returns once the message was handed over
+// (or failed); retrying is up to the caller.
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the Mailer selected by MAIL_DRIVER.
+func New() (Mailer, error) {
+	from, err := mail.ParseAddress(config.MailFrom)
+	if err != nil {
+		return nil, fmt.Errorf("MAIL_FROM: %w", err)
+	}
+
+	switch config.MailDriver {
+	case config.MailDriverSMTP:
+		return &SMTPMailer{
+			Addr: config.SMTPHost + ":" + config.SMTPPort,
+			Host: config.SMTPHost,
+			User: config.SMTPUser,
+			Pass: config.SMTPPass,
+			From: *from,
+		}, nil
+	case config.MailDriverFile:
+		if err := os.MkdirAll(config.MailFileDir, 0o750); err != nil {
+			return nil, err
+		}
+		return &FileMailer{Dir: config.MailFileDir, From: *from}, nil
+	case config.MailDriverLog:
+		return LogMailer{}, nil
+	}
+	return nil, fmt.Errorf("unknown MAIL_DRIVER %q", config.MailDriver)
+}
+
+// ---- SMTP ----
+
+// SMTPMailer sends through an SMTP server, upgrading to TLS with STARTTLS
+// when the server offers it. It authenticates only when User is set.
+type SMTPMailer struct {
+	Addr string
+	Host string
+	User string
+	Pass string
+	From mail.Address
+}
+
+func (m *SMTPMailer) Send(_ context.Context, msg Message) error {
+	raw, err := Build(m.From, msg, time.Now())
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if m.User != "" {
+		auth = smtp.PlainAuth("", m.User, m.Pass, m.Host)
+	}
+	return smtp.SendMail(m.Addr, auth, m.From.Address, []string{msg.To}, raw)
+}
+
+// ---- FILE ----
+
+// FileMailer writes every message as an .eml file into Dir, to be opened
+// with a mail client.
+type FileMailer struct {
+	Dir  string
+	From mail.Address
+}
+
+func (m *FileMailer) Send(_ context.Context, msg Message) error {
+	now := time.Now()
+	raw, err := Build(m.From, msg, now)
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405"), randomHex(4))
+	return os.WriteFile(filepath.Join(m.Dir, name), raw, 0o640)
+}
+
+// ---- LOG ----
+
+// LogMailer only logs the recipient, subject and text body.
+type LogMailer struct{}
+
+func (LogMailer) Send(_ context.Context, msg Message) error {
+	log.Printf("✉️ Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Text)
+	return nil
+}
+
+// ---- MEMORY ----
+
+// MemoryMailer keeps sent messages in memory; Err, when set, is returned
+// instead of sending.
+type MemoryMailer struct {
+	mu   sync.Mutex
+	sent []Message
+	Err  error
+}
+
+func (m *MemoryMailer) Send(_ context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.sent...)
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// domainOf is the part of an address after the @, for Message-IDs.
+func domainOf(address string) string {
+	if _, domain, ok := strings.Cut(address, "@"); ok && domain != "" {
+		return domain
+	}
+	return "localhost"
+}
@@ -0,0 +1,53 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestMemoryMailer(t *testing.T) {
+	first := Message{To: "ada@example.com", Subject: "Welcome", Text: "Hi Ada"}
+	second := Message{To: "bob@example.com", Subject: "Reminder", Text: "Hi Bob", HTML: "<p>Hi Bob</p>"}
+	errDown := errors.New("mail server down")
+
+	tests := []struct {
+		name    string
+		err     error
+		send    []Message
+		wantErr error
+		want    []Message
+	}{
+		{"nothing sent", nil, nil, nil, nil},
+		{"keeps messages in order", nil, []Message{first, second}, nil, []Message{first, second}},
+		{"Err refuses every message", errDown, []Message{first, second}, errDown, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &MemoryMailer{Err: tt.err}
+			for _, msg := range tt.send {
+				if err := m.Send(context.Background(), msg); !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Send error = %v, want %v", err, tt.wantErr)
+				}
+			}
+			if got := m.Messages(); !slices.Equal(got, tt.want) {
+				t.Errorf("Messages = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryMailerMessagesIsACopy(t *testing.T) {
+	m := &MemoryMailer{}
+	if err := m.Send(context.Background(), Message{To: "ada@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := m.Messages()
+	got[0].To = "mallory@example.com"
+	if m.Messages()[0].To != "ada@example.com" {
+		t.Error("changing the result of Messages changed the mailer's copy")
+	}
+}
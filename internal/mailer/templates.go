@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Message types, each with a templates/<name>.txt (defining "subject" and
+// "text") and a templates/<name>.html (defining "content", wrapped in
+// templates/layout.html).
+const (
+	TemplateVerifyEmail             = "verify_email"
+	TemplatePasswordReset           = "password_reset"
+	TemplateAccountDeleted          = "account_deleted"
+	TemplateAccountDeletionReminder = "account_deletion_reminder"
+	TemplateBookingReminder         = "booking_reminder"
+	TemplateEventReminder           = "event_reminder"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// Render builds the message of type name for data; the caller sets To.
+func Render(name string, data any) (Message, error) {
+	text, err := texttemplate.ParseFS(templateFS, "templates/"+name+".txt")
+	if err != nil {
+		return Message{}, fmt.Errorf("mail template %s: %w", name, err)
+	}
+	html, err := htmltemplate.ParseFS(templateFS, "templates/layout.html", "templates/"+name+".html")
+	if err != nil {
+		return Message{}, fmt.Errorf("mail template %s: %w", name, err)
+	}
+
+	var subject, textBody, htmlBody bytes.Buffer
+	if err := text.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return Message{}, err
+	}
+	if err := text.ExecuteTemplate(&textBody, "text", data); err != nil {
+		return Message{}, err
+	}
+	if err := html.ExecuteTemplate(&htmlBody, "layout", data); err != nil {
+		return Message{}, err
+	}
+
+	return Message{
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    strings.TrimSpace(textBody.String()) + "\n",
+		HTML:    htmlBody.String(),
+	}, nil
+}
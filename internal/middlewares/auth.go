@@ -1,90 +1,265 @@
 package middlewares
 
 import (
-	"fmt"
+	"log"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/FiraBro/local-go/internal/audit"
 	"github.com/FiraBro/local-go/internal/config"
+	"github.com/FiraBro/local-go/internal/models"
 	"github.com/FiraBro/local-go/internal/repositories"
+	"github.com/FiraBro/local-go/internal/tokens"
+	"github.com/FiraBro/local-go/internal/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
 )
 
 // Typed JWT claims
 type Claims struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
+	UserID    string `json:"user_id"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid"`
+	Actor     *Actor `json:"act,omitempty"` // set while impersonating
 	jwt.RegisteredClaims
 }
 
-// AuthMiddleware verifies JWT and checks user existence & soft delete
-func AuthMiddleware(userRepo *repositories.UserRepository) gin.HandlerFunc {
+// Actor is the user really making the request when impersonating (RFC 8693
+// act claim).
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// apiKeyTouchInterval limits last-used writes to one per key per interval.
+const apiKeyTouchInterval = time.Minute
+
+// AuthMiddleware accepts either a Bearer JWT or an X-API-Key header, checks
+// a JWT's session is still active and the user still exists and is not
+// soft-deleted, then loads the permissions of the user's role for
+// RequirePermission. An API key's permissions are further limited to its
+// scopes.
+//
+// "user_id" is always the effective user. "real_user_id" is who is actually
+// calling: the same user, or the admin when the token is an impersonation
+// one, in which case "impersonation_id" is set and the request is recorded.
+func AuthMiddleware(userRepo repositories.UserRepository, roleRepo repositories.RoleRepository, apiKeyRepo repositories.APIKeyRepository, impersonationRepo repositories.ImpersonationRepository, refreshRepo repositories.RefreshTokenRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization required"})
-			return
-		}
+		var userID, sessionID string
+		var apiKey *models.APIKey
+		var impersonation *models.Impersonation
 
-		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		if tokenString == authHeader {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Bearer token required"})
-			return
-		}
+		if rawKey := c.GetHeader("X-API-Key"); rawKey != "" {
+			key, err := apiKeyRepo.GetByHash(utils.HashAPIKey(rawKey))
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify API key"})
+				return
+			}
+			if key == nil || key.RevokedAt != nil || key.ExpiresAt.Before(time.Now()) {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid, expired or revoked API key"})
+				return
+			}
+			apiKey, userID = key, key.UserID
+		} else {
+			authHeader := c.GetHeader("Authorization")
+			if authHeader == "" {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authorization required"})
+				return
+			}
 
-		claims := &Claims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
-			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
+			tokenString := strings.TrimPrefix(authHeader, "Bearer ")
+			if tokenString == authHeader {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Bearer token required"})
+				return
 			}
-			return []byte(config.JWTSecret), nil
-		})
 
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			return
-		}
+			// Checks signature (by kid), expiry, issuer and audience
+			claims := &Claims{}
+			if err := tokens.Parse(tokenString, claims, config.JWTAudience); err != nil {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
+				return
+			}
+			userID, sessionID = claims.UserID, claims.SessionID
 
-		// Optional: check token expiry
-		if claims.ExpiresAt != nil && claims.ExpiresAt.Time.Before(time.Now()) {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token expired"})
-			return
+			if claims.Actor != nil {
+				imp, ok := checkImpersonation(c, claims, userRepo, roleRepo, impersonationRepo)
+				if !ok {
+					return
+				}
+				impersonation = imp
+			} else if !checkSession(c, claims, refreshRepo) {
+				return
+			}
 		}
 
 		// Fetch user from DB to validate soft deletion
-		user, err := userRepo.GetActiveByID(claims.UserID)
-		fmt.Println("USER FROM DB =", user.Role)
+		user, err := userRepo.GetActiveByID(userID)
 		if err != nil || user.DeletedAt != nil {
 			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User no longer active"})
 			return
 		}
 
+		// Role and permissions come from the database, not the token, so
+		// role changes apply immediately
+		permissions, err := roleRepo.GetPermissions(user.Role)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to load permissions"})
+			return
+		}
+
+		if apiKey != nil {
+			permissions = slices.DeleteFunc(permissions, func(p string) bool {
+				return !slices.Contains(apiKey.Scopes, p)
+			})
+
+			now := time.Now()
+			if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+				if err := apiKeyRepo.Touch(apiKey.ID, c.ClientIP(), now); err != nil {
+					log.Println("⚠️ Failed to record API key use:", err)
+				}
+			}
+			c.Set("api_key_id", apiKey.ID)
+		}
+
 		// Store info in context
 		c.Set("user_id", user.ID)
+		c.Set("real_user_id", user.ID)
 		c.Set("role", user.Role)
+		c.Set("session_id", sessionID)
+		c.Set("email_verified", user.VerifiedAt != nil)
+		c.Set("permissions", permissions)
+
+		// Services attribute audit events to the actor in the request context
+		actor := audit.Actor{UserID: user.ID, IP: c.ClientIP()}
+		if apiKey != nil {
+			actor.APIKeyID = apiKey.ID
+		}
+		if impersonation != nil {
+			actor.ImpersonatorID = impersonation.ActorID
+		}
+		c.Request = c.Request.WithContext(audit.WithActor(c.Request.Context(), actor))
+
+		if impersonation == nil {
+			c.Next()
+			return
+		}
 
+		c.Set("real_user_id", impersonation.ActorID)
+		c.Set("impersonation_id", impersonation.ID)
 		c.Next()
+
+		err = impersonationRepo.RecordRequest(&models.ImpersonatedRequest{
+			ID:              uuid.New().String(),
+			ImpersonationID: impersonation.ID,
+			Method:          c.Request.Method,
+			Path:            c.Request.URL.RequestURI(),
+			Status:          c.Writer.Status(),
+			IP:              c.ClientIP(),
+			CreatedAt:       time.Now(),
+		})
+		if err != nil {
+			log.Println("⚠️ Failed to record impersonated request:", err)
+		}
+	}
+}
+
+// checkImpersonation accepts an impersonation token only while its
+// impersonation is running and the admin behind it still may impersonate.
+// It aborts the request otherwise.
+func checkImpersonation(c *gin.Context, claims *Claims, userRepo repositories.UserRepository, roleRepo repositories.RoleRepository, impersonationRepo repositories.ImpersonationRepository) (*models.Impersonation, bool) {
+	imp, err := impersonationRepo.Get(claims.SessionID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify impersonation"})
+		return nil, false
+	}
+	if imp == nil || imp.ActorID != claims.Actor.Subject || imp.TargetID != claims.UserID ||
+		imp.EndedAt != nil || imp.ExpiresAt.Before(time.Now()) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Impersonation has ended"})
+		return nil, false
+	}
+
+	actor, err := userRepo.GetActiveByID(imp.ActorID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Impersonation has ended"})
+		return nil, false
+	}
+	actorPerms, err := roleRepo.GetPermissions(actor.Role)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to load permissions"})
+		return nil, false
+	}
+	if !slices.Contains(actorPerms, models.PermUsersImpersonate) {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Impersonation has ended"})
+		return nil, false
+	}
+	return imp, true
+}
+
+// checkSession accepts a token only while the session (refresh token family)
+// it was issued for is active, so logging out or revoking a session ends its
+// access tokens too. It aborts the request otherwise.
+func checkSession(c *gin.Context, claims *Claims, refreshRepo repositories.RefreshTokenRepository) bool {
+	if _, err := uuid.Parse(claims.SessionID); err != nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session has ended, please log in again"})
+		return false
+	}
+
+	active, err := refreshRepo.IsSessionActive(claims.UserID, claims.SessionID)
+	if err != nil {
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify session"})
+		return false
 	}
+	if !active {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Session has ended, please log in again"})
+		return false
+	}
+	return true
 }
 
-// AdminOnly ensures only admin users can access
-func AdminOnly() gin.HandlerFunc {
+// SessionRequired refuses requests authenticated with an API key, for
+// account and credential management that needs a logged-in user. Use after
+// AuthMiddleware.
+func SessionRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		role, exists := getStringFromContext(c, "role")
-     fmt.Println("ROLE FROM DB =", role)
+		if c.GetString("api_key_id") != "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Not available with an API key, log in instead"})
+			return
+		}
+		c.Next()
+	}
+}
 
-		if !exists || role != "admin" {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin access required"})
+// NotImpersonated refuses requests made while impersonating, for changing
+// the user's credentials, sessions or account. Use after AuthMiddleware.
+func NotImpersonated() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("impersonation_id") != "" {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Not available while impersonating"})
 			return
 		}
 		c.Next()
 	}
 }
 
-// OwnerOrAdmin ensures only owner or admin can access
-func OwnerOrAdmin(getOwnerID func(c *gin.Context) string) gin.HandlerFunc {
+// RequirePermission lets the request through only if the user's role has
+// every one of perms. Use after AuthMiddleware.
+func RequirePermission(perms ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, p := range perms {
+			if !HasPermission(c, p) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Missing permission: " + p})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// OwnerOrPermission lets the owner of a resource through, as well as anyone
+// whose role has perm.
+func OwnerOrPermission(perm string, getOwnerID func(c *gin.Context) string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userID, exists := getStringFromContext(c, "user_id")
 		if !exists {
@@ -92,12 +267,7 @@ func OwnerOrAdmin(getOwnerID func(c *gin.Context) string) gin.HandlerFunc {
 			return
 		}
 
-		role, _ := getStringFromContext(c, "role")
-     fmt.Println("ROLE FROM DB =", role)
-
-		ownerID := getOwnerID(c)
-
-		if role != "admin" && userID != ownerID {
+		if !HasPermission(c, perm) && userID != getOwnerID(c) {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 			return
 		}
@@ -105,6 +275,36 @@ func OwnerOrAdmin(getOwnerID func(c *gin.Context) string) gin.HandlerFunc {
 	}
 }
 
+// HasPermission reports whether the authenticated user's role has perm.
+func HasPermission(c *gin.Context, perm string) bool {
+	val, exists := c.Get("permissions")
+	if !exists {
+		return false
+	}
+	perms, _ := val.([]string)
+	for _, p := range perms {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifiedEmailRequired blocks unverified users when EMAIL_VERIFICATION_POLICY
+// is "booking" or "login". Use after AuthMiddleware.
+func VerifiedEmailRequired() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		policy := config.EmailVerificationPolicy
+		enforced := policy == config.VerifyPolicyBooking || policy == config.VerifyPolicyLogin
+		if !enforced || c.GetBool("email_verified") {
+			c.Next()
+			return
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Please verify your email address first"})
+	}
+}
+
 // Helper to get string from context
 func getStringFromContext(c *gin.Context, key string) (string, bool) {
 	val, exists := c.Get(key)
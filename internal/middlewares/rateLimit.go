@@ -0,0 +1,64 @@
+package middlewares
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitStore backs every RateLimit middleware. Replace it with
+// SetRateLimitStore before routes are registered to share limits between
+// instances.
+var rateLimitStore ratelimit.Store = ratelimit.NewMemoryStore()
+
+func SetRateLimitStore(store ratelimit.Store) {
+	rateLimitStore = store
+}
+
+// RateLimit applies policy per user when placed after AuthMiddleware, and
+// per client IP otherwise. Responses carry RateLimit-* headers; refused
+// requests get 429 with Retry-After. If the store fails, requests are let
+// through rather than taking the API down with it.
+func RateLimit(policy ratelimit.Policy) gin.HandlerFunc {
+	if !policy.Enabled() {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		key := policy.Name + ":ip:" + c.ClientIP()
+		if userID := c.GetString("user_id"); userID != "" {
+			key = policy.Name + ":user:" + userID
+		}
+
+		res, err := rateLimitStore.Take(c.Request.Context(), key, policy)
+		if err != nil {
+			log.Println("⚠️ Rate limit store failed:", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, int(policy.Period.Seconds())))
+		c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", seconds(res.Reset))
+
+		if !res.Allowed {
+			c.Header("Retry-After", seconds(res.RetryAfter))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, please slow down"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// seconds rounds up, so clients never retry a moment too early.
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
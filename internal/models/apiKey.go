@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// APIKeyPrefix starts every API key, so leaked keys are easy to recognise
+// (and to grep for).
+const APIKeyPrefix = "lgo_"
+
+// APIKey is a personal key for machine clients. The key itself is shown once
+// at creation; only its SHA-256 hash is stored. Scopes are permission names
+// and cap what the key can do: it never gets more than its owner's role has.
+type APIKey struct {
+	ID         string     `json:"id"`
+	UserID     string     `json:"-"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
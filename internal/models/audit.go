@@ -0,0 +1,46 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit target types
+const (
+	AuditTargetUser          = "user"
+	AuditTargetRole          = "role"
+	AuditTargetService       = "service"
+	AuditTargetStaff         = "staff"
+	AuditTargetAPIKey        = "api_key"
+	AuditTargetImpersonation = "impersonation"
+	AuditTargetLoginAttempts = "login_attempts"
+	AuditTargetWebhook       = "webhook"
+)
+
+// AuditEvent is one entry of the append-only audit log. ActorID is empty for
+// anonymous or system actions. Changes maps field names to their before and
+// after values.
+type AuditEvent struct {
+	ID             string          `json:"id"`
+	ActorID        string          `json:"actor_id,omitempty"`
+	ImpersonatorID string          `json:"impersonator_id,omitempty"`
+	APIKeyID       string          `json:"api_key_id,omitempty"`
+	Action         string          `json:"action"`
+	TargetType     string          `json:"target_type"`
+	TargetID       string          `json:"target_id"`
+	Changes        json.RawMessage `json:"changes,omitempty"`
+	IP             string          `json:"ip"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// AuditFilter narrows an audit log query; zero fields match everything.
+type AuditFilter struct {
+	ActorID    string
+	TargetType string
+	TargetID   string
+	Action     string
+	From       time.Time
+	To         time.Time
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+const (
+	BookingStatusConfirmed = "confirmed"
+	BookingStatusCancelled = "cancelled"
+)
+
+type Booking struct {
+	ID        string `json:"id"`
+	UserID    string `json:"user_id"`
+	ServiceID string `json:"service_id"`
+	StaffID   string `json:"staff_id"`
+	Date      string `json:"date"`       // YYYY-MM-DD
+	StartTime string `json:"start_time"` // HH:MM
+	EndTime   string `json:"end_time"`   // HH:MM, start + service duration
+	Status    string `json:"status"`
+
+	// Minutes since midnight the booking keeps the staff member busy,
+	// service buffers included
+	BlockStartMinute int `json:"-"`
+	BlockEndMinute   int `json:"-"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// DTO for POST /bookings
+type CreateBookingRequest struct {
+	ServiceID string `json:"service_id" binding:"required"`
+	StaffID   string `json:"staff_id" binding:"required"`
+	Date      string `json:"date" binding:"required"`
+	StartTime string `json:"start_time" binding:"required"`
+}
+
+// BookedInterval is a half-open [StartMinute, EndMinute) range of a staff
+// member's day already taken by a booking.
+type BookedInterval struct {
+	StartMinute int
+	EndMinute   int
+}
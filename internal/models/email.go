@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// Outbox email statuses
+const (
+	EmailStatusPending = "pending"
+	EmailStatusSent    = "sent"
+	EmailStatusFailed  = "failed" // out of attempts, or refused for good
+)
+
+// OutboxEmail is a rendered email waiting for (or done with) delivery.
+type OutboxEmail struct {
+	ID            string     `json:"id"`
+	Template      string     `json:"template"`
+	Recipient     string     `json:"recipient"`
+	Subject       string     `json:"subject"`
+	TextBody      string     `json:"-"`
+	HTMLBody      string     `json:"-"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
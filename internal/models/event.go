@@ -9,4 +9,6 @@ type Event struct {
 	Location    string    `json:"location" binding:"required"`
 	UserId      string    `json:"user_id"`
 	DateTime    time.Time `json:"date_time"`
+	Capacity    int       `json:"capacity"` // 0 = unlimited
+	TicketsSold int       `json:"tickets_sold"`
 }
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Identity links a user to an account at an external OIDC provider.
+type Identity struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"-"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"subject"`
+	Email       string     `json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// OIDCLoginState is an OIDC login between the redirect to the provider and
+// its callback.
+type OIDCLoginState struct {
+	State        string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Impersonation is a support session in which ActorID acts as TargetID. The
+// access token issued for it names it as its sid.
+type Impersonation struct {
+	ID        string     `json:"id"`
+	ActorID   string     `json:"actor_id"`
+	TargetID  string     `json:"target_id"`
+	Reason    string     `json:"reason"`
+	IP        string     `json:"ip"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	EndedAt   *time.Time `json:"ended_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// ImpersonatedRequest is one API request made during an impersonation.
+type ImpersonatedRequest struct {
+	ID              string    `json:"id"`
+	ImpersonationID string    `json:"impersonation_id"`
+	Method          string    `json:"method"`
+	Path            string    `json:"path"`
+	Status          int       `json:"status"`
+	IP              string    `json:"ip"`
+	CreatedAt       time.Time `json:"created_at"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Login throttling scopes: failures are counted per account and per client IP
+const (
+	ThrottleScopeAccount = "account"
+	ThrottleScopeIP      = "ip"
+)
+
+// LoginAttempt is the failed-attempt record for one account or IP.
+// LockedUntil is set while further attempts are refused (backoff or lockout).
+type LoginAttempt struct {
+	Scope         string     `json:"scope"`
+	Identifier    string     `json:"identifier"`
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+	LockedOut     bool       `json:"locked_out"` // reached the failure limit, not just backing off
+}
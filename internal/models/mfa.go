@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// UserMFA is a user's TOTP enrollment. A row with Enabled false is a setup
+// that has not been confirmed yet.
+type UserMFA struct {
+	UserID       string
+	Secret       string
+	Enabled      bool
+	LastUsedStep *int64
+	EnabledAt    *time.Time
+	CreatedAt    time.Time
+}
+
+// MFAStatus is what a user sees about their own MFA setup.
+type MFAStatus struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// MFASetup is handed to the user to add the account to an authenticator app.
+type MFASetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
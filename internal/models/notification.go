@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+// Notification channels users can opt out of. Only reminders honour
+// opt-outs; account email (verification, password reset...) always goes out.
+const (
+	NotificationChannelEmail = "email"
+)
+
+// NotificationChannels lists every channel, in display order.
+var NotificationChannels = []string{NotificationChannelEmail}
+
+// What a reminder is for
+const (
+	ReminderKindBooking = "booking"
+	ReminderKindTicket  = "ticket"
+)
+
+// UpcomingItem is a confirmed booking or an issued ticket that may be due
+// for a reminder, with what the reminder needs to say.
+type UpcomingItem struct {
+	Kind      string
+	ID        string
+	UserID    string
+	Username  string
+	Email     string
+	Title     string // service or event name
+	With      string // staff member, for bookings
+	Location  string // for tickets
+	StartsAt  time.Time
+	CreatedAt time.Time
+}
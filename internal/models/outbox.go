@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// OutboxEvent is a domain event saved in the transaction of the change that
+// raised it, waiting to be relayed to the bus.
+type OutboxEvent struct {
+	ID            string
+	Name          string
+	Payload       []byte
+	Attempts      int
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	PublishedAt   *time.Time
+}
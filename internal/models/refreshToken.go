@@ -2,8 +2,33 @@ package models
 
 import "time"
 
+// RefreshToken is the stored side of a refresh token. Only the SHA-256 hash
+// of the token handed to the client is persisted.
 type RefreshToken struct {
-	Token     string
-	UserID    string
-	ExpiresAt time.Time
+	TokenHash  string
+	UserID     string
+	FamilyID   string // shared by every token rotated from the same login
+	UserAgent  string
+	IPAddress  string
+	ExpiresAt  time.Time
+	CreatedAt  time.Time // when the login that started the family happened
+	LastUsedAt time.Time
+	RevokedAt  *time.Time // set once rotated or revoked
+}
+
+// ClientInfo describes the device a login or refresh came from.
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// Session is one active refresh token family as shown to its owner.
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IPAddress  string    `json:"ip_address"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
 }
@@ -6,4 +6,5 @@ type ResetToken struct {
 	Email     string
 	OTP       string
 	ExpiresAt time.Time
+	Attempts  int // wrong OTPs entered so far
 }
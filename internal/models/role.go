@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Permissions checked by the API. They are seeded by migrations; roles are
+// granted any subset of them.
+const (
+	PermUsersRead        = "users:read"
+	PermUsersWrite       = "users:write"
+	PermUsersImpersonate = "users:impersonate"
+	PermRolesManage      = "roles:manage"
+	PermSecurityManage   = "security:manage"
+	PermServicesWrite    = "services:write"
+	PermStaffWrite       = "staff:write"
+	PermStaffSchedule    = "staff:schedule"
+	PermBookingsManage   = "bookings:manage"
+	PermEventsManage     = "events:manage"
+	PermAuditRead        = "audit:read"
+	PermWebhooksManage   = "webhooks:manage"
+)
+
+// Seeded roles
+const (
+	RoleAdmin    = "admin"
+	RoleManager  = "manager"
+	RoleStaff    = "staff"
+	RoleCustomer = "customer"
+
+	// DefaultRole is given to self-registered accounts
+	DefaultRole = RoleCustomer
+)
+
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	IsSystem    bool      `json:"is_system"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
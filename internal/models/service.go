@@ -3,13 +3,19 @@ package models
 import "time"
 
 type Service struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	Category    string    `json:"category"`
-	Price       float64   `json:"price"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Category    string  `json:"category"`
+	Price       float64 `json:"price"`
+
+	// Length of the appointment and the clean-up/prep time around it
+	DurationMinutes     int `json:"duration_minutes"`
+	BufferBeforeMinutes int `json:"buffer_before_minutes"`
+	BufferAfterMinutes  int `json:"buffer_after_minutes"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // DTO for PATCH updates (partial updates)
@@ -18,4 +24,8 @@ type UpdateServiceRequest struct {
 	Description *string  `json:"description,omitempty"`
 	Category    *string  `json:"category,omitempty"`
 	Price       *float64 `json:"price,omitempty"`
+
+	DurationMinutes     *int `json:"duration_minutes,omitempty"`
+	BufferBeforeMinutes *int `json:"buffer_before_minutes,omitempty"`
+	BufferAfterMinutes  *int `json:"buffer_after_minutes,omitempty"`
 }
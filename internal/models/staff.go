@@ -5,5 +5,5 @@ type Staff struct {
     Name      string `json:"name"`
     Email     string `json:"email"`
     Phone     string `json:"phone"`
-    Role      string `json:"role"`
+    Title     string `json:"title"` // job title, unrelated to user roles
 }
@@ -0,0 +1,32 @@
+package models
+
+// Stream topic kinds
+const (
+	StreamTopicAvailability = "availability" // availability:<serviceId>:<date>
+	StreamTopicStaff        = "staff"        // staff:<staffId>:<date>
+	StreamTopicBookings     = "bookings"     // the caller's own bookings
+)
+
+// StreamTopic is something a stream client follows.
+type StreamTopic struct {
+	Kind string
+	ID   string // service or staff ID; the user's ID for bookings
+	Date string // YYYY-MM-DD
+}
+
+// String is the topic as clients name it.
+func (t StreamTopic) String() string {
+	if t.Kind == StreamTopicBookings {
+		return t.Kind
+	}
+	return t.Kind + ":" + t.ID + ":" + t.Date
+}
+
+// StreamMessage is the current state of a topic, sent as one server-sent
+// event: ID and Event become its "id" and "event" fields, the rest its data.
+type StreamMessage struct {
+	ID    string `json:"-"`
+	Event string `json:"-"`
+	Topic string `json:"topic"`
+	Data  any    `json:"data"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+const (
+	TicketKindFree      = "free"
+	TicketKindPaid      = "paid"
+	TicketKindVIP       = "vip"
+	TicketKindEarlyBird = "early_bird"
+
+	TicketStatusIssued    = "issued"
+	TicketStatusCancelled = "cancelled"
+)
+
+type TicketType struct {
+	ID         string     `json:"id"`
+	EventID    string     `json:"event_id"`
+	Name       string     `json:"name" binding:"required"`
+	Kind       string     `json:"kind" binding:"required"`
+	Price      float64    `json:"price"`
+	Quantity   int        `json:"quantity"` // 0 = limited only by event capacity
+	Sold       int        `json:"sold"`
+	SalesStart *time.Time `json:"sales_start"`
+	SalesEnd   *time.Time `json:"sales_end"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// OnSale reports whether the ticket type can be bought at t.
+func (tt *TicketType) OnSale(t time.Time) bool {
+	if tt.SalesStart != nil && t.Before(*tt.SalesStart) {
+		return false
+	}
+	if tt.SalesEnd != nil && t.After(*tt.SalesEnd) {
+		return false
+	}
+	return true
+}
+
+type Ticket struct {
+	ID           string    `json:"id"`
+	EventID      string    `json:"event_id"`
+	TicketTypeID string    `json:"ticket_type_id"`
+	UserID       string    `json:"user_id"`
+	Code         string    `json:"code"`
+	Price        float64   `json:"price"`
+	Status       string    `json:"status"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Attendee is a row of an event's attendee list
+type Attendee struct {
+	TicketID   string    `json:"ticket_id"`
+	Code       string    `json:"code"`
+	TicketType string    `json:"ticket_type"`
+	UserID     string    `json:"user_id"`
+	Username   string    `json:"username"`
+	Email      string    `json:"email"`
+	CreatedAt  time.Time `json:"registered_at"`
+}
@@ -11,5 +11,6 @@ type User struct {
 	CreatedAt       time.Time  `json:"created_at"`
 	DeletedAt       *time.Time `json:"deleted_at"`
 	DeleteDeadline  *time.Time `json:"delete_deadline"`
+	VerifiedAt      *time.Time `json:"verified_at"`
 }
 
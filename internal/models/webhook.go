@@ -0,0 +1,123 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook event types, "<resource>.<change>"
+const (
+	WebhookServiceCreated = "service.created"
+	WebhookServiceUpdated = "service.updated"
+	WebhookServiceDeleted = "service.deleted"
+
+	WebhookStaffCreated         = "staff.created"
+	WebhookStaffUpdated         = "staff.updated"
+	WebhookStaffDeleted         = "staff.deleted"
+	WebhookStaffScheduleUpdated = "staff.schedule_updated" // weekly hours, holidays or offered services
+
+	WebhookUserCreated  = "user.created"
+	WebhookUserUpdated  = "user.updated"
+	WebhookUserDeleted  = "user.deleted" // scheduled for deletion, still restorable
+	WebhookUserRestored = "user.restored"
+	WebhookUserPurged   = "user.purged"
+
+	WebhookEventCreated = "event.created"
+	WebhookEventUpdated = "event.updated"
+	WebhookEventDeleted = "event.deleted"
+
+	WebhookTicketIssued    = "ticket.issued"
+	WebhookTicketCancelled = "ticket.cancelled"
+
+	WebhookBookingCreated   = "booking.created"
+	WebhookBookingCancelled = "booking.cancelled"
+
+	// WebhookAllEvents subscribes an endpoint to every event type
+	WebhookAllEvents = "*"
+)
+
+// WebhookEventTypes lists every event type endpoints can subscribe to.
+var WebhookEventTypes = []string{
+	WebhookServiceCreated, WebhookServiceUpdated, WebhookServiceDeleted,
+	WebhookStaffCreated, WebhookStaffUpdated, WebhookStaffDeleted, WebhookStaffScheduleUpdated,
+	WebhookUserCreated, WebhookUserUpdated, WebhookUserDeleted, WebhookUserRestored, WebhookUserPurged,
+	WebhookEventCreated, WebhookEventUpdated, WebhookEventDeleted,
+	WebhookTicketIssued, WebhookTicketCancelled,
+	WebhookBookingCreated, WebhookBookingCancelled,
+}
+
+// WebhookEndpoint is a URL that receives the event types it subscribes to.
+// The signing secret is shown when the endpoint is created or its secret
+// rotated.
+type WebhookEndpoint struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description"`
+	EventTypes  []string  `json:"event_types"`
+	Secret      string    `json:"-"`
+	Enabled     bool      `json:"enabled"`
+	CreatedBy   string    `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Subscribes reports whether the endpoint wants events of eventType.
+func (e *WebhookEndpoint) Subscribes(eventType string) bool {
+	for _, t := range e.EventTypes {
+		if t == eventType || t == WebhookAllEvents {
+			return true
+		}
+	}
+	return false
+}
+
+// DTO for POST /webhooks; on PATCH /webhooks/:id every field is optional
+type WebhookEndpointRequest struct {
+	URL         *string   `json:"url"`
+	Description *string   `json:"description"`
+	EventTypes  *[]string `json:"event_types"`
+	Enabled     *bool     `json:"enabled"`
+}
+
+// Webhook delivery statuses
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead" // out of attempts; can be redelivered by hand
+)
+
+// WebhookPayload is the JSON body POSTed to endpoints. ID identifies the
+// event and is the same for every endpoint and redelivery.
+type WebhookPayload struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WebhookDelivery is one event queued for (or sent to) one endpoint, with
+// the outcome of its latest attempt.
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	EndpointID     string          `json:"endpoint_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	ResponseStatus int             `json:"response_status,omitempty"`
+	ResponseBody   string          `json:"response_body,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+}
+
+// WebhookDeliveryFilter narrows the delivery log of an endpoint.
+type WebhookDeliveryFilter struct {
+	EndpointID string
+	Status     string
+	EventType  string
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,55 @@
+package passwords
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// breached holds the SHA-1 hashes of known breached passwords; empty when
+// no list is configured.
+var breached = map[[sha1.Size]byte]struct{}{}
+
+// LoadBreached reads a list of breached password hashes, one uppercase or
+// lowercase hex SHA-1 per line. Lines may carry a ":count" suffix, as in
+// the Have I Been Pwned downloads; other lines are skipped. It returns how
+// many hashes were loaded. Call it once at startup, before serving.
+func LoadBreached(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	set := map[[sha1.Size]byte]struct{}{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		var sum [sha1.Size]byte
+		if len(line) != hex.EncodedLen(sha1.Size) {
+			continue
+		}
+		if _, err := hex.Decode(sum[:], []byte(line)); err != nil {
+			continue
+		}
+		set[sum] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("read %s: %w", path, err)
+	}
+
+	breached = set
+	return len(set), nil
+}
+
+// IsBreached reports whether password is on the loaded breached list.
+func IsBreached(password string) bool {
+	if len(breached) == 0 {
+		return false
+	}
+	_, found := breached[sha1.Sum([]byte(password))]
+	return found
+}
@@ -0,0 +1,118 @@
+// Package passwords hashes and verifies passwords and enforces the password
+// policy, including the optional list of known breached passwords.
+package passwords
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/FiraBro/local-go/internal/config"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrMismatch is returned by Verify when the password does not match.
+var ErrMismatch = errors.New("password does not match")
+
+const (
+	argon2SaltLen = 16
+	argon2KeyLen  = 32
+)
+
+// Hash hashes password with the configured algorithm (PASSWORD_HASH).
+// argon2id hashes use the PHC string format:
+// $argon2id$v=19$m=<KiB>,t=<passes>,p=<threads>$<salt>$<key>.
+func Hash(password string) (string, error) {
+	if config.PasswordHash == config.PasswordHashArgon2id {
+		salt := make([]byte, argon2SaltLen)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		p := currentArgon2Params()
+		key := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, argon2KeyLen)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, p.memory, p.time, p.threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), config.PasswordBcryptCost)
+	return string(hash), err
+}
+
+// Verify checks password against a bcrypt or argon2id hash. needsRehash
+// reports whether a matching hash was made with another algorithm or other
+// parameters than the configured ones, so the caller can store a fresh Hash.
+func Verify(hash, password string) (needsRehash bool, err error) {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		p, salt, key, err := decodeArgon2(hash)
+		if err != nil {
+			return false, err
+		}
+		got := argon2.IDKey([]byte(password), salt, p.time, p.memory, p.threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(got, key) != 1 {
+			return false, ErrMismatch
+		}
+		return config.PasswordHash != config.PasswordHashArgon2id || p != currentArgon2Params(), nil
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, ErrMismatch
+		}
+		return false, err
+	}
+	cost, err := bcrypt.Cost([]byte(hash))
+	if err != nil {
+		return false, err
+	}
+	return config.PasswordHash != config.PasswordHashBcrypt || cost != config.PasswordBcryptCost, nil
+}
+
+// Check is Verify for callers that do not upgrade hashes.
+func Check(hash, password string) error {
+	_, err := Verify(hash, password)
+	return err
+}
+
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+func currentArgon2Params() argon2Params {
+	return argon2Params{
+		memory:  uint32(config.PasswordArgon2Memory),
+		time:    uint32(config.PasswordArgon2Time),
+		threads: uint8(config.PasswordArgon2Threads),
+	}
+}
+
+func decodeArgon2(hash string) (argon2Params, []byte, []byte, error) {
+	var p argon2Params
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, errors.New("malformed argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, fmt.Errorf("unsupported argon2id version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.time, &p.threads); err != nil {
+		return p, nil, nil, fmt.Errorf("malformed argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, fmt.Errorf("malformed argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return p, nil, nil, errors.New("malformed argon2id key")
+	}
+	return p, salt, key, nil
+}
@@ -0,0 +1,92 @@
+package passwords
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/FiraBro/local-go/internal/config"
+)
+
+type hashSettings struct {
+	algorithm   string
+	bcryptCost  int
+	argonMemory int
+	argonTime   int
+}
+
+// Cheap settings, so the tests do not spend seconds hashing
+var (
+	bcryptLow  = hashSettings{config.PasswordHashBcrypt, 4, 64, 1}
+	bcryptHigh = hashSettings{config.PasswordHashBcrypt, 5, 64, 1}
+	argonLow   = hashSettings{config.PasswordHashArgon2id, 4, 64, 1}
+	argonHigh  = hashSettings{config.PasswordHashArgon2id, 4, 128, 2}
+)
+
+func useSettings(t *testing.T, s hashSettings) {
+	t.Helper()
+	algorithm, cost, memory, passes, threads := config.PasswordHash, config.PasswordBcryptCost,
+		config.PasswordArgon2Memory, config.PasswordArgon2Time, config.PasswordArgon2Threads
+	t.Cleanup(func() {
+		config.PasswordHash, config.PasswordBcryptCost = algorithm, cost
+		config.PasswordArgon2Memory, config.PasswordArgon2Time, config.PasswordArgon2Threads = memory, passes, threads
+	})
+
+	config.PasswordHash, config.PasswordBcryptCost = s.algorithm, s.bcryptCost
+	config.PasswordArgon2Memory, config.PasswordArgon2Time, config.PasswordArgon2Threads = s.argonMemory, s.argonTime, 1
+}
+
+func TestVerifyRehash(t *testing.T) {
+	tests := []struct {
+		name       string
+		hashedWith hashSettings
+		verifyWith hashSettings
+		password   string
+		wantRehash bool
+		wantErr    error
+	}{
+		{"bcrypt, same cost", bcryptLow, bcryptLow, "secret", false, nil},
+		{"bcrypt, cost raised", bcryptLow, bcryptHigh, "secret", true, nil},
+		{"bcrypt, cost lowered", bcryptHigh, bcryptLow, "secret", true, nil},
+		{"bcrypt, switched to argon2id", bcryptLow, argonLow, "secret", true, nil},
+		{"bcrypt, wrong password", bcryptLow, bcryptHigh, "wrong", false, ErrMismatch},
+		{"argon2id, same parameters", argonLow, argonLow, "secret", false, nil},
+		{"argon2id, parameters changed", argonLow, argonHigh, "secret", true, nil},
+		{"argon2id, switched to bcrypt", argonLow, bcryptLow, "secret", true, nil},
+		{"argon2id, wrong password", argonLow, argonHigh, "wrong", false, ErrMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useSettings(t, tt.hashedWith)
+			hash, err := Hash("secret")
+			if err != nil {
+				t.Fatalf("Hash: %v", err)
+			}
+
+			useSettings(t, tt.verifyWith)
+			rehash, err := Verify(hash, tt.password)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Verify error = %v, want %v", err, tt.wantErr)
+			}
+			if rehash != tt.wantRehash {
+				t.Errorf("Verify needsRehash = %v, want %v", rehash, tt.wantRehash)
+			}
+		})
+	}
+}
+
+func TestVerifyMalformedArgon2(t *testing.T) {
+	hashes := []string{
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHQ",
+		"$argon2id$v=18$m=64,t=1,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdHNhbHQ$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdHNhbHQ$",
+	}
+
+	for _, hash := range hashes {
+		if _, err := Verify(hash, "secret"); err == nil || errors.Is(err, ErrMismatch) {
+			t.Errorf("Verify(%q) error = %v, want a malformed hash error", hash, err)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package passwords
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/FiraBro/local-go/internal/config"
+)
+
+var (
+	ErrWeak     = errors.New("password does not meet the password policy")
+	ErrBreached = errors.New("this password has appeared in a data breach; choose a different one")
+)
+
+// bcrypt only looks at the first 72 bytes of a password.
+const bcryptMaxBytes = 72
+
+// Policy describes the password rules, for clients to check against before
+// submitting.
+type Policy struct {
+	MinLength     int  `json:"min_length"`
+	MaxLength     int  `json:"max_length"`
+	MinClasses    int  `json:"min_character_classes"`
+	BreachedCheck bool `json:"breached_check"`
+}
+
+// CurrentPolicy returns the configured policy.
+func CurrentPolicy() Policy {
+	return Policy{
+		MinLength:     config.PasswordMinLength,
+		MaxLength:     config.PasswordMaxLength,
+		MinClasses:    config.PasswordMinClasses,
+		BreachedCheck: len(breached) > 0,
+	}
+}
+
+// Validate checks password against the policy for the account with the given
+// username and email. Errors wrap ErrWeak or ErrBreached.
+func Validate(password, username, email string) error {
+	length := utf8.RuneCountInString(password)
+	if length < config.PasswordMinLength {
+		return fmt.Errorf("%w: it must be at least %d characters", ErrWeak, config.PasswordMinLength)
+	}
+	if length > config.PasswordMaxLength || (config.PasswordHash == config.PasswordHashBcrypt && len(password) > bcryptMaxBytes) {
+		return fmt.Errorf("%w: it is too long", ErrWeak)
+	}
+
+	if classes := characterClasses(password); classes < config.PasswordMinClasses {
+		return fmt.Errorf("%w: it must mix at least %d of lowercase letters, uppercase letters, digits and symbols",
+			ErrWeak, config.PasswordMinClasses)
+	}
+
+	lower := strings.ToLower(password)
+	local, _, _ := strings.Cut(strings.ToLower(email), "@")
+	for _, personal := range []string{strings.ToLower(strings.TrimSpace(username)), local} {
+		if len(personal) >= 3 && strings.Contains(lower, personal) {
+			return fmt.Errorf("%w: it must not contain your username or email address", ErrWeak)
+		}
+	}
+
+	if IsBreached(password) {
+		return ErrBreached
+	}
+	return nil
+}
+
+// characterClasses counts which of lowercase, uppercase, digits and anything
+// else appear in password.
+func characterClasses(password string) int {
+	var lower, upper, digit, other bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			other = true
+		}
+	}
+
+	n := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			n++
+		}
+	}
+	return n
+}
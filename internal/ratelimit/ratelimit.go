@@ -0,0 +1,120 @@
+// Package ratelimit implements token-bucket rate limiting behind a Store
+// interface, so the in-memory store can be swapped for a shared one (e.g.
+// Redis) when running more than one instance.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/config"
+)
+
+// Policy is a bucket of Limit tokens that refills completely over Period.
+// Each request takes one token. A Limit of 0 disables the policy.
+type Policy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+}
+
+// Route group policies, per minute, from config
+var (
+	Public = Policy{Name: "public", Limit: config.RateLimitPublic, Period: time.Minute} // anonymous catalogue reads
+	Auth   = Policy{Name: "auth", Limit: config.RateLimitAuth, Period: time.Minute}     // login, registration, password reset
+	User   = Policy{Name: "user", Limit: config.RateLimitUser, Period: time.Minute}     // authenticated API
+	Admin  = Policy{Name: "admin", Limit: config.RateLimitAdmin, Period: time.Minute}   // permission-gated management
+)
+
+func (p Policy) Enabled() bool {
+	return p.Limit > 0 && p.Period > 0
+}
+
+// interval is how long one token takes to refill.
+func (p Policy) interval() time.Duration {
+	return p.Period / time.Duration(p.Limit)
+}
+
+// Result describes the bucket after a request.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next token, when not Allowed
+}
+
+// Store takes one token from the bucket for key under policy.
+type Store interface {
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+}
+
+// ----------------------------
+// IN-MEMORY STORE
+// ----------------------------
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+	period  time.Duration
+}
+
+// MemoryStore keeps buckets in process memory. Limits are per instance.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), lastSweep: time.Now()}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, policy Policy) (Result, error) {
+	now := time.Now()
+	interval := policy.interval()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(policy.Limit), updated: now, period: policy.Period}
+		s.buckets[key] = b
+	}
+
+	// Refill for the time since the last request
+	elapsed := now.Sub(b.updated)
+	b.tokens = math.Min(float64(policy.Limit), b.tokens+elapsed.Seconds()/interval.Seconds())
+	b.updated = now
+
+	res := Result{Limit: policy.Limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) * float64(interval))
+	}
+
+	res.Remaining = int(b.tokens)
+	res.Reset = time.Duration((float64(policy.Limit) - b.tokens) * float64(interval))
+	return res, nil
+}
+
+// sweep drops buckets idle long enough to have refilled, at most once a
+// minute. Callers hold mu.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.Sub(b.updated) >= b.period {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 3, Period: time.Hour} // a token every 20 minutes
+	interval := 20 * time.Minute
+
+	// rewind moves a bucket's last update back, as if d had passed
+	rewind := func(s *MemoryStore, key string, d time.Duration) {
+		s.buckets[key].updated = s.buckets[key].updated.Add(-d)
+	}
+
+	tests := []struct {
+		name          string
+		prepare       func(s *MemoryStore)
+		wantAllowed   bool
+		wantRemaining int
+	}{
+		{"new key gets a full bucket", func(s *MemoryStore) {}, true, 2},
+		{"last token", func(s *MemoryStore) { take(t, s, "k", policy, 2) }, true, 0},
+		{"empty bucket is refused", func(s *MemoryStore) { take(t, s, "k", policy, 3) }, false, 0},
+		{"keys have their own buckets", func(s *MemoryStore) { take(t, s, "other", policy, 3) }, true, 2},
+		{"refills one token per interval", func(s *MemoryStore) {
+			take(t, s, "k", policy, 3)
+			rewind(s, "k", interval)
+		}, true, 0},
+		{"refill stops at the limit", func(s *MemoryStore) {
+			take(t, s, "k", policy, 1)
+			rewind(s, "k", 10*time.Hour)
+		}, true, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemoryStore()
+			tt.prepare(s)
+
+			res, err := s.Take(context.Background(), "k", policy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Allowed != tt.wantAllowed || res.Remaining != tt.wantRemaining || res.Limit != policy.Limit {
+				t.Errorf("Take = allowed %v, remaining %d, limit %d; want %v, %d, %d",
+					res.Allowed, res.Remaining, res.Limit, tt.wantAllowed, tt.wantRemaining, policy.Limit)
+			}
+			if res.Allowed && res.RetryAfter != 0 {
+				t.Errorf("RetryAfter = %s on an allowed request", res.RetryAfter)
+			}
+			if !res.Allowed && (res.RetryAfter <= 0 || res.RetryAfter > interval) {
+				t.Errorf("RetryAfter = %s, want up to %s", res.RetryAfter, interval)
+			}
+			if res.Reset < 0 || res.Reset > policy.Period {
+				t.Errorf("Reset = %s, want up to %s", res.Reset, policy.Period)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreSweep(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 3, Period: time.Hour}
+	s := NewMemoryStore()
+	take(t, s, "idle", policy, 1)
+	take(t, s, "busy", policy, 1)
+
+	s.buckets["idle"].updated = s.buckets["idle"].updated.Add(-policy.Period)
+	s.lastSweep = s.lastSweep.Add(-time.Minute)
+	take(t, s, "busy", policy, 1)
+
+	if _, ok := s.buckets["idle"]; ok {
+		t.Error("idle bucket was not swept")
+	}
+	if _, ok := s.buckets["busy"]; !ok {
+		t.Error("busy bucket was swept")
+	}
+}
+
+func take(t *testing.T, s *MemoryStore, key string, policy Policy, n int) {
+	t.Helper()
+	for range n {
+		if _, err := s.Take(context.Background(), key, policy); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
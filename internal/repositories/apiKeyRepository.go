@@ -0,0 +1,123 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/models"
+)
+
+// APIKeyRepository stores personal API keys by the hash of the key.
+type APIKeyRepository interface {
+	Create(key *models.APIKey) error
+	GetByHash(keyHash string) (*models.APIKey, error)
+	ListByUser(userID string) ([]models.APIKey, error)
+	Revoke(userID, id string) (bool, error)
+	Touch(id, ip string, now time.Time) error
+}
+
+type sqlAPIKeyRepository struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) APIKeyRepository {
+	return &sqlAPIKeyRepository{db: db}
+}
+
+const apiKeyColumns = `id, user_id, name, prefix, key_hash, scopes, expires_at,
+	last_used_at, last_used_ip, revoked_at, created_at`
+
+func scanAPIKey(row interface{ Scan(...any) error }) (*models.APIKey, error) {
+	var k models.APIKey
+	var scopes string
+	var lastUsedAt, revokedAt sql.NullTime
+	var lastUsedIP sql.NullString
+
+	err := row.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &k.KeyHash, &scopes, &k.ExpiresAt,
+		&lastUsedAt, &lastUsedIP, &revokedAt, &k.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	k.Scopes = strings.Fields(scopes)
+	if lastUsedAt.Valid {
+		k.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		k.RevokedAt = &revokedAt.Time
+	}
+	k.LastUsedIP = lastUsedIP.String
+	return &k, nil
+}
+
+func (r *sqlAPIKeyRepository) Create(key *models.APIKey) error {
+	_, err := r.db.Exec(
+		`INSERT INTO api_keys (id, user_id, name, prefix, key_hash, scopes, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		key.ID, key.UserID, key.Name, key.Prefix, key.KeyHash,
+		strings.Join(key.Scopes, " "), key.ExpiresAt, key.CreatedAt,
+	)
+	return err
+}
+
+// GetByHash returns nil, nil for unknown keys. Revoked and expired keys are
+// returned; the caller decides.
+func (r *sqlAPIKeyRepository) GetByHash(keyHash string) (*models.APIKey, error) {
+	k, err := scanAPIKey(r.db.QueryRow(
+		`SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = $1`,
+		keyHash,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return k, err
+}
+
+// ListByUser returns the user's keys that have not been revoked, newest first.
+func (r *sqlAPIKeyRepository) ListByUser(userID string) ([]models.APIKey, error) {
+	rows, err := r.db.Query(
+		`SELECT `+apiKeyColumns+` FROM api_keys
+		 WHERE user_id = $1 AND revoked_at IS NULL
+		 ORDER BY created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+	return keys, rows.Err()
+}
+
+// Revoke reports false when the user has no such active key.
+func (r *sqlAPIKeyRepository) Revoke(userID, id string) (bool, error) {
+	res, err := r.db.Exec(
+		`UPDATE api_keys SET revoked_at = $1
+		 WHERE id = $2 AND user_id = $3 AND revoked_at IS NULL`,
+		time.Now(), id, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Touch records a use of the key.
+func (r *sqlAPIKeyRepository) Touch(id, ip string, now time.Time) error {
+	_, err := r.db.Exec(
+		`UPDATE api_keys SET last_used_at = $1, last_used_ip = $2 WHERE id = $3`,
+		now, ip, id,
+	)
+	return err
+}
@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/FiraBro/local-go/internal/models"
+)
+
+// AuditRepository appends to and reads the audit log. There is deliberately
+// no way to change or remove entries (the database refuses it too).
+type AuditRepository interface {
+	Append(event *models.AuditEvent) error
+	List(filter models.AuditFilter) ([]models.AuditEvent, error)
+}
+
+type sqlAuditRepository struct {
+	db *sql.DB
+}
+
+func NewAuditRepository(db *sql.DB) AuditRepository {
+	return &sqlAuditRepository{db: db}
+}
+
+func (r *sqlAuditRepository) Append(e *models.AuditEvent) error {
+	var changes any
+	if len(e.Changes) > 0 {
+		changes = string(e.Changes)
+	}
+
+	_, err := r.db.Exec(
+		`INSERT INTO audit_events (id, actor_id, impersonator_id, api_key_id, action,
+			target_type, target_id, changes, ip, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		e.ID, nullIfEmpty(e.ActorID), nullIfEmpty(e.ImpersonatorID), nullIfEmpty(e.APIKeyID), e.Action,
+		e.TargetType, e.TargetID, changes, e.IP, e.CreatedAt,
+	)
+	return err
+}
+
+// List returns matching events, newest first.
+func (r *sqlAuditRepository) List(f models.AuditFilter) ([]models.AuditEvent, error) {
+	query := `SELECT id, actor_id, impersonator_id, api_key_id, action, target_type,
+		target_id, changes, ip, created_at
+		FROM audit_events WHERE 1 = 1`
+	args := []any{}
+	where := func(clause string, value any) {
+		args = append(args, value)
+		query += fmt.Sprintf(" AND "+clause, len(args))
+	}
+
+	if f.ActorID != "" {
+		where("actor_id = $%d", f.ActorID)
+	}
+	if f.TargetType != "" {
+		where("target_type = $%d", f.TargetType)
+	}
+	if f.TargetID != "" {
+		where("target_id = $%d", f.TargetID)
+	}
+	if f.Action != "" {
+		where("action = $%d", f.Action)
+	}
+	if !f.From.IsZero() {
+		where("created_at >= $%d", f.From)
+	}
+	if !f.To.IsZero() {
+		where("created_at < $%d", f.To)
+	}
+	args = append(args, f.Limit, f.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.AuditEvent{}
+	for rows.Next() {
+		var e models.AuditEvent
+		var actorID, impersonatorID, apiKeyID, changes sql.NullString
+		if err := rows.Scan(&e.ID, &actorID, &impersonatorID, &apiKeyID, &e.Action, &e.TargetType,
+			&e.TargetID, &changes, &e.IP, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.ActorID, e.ImpersonatorID, e.APIKeyID = actorID.String, impersonatorID.String, apiKeyID.String
+		if changes.Valid {
+			e.Changes = []byte(changes.String)
+		}
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// nullIfEmpty stores "" as NULL.
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
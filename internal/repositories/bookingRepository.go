@@ -0,0 +1,172 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrSlotTaken is returned when the database overlap guard (the
+// bookings_no_overlap constraint on Postgres, a trigger on SQLite) rejects a
+// booking, i.e. another request reserved an overlapping time first.
+var ErrSlotTaken = errors.New("slot already booked")
+
+// BookingRepository stores appointment bookings.
+type BookingRepository interface {
+	Create(ctx context.Context, b *models.Booking) error
+	GetByID(ctx context.Context, id string) (*models.Booking, error)
+	GetByUser(ctx context.Context, userID string) ([]models.Booking, error)
+	GetBookedIntervals(ctx context.Context, staffID, date string) ([]models.BookedInterval, error)
+	UpdateStatus(ctx context.Context, id, status string) (bool, error)
+}
+
+type sqlBookingRepository struct {
+	db *sql.DB
+}
+
+func NewBookingRepository(db *sql.DB) BookingRepository {
+	return &sqlBookingRepository{db: db}
+}
+
+const bookingColumns = `id, user_id, service_id, staff_id, booking_date, start_time, end_time,
+	block_start_minute, block_end_minute, status, created_at, updated_at`
+
+// --------------------
+// CREATE BOOKING
+// --------------------
+func (r *sqlBookingRepository) Create(ctx context.Context, b *models.Booking) error {
+	if b.ID == "" {
+		b.ID = uuid.New().String()
+	}
+	if b.Status == "" {
+		b.Status = models.BookingStatusConfirmed
+	}
+	b.CreatedAt = time.Now()
+	b.UpdatedAt = b.CreatedAt
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO bookings (`+bookingColumns+`)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+	`, b.ID, b.UserID, b.ServiceID, b.StaffID, b.Date, b.StartTime, b.EndTime,
+		b.BlockStartMinute, b.BlockEndMinute, b.Status, b.CreatedAt, b.UpdatedAt)
+
+	if isConstraintViolation(err) {
+		return ErrSlotTaken
+	}
+	return err
+}
+
+// --------------------
+// GET BOOKING BY ID
+// --------------------
+func (r *sqlBookingRepository) GetByID(ctx context.Context, id string) (*models.Booking, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+bookingColumns+` FROM bookings WHERE id = $1`, id)
+
+	b, err := scanBooking(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return b, nil
+}
+
+// --------------------
+// LIST BOOKINGS FOR USER
+// --------------------
+func (r *sqlBookingRepository) GetByUser(ctx context.Context, userID string) ([]models.Booking, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT `+bookingColumns+`
+		FROM bookings
+		WHERE user_id = $1
+		ORDER BY booking_date DESC, start_time DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	bookings := []models.Booking{}
+	for rows.Next() {
+		b, err := scanBooking(rows)
+		if err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, *b)
+	}
+	return bookings, rows.Err()
+}
+
+// --------------------
+// BOOKED INTERVALS FOR A STAFF DAY
+// --------------------
+func (r *sqlBookingRepository) GetBookedIntervals(ctx context.Context, staffID, date string) ([]models.BookedInterval, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT block_start_minute, block_end_minute
+		FROM bookings
+		WHERE staff_id = $1 AND booking_date = $2 AND status <> $3
+	`, staffID, date, models.BookingStatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var intervals []models.BookedInterval
+	for rows.Next() {
+		var i models.BookedInterval
+		if err := rows.Scan(&i.StartMinute, &i.EndMinute); err != nil {
+			return nil, err
+		}
+		intervals = append(intervals, i)
+	}
+	return intervals, rows.Err()
+}
+
+// --------------------
+// UPDATE STATUS
+// --------------------
+// UpdateStatus reports false if the booking already had the status (or does
+// not exist), so a concurrent identical update only takes effect once.
+func (r *sqlBookingRepository) UpdateStatus(ctx context.Context, id, status string) (bool, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE bookings SET status = $1, updated_at = $2 WHERE id = $3 AND status <> $1`,
+		status, time.Now(), id,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanBooking(row rowScanner) (*models.Booking, error) {
+	var b models.Booking
+	var date time.Time
+	if err := row.Scan(
+		&b.ID,
+		&b.UserID,
+		&b.ServiceID,
+		&b.StaffID,
+		&date,
+		&b.StartTime,
+		&b.EndTime,
+		&b.BlockStartMinute,
+		&b.BlockEndMinute,
+		&b.Status,
+		&b.CreatedAt,
+		&b.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	b.Date = date.Format("2006-01-02")
+	return &b, nil
+}
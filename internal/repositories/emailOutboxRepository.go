@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/models"
+)
+
+// EmailOutboxRepository stores outgoing email until it is delivered.
+type EmailOutboxRepository interface {
+	Enqueue(email *models.OutboxEmail) error
+	ListDue(now time.Time, limit int) ([]models.OutboxEmail, error)
+	Claim(id string, now, leaseUntil time.Time) (bool, error)
+	MarkSent(id string, at time.Time) error
+	MarkRetry(id string, next time.Time, lastError string) error
+	MarkFailed(id string, lastError string) error
+	DeleteFinishedBefore(before time.Time) (int64, error)
+}
+
+type sqlEmailOutboxRepository struct {
+	db *sql.DB
+}
+
+func NewEmailOutboxRepository(db *sql.DB) EmailOutboxRepository {
+	return &sqlEmailOutboxRepository{db: db}
+}
+
+func (r *sqlEmailOutboxRepository) Enqueue(e *models.OutboxEmail) error {
+	_, err := r.db.Exec(
+		`INSERT INTO email_outbox (id, template, recipient, subject, text_body, html_body,
+			status, attempts, next_attempt_at, last_error, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`,
+		e.ID, e.Template, e.Recipient, e.Subject, e.TextBody, e.HTMLBody,
+		e.Status, e.Attempts, e.NextAttemptAt, e.LastError, e.CreatedAt,
+	)
+	return err
+}
+
+// ListDue returns pending messages whose next attempt is due, oldest first.
+func (r *sqlEmailOutboxRepository) ListDue(now time.Time, limit int) ([]models.OutboxEmail, error) {
+	rows, err := r.db.Query(
+		`SELECT id, template, recipient, subject, text_body, html_body, status, attempts,
+			next_attempt_at, last_error, created_at
+		 FROM email_outbox
+		 WHERE status = $1 AND next_attempt_at <= $2
+		 ORDER BY next_attempt_at
+		 LIMIT $3`,
+		models.EmailStatusPending, now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emails := []models.OutboxEmail{}
+	for rows.Next() {
+		var e models.OutboxEmail
+		if err := rows.Scan(&e.ID, &e.Template, &e.Recipient, &e.Subject, &e.TextBody, &e.HTMLBody,
+			&e.Status, &e.Attempts, &e.NextAttemptAt, &e.LastError, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		emails = append(emails, e)
+	}
+	return emails, rows.Err()
+}
+
+// Claim counts an attempt and hides the message from other workers until
+// leaseUntil. It returns false if another worker claimed it first. Should the
+// worker die mid-send, the message is retried once the lease runs out.
+func (r *sqlEmailOutboxRepository) Claim(id string, now, leaseUntil time.Time) (bool, error) {
+	res, err := r.db.Exec(
+		`UPDATE email_outbox
+		 SET attempts = attempts + 1, next_attempt_at = $1
+		 WHERE id = $2 AND status = $3 AND next_attempt_at <= $4`,
+		leaseUntil, id, models.EmailStatusPending, now,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *sqlEmailOutboxRepository) MarkSent(id string, at time.Time) error {
+	_, err := r.db.Exec(
+		`UPDATE email_outbox
+		 SET status = $1, sent_at = $2, last_error = '', text_body = '', html_body = ''
+		 WHERE id = $3`,
+		models.EmailStatusSent, at, id,
+	)
+	return err
+}
+
+func (r *sqlEmailOutboxRepository) MarkRetry(id string, next time.Time, lastError string) error {
+	_, err := r.db.Exec(
+		`UPDATE email_outbox SET next_attempt_at = $1, last_error = $2 WHERE id = $3`,
+		next, lastError, id,
+	)
+	return err
+}
+
+func (r *sqlEmailOutboxRepository) MarkFailed(id string, lastError string) error {
+	_, err := r.db.Exec(
+		`UPDATE email_outbox
+		 SET status = $1, last_error = $2, text_body = '', html_body = ''
+		 WHERE id = $3`,
+		models.EmailStatusFailed, lastError, id,
+	)
+	return err
+}
+
+// DeleteFinishedBefore removes sent and failed messages created before the
+// given time.
+func (r *sqlEmailOutboxRepository) DeleteFinishedBefore(before time.Time) (int64, error) {
+	res, err := r.db.Exec(
+		`DELETE FROM email_outbox WHERE status <> $1 AND created_at < $2`,
+		models.EmailStatusPending, before,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/lib/pq"
+)
+
+// isConstraintViolation reports whether err is a uniqueness or overlap
+// constraint rejecting a write, for whichever driver produced it. Callers
+// map it to their own "already taken" error.
+func isConstraintViolation(err error) bool {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		// 23505 = unique_violation, 23P01 = exclusion_violation
+		return pqErr.Code == "23505" || pqErr.Code == "23P01"
+	}
+	return isSQLiteConstraintViolation(err)
+}
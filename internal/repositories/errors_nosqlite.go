@@ -0,0 +1,9 @@
+//go:build !cgo
+
+package repositories
+
+// go-sqlite3 needs cgo; without it the SQLite backend cannot be opened, so
+// there are no SQLite errors to classify.
+func isSQLiteConstraintViolation(err error) bool {
+	return false
+}
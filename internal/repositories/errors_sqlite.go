@@ -0,0 +1,24 @@
+//go:build cgo
+
+package repositories
+
+import (
+	"errors"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// Unique indexes and the RAISE(ABORT) overlap triggers in the SQLite schema
+// both surface as constraint errors; foreign key failures are deliberately
+// not treated as conflicts.
+func isSQLiteConstraintViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+	if !errors.As(err, &sqliteErr) {
+		return false
+	}
+	switch sqliteErr.ExtendedCode {
+	case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey, sqlite3.ErrConstraintTrigger:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/models"
+)
+
+// EventOutboxRepository stores domain events until they are relayed to the
+// bus.
+type EventOutboxRepository interface {
+	// Append stores the event in the transaction in ctx, if any.
+	Append(ctx context.Context, e *models.OutboxEvent) error
+	ListDue(now time.Time, limit int) ([]models.OutboxEvent, error)
+	Claim(id string, now, leaseUntil time.Time) (bool, error)
+	MarkPublished(id string, at time.Time) error
+	DeletePublishedBefore(before time.Time) (int64, error)
+}
+
+type sqlEventOutboxRepository struct {
+	db *sql.DB
+}
+
+func NewEventOutboxRepository(db *sql.DB) EventOutboxRepository {
+	return &sqlEventOutboxRepository{db: db}
+}
+
+func (r *sqlEventOutboxRepository) Append(ctx context.Context, e *models.OutboxEvent) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO event_outbox (id, name, payload, attempts, next_attempt_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6)`,
+		e.ID, e.Name, string(e.Payload), e.Attempts, e.NextAttemptAt, e.CreatedAt,
+	)
+	return err
+}
+
+// ListDue returns unpublished events whose next attempt is due, in the order
+// they were stored.
+func (r *sqlEventOutboxRepository) ListDue(now time.Time, limit int) ([]models.OutboxEvent, error) {
+	rows, err := r.db.Query(
+		`SELECT id, name, payload, attempts, next_attempt_at, created_at
+		 FROM event_outbox
+		 WHERE published_at IS NULL AND next_attempt_at <= $1
+		 ORDER BY created_at
+		 LIMIT $2`,
+		now, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []models.OutboxEvent{}
+	for rows.Next() {
+		var e models.OutboxEvent
+		var payload string
+		if err := rows.Scan(&e.ID, &e.Name, &payload, &e.Attempts, &e.NextAttemptAt, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Payload = []byte(payload)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// Claim counts an attempt and hides the event from other relays until
+// leaseUntil. It returns false if another relay claimed it first. Should the
+// relay die mid-way, the event is relayed again once the lease runs out.
+func (r *sqlEventOutboxRepository) Claim(id string, now, leaseUntil time.Time) (bool, error) {
+	res, err := r.db.Exec(
+		`UPDATE event_outbox
+		 SET attempts = attempts + 1, next_attempt_at = $1
+		 WHERE id = $2 AND published_at IS NULL AND next_attempt_at <= $3`,
+		leaseUntil, id, now,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *sqlEventOutboxRepository) MarkPublished(id string, at time.Time) error {
+	_, err := r.db.Exec(`UPDATE event_outbox SET published_at = $1 WHERE id = $2`, at, id)
+	return err
+}
+
+// DeletePublishedBefore removes events relayed before the given time.
+func (r *sqlEventOutboxRepository) DeletePublishedBefore(before time.Time) (int64, error) {
+	res, err := r.db.Exec(`DELETE FROM event_outbox WHERE published_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -1,33 +1,61 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/FiraBro/local-go/internal/models"
+	"github.com/google/uuid"
 )
 
-type EventRepository struct {
+var (
+	// ErrEventSoldOut is returned when the event or the ticket type has no
+	// seats left at the moment the registration is committed.
+	ErrEventSoldOut = errors.New("no tickets left")
+	// ErrAlreadyRegistered is returned when the user already holds a live
+	// ticket for the event.
+	ErrAlreadyRegistered = errors.New("already registered for this event")
+)
+
+// EventRepository stores events, their ticket types and issued tickets.
+type EventRepository interface {
+	Create(ctx context.Context, event *models.Event) error
+	GetAll(ctx context.Context) ([]models.Event, error)
+	GetByID(ctx context.Context, id string) (*models.Event, error)
+	Update(ctx context.Context, event *models.Event) error
+	Delete(ctx context.Context, id string) error
+	CreateTicketType(ctx context.Context, tt *models.TicketType) error
+	GetTicketTypes(ctx context.Context, eventID string) ([]models.TicketType, error)
+	GetTicketType(ctx context.Context, eventID, ticketTypeID string) (*models.TicketType, error)
+	IssueTicket(ctx context.Context, ticket *models.Ticket) error
+	CancelTicket(ctx context.Context, eventID, userID string) error
+	GetTicketsByUser(ctx context.Context, userID string) ([]models.Ticket, error)
+	GetAttendees(ctx context.Context, eventID string) ([]models.Attendee, error)
+}
+
+type sqlEventRepository struct {
 	db *sql.DB
 }
 
-func NewEventRepository(db *sql.DB) *EventRepository {
-	return &EventRepository{db: db}
+func NewEventRepository(db *sql.DB) EventRepository {
+	return &sqlEventRepository{db: db}
 }
 
 // ----------------------------
 // CREATE EVENT
 // ----------------------------
-func (r *EventRepository) Create(event *models.Event) error {
+func (r *sqlEventRepository) Create(ctx context.Context, event *models.Event) error {
 	if event.DateTime.IsZero() {
 		event.DateTime = time.Now()
 	}
 
 	query := `
-		INSERT INTO events (id, name, description, location, user_id, date_time)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO events (id, name, description, location, user_id, date_time, capacity)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`
-	_, err := r.db.Exec(
+	_, err := conn(ctx, r.db).ExecContext(ctx,
 		query,
 		event.ID,
 		event.Name,
@@ -35,6 +63,7 @@ func (r *EventRepository) Create(event *models.Event) error {
 		event.Location,
 		event.UserId,
 		event.DateTime,
+		event.Capacity,
 	)
 	return err
 }
@@ -42,9 +71,9 @@ func (r *EventRepository) Create(event *models.Event) error {
 // ----------------------------
 // GET ALL EVENTS
 // ----------------------------
-func (r *EventRepository) GetAll() ([]models.Event, error) {
-	rows, err := r.db.Query(`
-		SELECT id, name, description, location, user_id, date_time
+func (r *sqlEventRepository) GetAll(ctx context.Context) ([]models.Event, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, name, description, location, user_id, date_time, capacity, tickets_sold
 		FROM events
 	`)
 	if err != nil {
@@ -55,16 +84,20 @@ func (r *EventRepository) GetAll() ([]models.Event, error) {
 	var events []models.Event
 	for rows.Next() {
 		var e models.Event
+		var userID sql.NullString // NULL once the organiser's account is purged
 		if err := rows.Scan(
 			&e.ID,
 			&e.Name,
 			&e.Description,
 			&e.Location,
-			&e.UserId,
+			&userID,
 			&e.DateTime,
+			&e.Capacity,
+			&e.TicketsSold,
 		); err != nil {
 			return nil, err
 		}
+		e.UserId = userID.String
 		events = append(events, e)
 	}
 	return events, nil
@@ -73,47 +106,53 @@ func (r *EventRepository) GetAll() ([]models.Event, error) {
 // ----------------------------
 // GET EVENT BY ID
 // ----------------------------
-func (r *EventRepository) GetByID(id string) (*models.Event, error) {
-	row := r.db.QueryRow(`
-		SELECT id, name, description, location, user_id, date_time
+func (r *sqlEventRepository) GetByID(ctx context.Context, id string) (*models.Event, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, name, description, location, user_id, date_time, capacity, tickets_sold
 		FROM events
 		WHERE id = $1
 	`, id)
 
 	var e models.Event
+	var userID sql.NullString
 	if err := row.Scan(
 		&e.ID,
 		&e.Name,
 		&e.Description,
 		&e.Location,
-		&e.UserId,
+		&userID,
 		&e.DateTime,
+		&e.Capacity,
+		&e.TicketsSold,
 	); err != nil {
 		return nil, err
 	}
+	e.UserId = userID.String
 	return &e, nil
 }
 
 // ----------------------------
 // UPDATE EVENT
 // ----------------------------
-func (r *EventRepository) Update(event *models.Event) error {
+func (r *sqlEventRepository) Update(ctx context.Context, event *models.Event) error {
 	query := `
 		UPDATE events
 		SET name = $1,
 		    description = $2,
 		    location = $3,
 		    user_id = $4,
-		    date_time = $5
-		WHERE id = $6
+		    date_time = $5,
+		    capacity = $6
+		WHERE id = $7
 	`
-	_, err := r.db.Exec(
+	_, err := conn(ctx, r.db).ExecContext(ctx,
 		query,
 		event.Name,
 		event.Description,
 		event.Location,
-		event.UserId,
+		nullIfEmpty(event.UserId),
 		event.DateTime,
+		event.Capacity,
 		event.ID,
 	)
 	return err
@@ -122,7 +161,254 @@ func (r *EventRepository) Update(event *models.Event) error {
 // ----------------------------
 // DELETE EVENT
 // ----------------------------
-func (r *EventRepository) Delete(id string) error {
-	_, err := r.db.Exec(`DELETE FROM events WHERE id = $1`, id)
+func (r *sqlEventRepository) Delete(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM events WHERE id = $1`, id)
+	return err
+}
+
+// ----------------------------
+// CREATE TICKET TYPE
+// ----------------------------
+func (r *sqlEventRepository) CreateTicketType(ctx context.Context, tt *models.TicketType) error {
+	if tt.ID == "" {
+		tt.ID = uuid.New().String()
+	}
+	tt.CreatedAt = time.Now()
+
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO ticket_types (id, event_id, name, kind, price, quantity, sold, sales_start, sales_end, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 0, $7, $8, $9)
+	`,
+		tt.ID,
+		tt.EventID,
+		tt.Name,
+		tt.Kind,
+		tt.Price,
+		tt.Quantity,
+		tt.SalesStart,
+		tt.SalesEnd,
+		tt.CreatedAt,
+	)
 	return err
 }
+
+// ----------------------------
+// GET TICKET TYPES FOR EVENT
+// ----------------------------
+func (r *sqlEventRepository) GetTicketTypes(ctx context.Context, eventID string) ([]models.TicketType, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, event_id, name, kind, price, quantity, sold, sales_start, sales_end, created_at
+		FROM ticket_types
+		WHERE event_id = $1
+		ORDER BY price ASC, created_at ASC
+	`, eventID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types := []models.TicketType{}
+	for rows.Next() {
+		var tt models.TicketType
+		if err := rows.Scan(
+			&tt.ID,
+			&tt.EventID,
+			&tt.Name,
+			&tt.Kind,
+			&tt.Price,
+			&tt.Quantity,
+			&tt.Sold,
+			&tt.SalesStart,
+			&tt.SalesEnd,
+			&tt.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		types = append(types, tt)
+	}
+	return types, rows.Err()
+}
+
+// ----------------------------
+// GET TICKET TYPE BY ID
+// ----------------------------
+func (r *sqlEventRepository) GetTicketType(ctx context.Context, eventID, ticketTypeID string) (*models.TicketType, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT id, event_id, name, kind, price, quantity, sold, sales_start, sales_end, created_at
+		FROM ticket_types
+		WHERE id = $1 AND event_id = $2
+	`, ticketTypeID, eventID)
+
+	var tt models.TicketType
+	if err := row.Scan(
+		&tt.ID,
+		&tt.EventID,
+		&tt.Name,
+		&tt.Kind,
+		&tt.Price,
+		&tt.Quantity,
+		&tt.Sold,
+		&tt.SalesStart,
+		&tt.SalesEnd,
+		&tt.CreatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &tt, nil
+}
+
+// ----------------------------
+// ISSUE TICKET (capacity enforced atomically)
+// ----------------------------
+// The conditional UPDATEs only succeed while seats remain, and they run in
+// the same transaction as the INSERT, so concurrent registrations can never
+// push tickets_sold past capacity.
+func (r *sqlEventRepository) IssueTicket(ctx context.Context, ticket *models.Ticket) error {
+	if ticket.ID == "" {
+		ticket.ID = uuid.New().String()
+	}
+	ticket.Status = models.TicketStatusIssued
+	ticket.CreatedAt = time.Now()
+
+	return withTx(ctx, r.db, func(tx dbConn) error {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE events
+			SET tickets_sold = tickets_sold + 1
+			WHERE id = $1 AND (capacity = 0 OR tickets_sold < capacity)
+		`, ticket.EventID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrEventSoldOut
+		}
+
+		res, err = tx.ExecContext(ctx, `
+			UPDATE ticket_types
+			SET sold = sold + 1
+			WHERE id = $1 AND event_id = $2 AND (quantity = 0 OR sold < quantity)
+		`, ticket.TicketTypeID, ticket.EventID)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return ErrEventSoldOut
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO tickets (id, event_id, ticket_type_id, user_id, code, price, status, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`,
+			ticket.ID,
+			ticket.EventID,
+			ticket.TicketTypeID,
+			ticket.UserID,
+			ticket.Code,
+			ticket.Price,
+			ticket.Status,
+			ticket.CreatedAt,
+		)
+		if isConstraintViolation(err) {
+			return ErrAlreadyRegistered
+		}
+		return err
+	})
+}
+
+// ----------------------------
+// CANCEL TICKET (frees the seat)
+// ----------------------------
+func (r *sqlEventRepository) CancelTicket(ctx context.Context, eventID, userID string) error {
+	return withTx(ctx, r.db, func(tx dbConn) error {
+		var ticketTypeID string
+		err := tx.QueryRowContext(ctx, `
+			UPDATE tickets
+			SET status = $1
+			WHERE event_id = $2 AND user_id = $3 AND status = $4
+			RETURNING ticket_type_id
+		`, models.TicketStatusCancelled, eventID, userID, models.TicketStatusIssued).Scan(&ticketTypeID)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE events SET tickets_sold = tickets_sold - 1 WHERE id = $1`, eventID); err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, `UPDATE ticket_types SET sold = sold - 1 WHERE id = $1`, ticketTypeID)
+		return err
+	})
+}
+
+// ----------------------------
+// GET TICKETS FOR USER
+// ----------------------------
+func (r *sqlEventRepository) GetTicketsByUser(ctx context.Context, userID string) ([]models.Ticket, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, event_id, ticket_type_id, user_id, code, price, status, created_at
+		FROM tickets
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tickets := []models.Ticket{}
+	for rows.Next() {
+		var t models.Ticket
+		if err := rows.Scan(
+			&t.ID,
+			&t.EventID,
+			&t.TicketTypeID,
+			&t.UserID,
+			&t.Code,
+			&t.Price,
+			&t.Status,
+			&t.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		tickets = append(tickets, t)
+	}
+	return tickets, rows.Err()
+}
+
+// ----------------------------
+// GET ATTENDEES FOR EVENT
+// ----------------------------
+func (r *sqlEventRepository) GetAttendees(ctx context.Context, eventID string) ([]models.Attendee, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT t.id, t.code, tt.name, u.id, u.username, u.email, t.created_at
+		FROM tickets t
+		JOIN ticket_types tt ON tt.id = t.ticket_type_id
+		JOIN users u ON u.id = t.user_id
+		WHERE t.event_id = $1 AND t.status = $2
+		ORDER BY t.created_at ASC
+	`, eventID, models.TicketStatusIssued)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attendees := []models.Attendee{}
+	for rows.Next() {
+		var a models.Attendee
+		if err := rows.Scan(
+			&a.TicketID,
+			&a.Code,
+			&a.TicketType,
+			&a.UserID,
+			&a.Username,
+			&a.Email,
+			&a.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		attendees = append(attendees, a)
+	}
+	return attendees, rows.Err()
+}
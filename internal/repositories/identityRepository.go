@@ -0,0 +1,154 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/google/uuid"
+)
+
+// IdentityRepository stores external (OIDC) identities and in-flight OIDC
+// logins.
+type IdentityRepository interface {
+	Get(provider, subject string) (*models.Identity, error)
+	Create(identity *models.Identity) error
+	ListByUser(userID string) ([]models.Identity, error)
+	Delete(userID, id string) (bool, error)
+	Touch(id string, now time.Time) error
+
+	SaveLoginState(state *models.OIDCLoginState) error
+	TakeLoginState(state string) (*models.OIDCLoginState, error)
+}
+
+type sqlIdentityRepository struct {
+	db *sql.DB
+}
+
+func NewIdentityRepository(db *sql.DB) IdentityRepository {
+	return &sqlIdentityRepository{db: db}
+}
+
+// ---- IDENTITIES ----
+
+// Get returns nil, nil when no user is linked to the provider account.
+func (r *sqlIdentityRepository) Get(provider, subject string) (*models.Identity, error) {
+	var i models.Identity
+	var lastLogin sql.NullTime
+
+	err := r.db.QueryRow(
+		`SELECT id, user_id, provider, subject, email, last_login_at, created_at
+		 FROM identities
+		 WHERE provider = $1 AND subject = $2`,
+		provider, subject,
+	).Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &lastLogin, &i.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if lastLogin.Valid {
+		i.LastLoginAt = &lastLogin.Time
+	}
+	return &i, nil
+}
+
+func (r *sqlIdentityRepository) Create(identity *models.Identity) error {
+	if identity.ID == "" {
+		identity.ID = uuid.New().String()
+	}
+	if identity.CreatedAt.IsZero() {
+		identity.CreatedAt = time.Now()
+	}
+
+	_, err := r.db.Exec(
+		`INSERT INTO identities (id, user_id, provider, subject, email, last_login_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		identity.ID, identity.UserID, identity.Provider, identity.Subject,
+		identity.Email, identity.LastLoginAt, identity.CreatedAt,
+	)
+	return err
+}
+
+func (r *sqlIdentityRepository) ListByUser(userID string) ([]models.Identity, error) {
+	rows, err := r.db.Query(
+		`SELECT id, user_id, provider, subject, email, last_login_at, created_at
+		 FROM identities
+		 WHERE user_id = $1
+		 ORDER BY created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	identities := []models.Identity{}
+	for rows.Next() {
+		var i models.Identity
+		var lastLogin sql.NullTime
+		if err := rows.Scan(&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &lastLogin, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		if lastLogin.Valid {
+			i.LastLoginAt = &lastLogin.Time
+		}
+		identities = append(identities, i)
+	}
+	return identities, rows.Err()
+}
+
+// Delete unlinks one of the user's identities; false if there was none.
+func (r *sqlIdentityRepository) Delete(userID, id string) (bool, error) {
+	res, err := r.db.Exec(
+		`DELETE FROM identities WHERE id = $1 AND user_id = $2`,
+		id, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *sqlIdentityRepository) Touch(id string, now time.Time) error {
+	_, err := r.db.Exec(`UPDATE identities SET last_login_at = $1 WHERE id = $2`, now, id)
+	return err
+}
+
+// ---- LOGIN STATES ----
+
+// SaveLoginState also clears out abandoned logins.
+func (r *sqlIdentityRepository) SaveLoginState(state *models.OIDCLoginState) error {
+	if _, err := r.db.Exec(`DELETE FROM oidc_login_states WHERE expires_at < $1`, time.Now()); err != nil {
+		return err
+	}
+
+	_, err := r.db.Exec(
+		`INSERT INTO oidc_login_states (state, provider, nonce, code_verifier, expires_at)
+		 VALUES ($1, $2, $3, $4, $5)`,
+		state.State, state.Provider, state.Nonce, state.CodeVerifier, state.ExpiresAt,
+	)
+	return err
+}
+
+// TakeLoginState deletes and returns the state, so each can be used once.
+// Returns nil, nil for unknown states.
+func (r *sqlIdentityRepository) TakeLoginState(state string) (*models.OIDCLoginState, error) {
+	var s models.OIDCLoginState
+	err := r.db.QueryRow(
+		`DELETE FROM oidc_login_states WHERE state = $1
+		 RETURNING state, provider, nonce, code_verifier, expires_at`,
+		state,
+	).Scan(&s.State, &s.Provider, &s.Nonce, &s.CodeVerifier, &s.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/models"
+)
+
+// ImpersonationRepository keeps impersonation sessions and the requests made
+// during them. Nothing is ever deleted.
+type ImpersonationRepository interface {
+	Create(imp *models.Impersonation) error
+	Get(id string) (*models.Impersonation, error)
+	End(id string, now time.Time) (bool, error)
+	List(actorID, targetID string, limit int) ([]models.Impersonation, error)
+
+	RecordRequest(req *models.ImpersonatedRequest) error
+	ListRequests(impersonationID string) ([]models.ImpersonatedRequest, error)
+}
+
+type sqlImpersonationRepository struct {
+	db *sql.DB
+}
+
+func NewImpersonationRepository(db *sql.DB) ImpersonationRepository {
+	return &sqlImpersonationRepository{db: db}
+}
+
+// ---- Impersonations ----
+
+const impersonationColumns = `id, actor_id, target_id, reason, ip, expires_at, ended_at, created_at`
+
+func scanImpersonation(row interface{ Scan(...any) error }) (*models.Impersonation, error) {
+	var imp models.Impersonation
+	var endedAt sql.NullTime
+
+	err := row.Scan(&imp.ID, &imp.ActorID, &imp.TargetID, &imp.Reason, &imp.IP,
+		&imp.ExpiresAt, &endedAt, &imp.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if endedAt.Valid {
+		imp.EndedAt = &endedAt.Time
+	}
+	return &imp, nil
+}
+
+func (r *sqlImpersonationRepository) Create(imp *models.Impersonation) error {
+	_, err := r.db.Exec(
+		`INSERT INTO impersonations (id, actor_id, target_id, reason, ip, expires_at, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		imp.ID, imp.ActorID, imp.TargetID, imp.Reason, imp.IP, imp.ExpiresAt, imp.CreatedAt,
+	)
+	return err
+}
+
+// Get returns nil, nil for unknown ids.
+func (r *sqlImpersonationRepository) Get(id string) (*models.Impersonation, error) {
+	imp, err := scanImpersonation(r.db.QueryRow(
+		`SELECT `+impersonationColumns+` FROM impersonations WHERE id = $1`,
+		id,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return imp, err
+}
+
+// End reports false when the impersonation does not exist or already ended.
+func (r *sqlImpersonationRepository) End(id string, now time.Time) (bool, error) {
+	res, err := r.db.Exec(
+		`UPDATE impersonations SET ended_at = $1 WHERE id = $2 AND ended_at IS NULL`,
+		now, id,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// List returns the newest impersonations first, optionally only those by
+// actorID and/or of targetID.
+func (r *sqlImpersonationRepository) List(actorID, targetID string, limit int) ([]models.Impersonation, error) {
+	query := `SELECT ` + impersonationColumns + ` FROM impersonations WHERE 1 = 1`
+	args := []any{}
+	if actorID != "" {
+		args = append(args, actorID)
+		query += fmt.Sprintf(" AND actor_id = $%d", len(args))
+	}
+	if targetID != "" {
+		args = append(args, targetID)
+		query += fmt.Sprintf(" AND target_id = $%d", len(args))
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d", len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.Impersonation{}
+	for rows.Next() {
+		imp, err := scanImpersonation(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *imp)
+	}
+	return list, rows.Err()
+}
+
+// ---- Requests ----
+
+func (r *sqlImpersonationRepository) RecordRequest(req *models.ImpersonatedRequest) error {
+	_, err := r.db.Exec(
+		`INSERT INTO impersonation_requests (id, impersonation_id, method, path, status, ip, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		req.ID, req.ImpersonationID, req.Method, req.Path, req.Status, req.IP, req.CreatedAt,
+	)
+	return err
+}
+
+// ListRequests returns the requests of one impersonation, oldest first.
+func (r *sqlImpersonationRepository) ListRequests(impersonationID string) ([]models.ImpersonatedRequest, error) {
+	rows, err := r.db.Query(
+		`SELECT id, impersonation_id, method, path, status, ip, created_at
+		 FROM impersonation_requests
+		 WHERE impersonation_id = $1
+		 ORDER BY created_at`,
+		impersonationID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requests := []models.ImpersonatedRequest{}
+	for rows.Next() {
+		var req models.ImpersonatedRequest
+		if err := rows.Scan(&req.ID, &req.ImpersonationID, &req.Method, &req.Path,
+			&req.Status, &req.IP, &req.CreatedAt); err != nil {
+			return nil, err
+		}
+		requests = append(requests, req)
+	}
+	return requests, rows.Err()
+}
@@ -0,0 +1,106 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/models"
+)
+
+// LoginAttemptRepository counts failed sign-in attempts per account and IP.
+type LoginAttemptRepository interface {
+	Get(scope, identifier string) (*models.LoginAttempt, error)
+	RecordFailure(scope, identifier string, now, windowStart time.Time) (int, error)
+	Clear(scope, identifier string) (bool, error)
+	ListSince(since time.Time) ([]models.LoginAttempt, error)
+	DeleteBefore(before time.Time) error
+}
+
+type sqlLoginAttemptRepository struct {
+	db *sql.DB
+}
+
+func NewLoginAttemptRepository(db *sql.DB) LoginAttemptRepository {
+	return &sqlLoginAttemptRepository{db: db}
+}
+
+// Get returns nil, nil when there are no recorded failures.
+func (r *sqlLoginAttemptRepository) Get(scope, identifier string) (*models.LoginAttempt, error) {
+	var a models.LoginAttempt
+	err := r.db.QueryRow(
+		`SELECT scope, identifier, failures, last_failure_at
+		 FROM login_attempts
+		 WHERE scope = $1 AND identifier = $2`,
+		scope, identifier,
+	).Scan(&a.Scope, &a.Identifier, &a.Failures, &a.LastFailureAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// RecordFailure adds one failure and returns the new count. A record whose
+// last failure is before windowStart starts over at 1. The increment happens
+// in the database so concurrent failures are all counted.
+func (r *sqlLoginAttemptRepository) RecordFailure(scope, identifier string, now, windowStart time.Time) (int, error) {
+	var failures int
+	err := r.db.QueryRow(
+		`INSERT INTO login_attempts (scope, identifier, failures, last_failure_at)
+		 VALUES ($1, $2, 1, $3)
+		 ON CONFLICT (scope, identifier) DO UPDATE SET
+		     failures = CASE WHEN login_attempts.last_failure_at < $4 THEN 1
+		                     ELSE login_attempts.failures + 1 END,
+		     last_failure_at = excluded.last_failure_at
+		 RETURNING failures`,
+		scope, identifier, now, windowStart,
+	).Scan(&failures)
+	return failures, err
+}
+
+// Clear reports whether there was anything to clear.
+func (r *sqlLoginAttemptRepository) Clear(scope, identifier string) (bool, error) {
+	res, err := r.db.Exec(
+		`DELETE FROM login_attempts WHERE scope = $1 AND identifier = $2`,
+		scope, identifier,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ListSince returns records with a failure at or after since, newest first.
+func (r *sqlLoginAttemptRepository) ListSince(since time.Time) ([]models.LoginAttempt, error) {
+	rows, err := r.db.Query(
+		`SELECT scope, identifier, failures, last_failure_at
+		 FROM login_attempts
+		 WHERE last_failure_at >= $1
+		 ORDER BY last_failure_at DESC`,
+		since,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := []models.LoginAttempt{}
+	for rows.Next() {
+		var a models.LoginAttempt
+		if err := rows.Scan(&a.Scope, &a.Identifier, &a.Failures, &a.LastFailureAt); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+	}
+	return attempts, rows.Err()
+}
+
+// DeleteBefore drops records that have aged out of the window.
+func (r *sqlLoginAttemptRepository) DeleteBefore(before time.Time) error {
+	_, err := r.db.Exec(`DELETE FROM login_attempts WHERE last_failure_at < $1`, before)
+	return err
+}
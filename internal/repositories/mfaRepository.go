@@ -0,0 +1,249 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/google/uuid"
+)
+
+// MFARepository stores TOTP enrollments, recovery codes and the roles that
+// must use MFA.
+type MFARepository interface {
+	Get(userID string) (*models.UserMFA, error)
+	SavePending(userID, secret string) error
+	Enable(userID string, codeHashes []string) error
+	Disable(userID string) error
+	UseStep(userID string, step int64) (bool, error)
+
+	ReplaceRecoveryCodes(userID string, codeHashes []string) error
+	UseRecoveryCode(userID, codeHash string) (bool, error)
+	CountRecoveryCodes(userID string) (int, error)
+
+	IsRequiredForRole(role string) (bool, error)
+	SetRequiredForRole(role string, required bool) error
+	GetRequiredRoles() ([]string, error)
+}
+
+type sqlMFARepository struct {
+	db *sql.DB
+}
+
+func NewMFARepository(db *sql.DB) MFARepository {
+	return &sqlMFARepository{db: db}
+}
+
+// ---- ENROLLMENT ----
+
+// Get returns nil, nil when the user never started MFA setup.
+func (r *sqlMFARepository) Get(userID string) (*models.UserMFA, error) {
+	var m models.UserMFA
+	var lastStep sql.NullInt64
+	var enabledAt sql.NullTime
+
+	err := r.db.QueryRow(
+		`SELECT user_id, secret, enabled, last_used_step, enabled_at, created_at
+		 FROM user_mfa
+		 WHERE user_id = $1`,
+		userID,
+	).Scan(&m.UserID, &m.Secret, &m.Enabled, &lastStep, &enabledAt, &m.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if lastStep.Valid {
+		m.LastUsedStep = &lastStep.Int64
+	}
+	if enabledAt.Valid {
+		m.EnabledAt = &enabledAt.Time
+	}
+	return &m, nil
+}
+
+// SavePending starts (or restarts) setup with a new secret. It never touches
+// an enrollment that is already enabled.
+func (r *sqlMFARepository) SavePending(userID, secret string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`DELETE FROM user_mfa WHERE user_id = $1 AND enabled = $2`,
+		userID, false,
+	); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO user_mfa (user_id, secret, enabled, created_at)
+		 VALUES ($1, $2, $3, $4)`,
+		userID, secret, false, time.Now(),
+	); err != nil {
+		if isConstraintViolation(err) {
+			return errors.New("mfa already enabled")
+		}
+		return err
+	}
+	return tx.Commit()
+}
+
+// Enable confirms the pending enrollment and stores its recovery codes.
+func (r *sqlMFARepository) Enable(userID string, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`UPDATE user_mfa SET enabled = $1, enabled_at = $2 WHERE user_id = $3`,
+		true, time.Now(), userID,
+	); err != nil {
+		return err
+	}
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *sqlMFARepository) Disable(userID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM user_mfa WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseStep records step as used. It returns false if that step (or a later
+// one) was already used, so each code works only once.
+func (r *sqlMFARepository) UseStep(userID string, step int64) (bool, error) {
+	res, err := r.db.Exec(
+		`UPDATE user_mfa
+		 SET last_used_step = $1
+		 WHERE user_id = $2 AND (last_used_step IS NULL OR last_used_step < $1)`,
+		step, userID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ---- RECOVERY CODES ----
+
+func (r *sqlMFARepository) ReplaceRecoveryCodes(userID string, codeHashes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, userID, codeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseRecoveryCode burns the code; only one caller can win the UPDATE.
+func (r *sqlMFARepository) UseRecoveryCode(userID, codeHash string) (bool, error) {
+	res, err := r.db.Exec(
+		`UPDATE mfa_recovery_codes
+		 SET used_at = $1
+		 WHERE user_id = $2 AND code_hash = $3 AND used_at IS NULL`,
+		time.Now(), userID, codeHash,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *sqlMFARepository) CountRecoveryCodes(userID string) (int, error) {
+	var n int
+	err := r.db.QueryRow(
+		`SELECT COUNT(*) FROM mfa_recovery_codes WHERE user_id = $1 AND used_at IS NULL`,
+		userID,
+	).Scan(&n)
+	return n, err
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, userID string, codeHashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, hash := range codeHashes {
+		if _, err := tx.Exec(
+			`INSERT INTO mfa_recovery_codes (id, user_id, code_hash, created_at)
+			 VALUES ($1, $2, $3, $4)`,
+			uuid.New().String(), userID, hash, now,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ---- POLICY ----
+
+func (r *sqlMFARepository) IsRequiredForRole(role string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM mfa_required_roles WHERE role = $1)`,
+		role,
+	).Scan(&exists)
+	return exists, err
+}
+
+func (r *sqlMFARepository) SetRequiredForRole(role string, required bool) error {
+	if !required {
+		_, err := r.db.Exec(`DELETE FROM mfa_required_roles WHERE role = $1`, role)
+		return err
+	}
+
+	_, err := r.db.Exec(
+		`INSERT INTO mfa_required_roles (role, created_at)
+		 VALUES ($1, $2)
+		 ON CONFLICT (role) DO NOTHING`,
+		role, time.Now(),
+	)
+	return err
+}
+
+func (r *sqlMFARepository) GetRequiredRoles() ([]string, error) {
+	rows, err := r.db.Query(`SELECT role FROM mfa_required_roles ORDER BY role`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
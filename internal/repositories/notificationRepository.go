@@ -0,0 +1,174 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/models"
+)
+
+// NotificationRepository finds bookings and tickets due for a reminder,
+// records which reminders were sent, and stores per-channel opt-outs.
+type NotificationRepository interface {
+	// Reminders
+	ListUpcomingBookings(ctx context.Context, from, to time.Time, channel string) ([]models.UpcomingItem, error)
+	ListUpcomingTickets(ctx context.Context, from, to time.Time, channel string) ([]models.UpcomingItem, error)
+	ClaimReminder(ctx context.Context, kind, itemID string, offset time.Duration, channel string, at time.Time) (bool, error)
+	DeleteRemindersBefore(ctx context.Context, before time.Time) (int64, error)
+
+	// Opt-outs
+	ListOptOuts(ctx context.Context, userID string) ([]string, error)
+	SetOptOut(ctx context.Context, userID, channel string, optedOut bool, at time.Time) error
+}
+
+type sqlNotificationRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationRepository(db *sql.DB) NotificationRepository {
+	return &sqlNotificationRepository{db: db}
+}
+
+// ---- REMINDERS ----
+
+// ListUpcomingBookings returns confirmed bookings of active users not opted
+// out of channel that start in (from, to]. Booking dates and times are
+// wall-clock times of the server's time zone, as everywhere else.
+func (r *sqlNotificationRepository) ListUpcomingBookings(ctx context.Context, from, to time.Time, channel string) ([]models.UpcomingItem, error) {
+	// Narrow down by date in SQL, by time below
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT b.id, u.id, u.username, u.email, s.name, st.name, b.booking_date, b.start_time, b.created_at
+		FROM bookings b
+		JOIN users u ON u.id = b.user_id
+		JOIN services s ON s.id = b.service_id
+		JOIN staff st ON st.id = b.staff_id
+		WHERE b.status = $1
+		  AND b.booking_date >= $2 AND b.booking_date <= $3
+		  AND u.deleted_at IS NULL
+		  AND NOT EXISTS (
+		      SELECT 1 FROM notification_opt_outs o WHERE o.user_id = u.id AND o.channel = $4
+		  )
+	`, models.BookingStatusConfirmed, from.Local().Format("2006-01-02"), to.Local().Format("2006-01-02"), channel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.UpcomingItem{}
+	for rows.Next() {
+		item := models.UpcomingItem{Kind: models.ReminderKindBooking}
+		var date time.Time
+		var startTime string
+		if err := rows.Scan(&item.ID, &item.UserID, &item.Username, &item.Email, &item.Title, &item.With,
+			&date, &startTime, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+
+		item.StartsAt, err = time.ParseInLocation("2006-01-02 15:04", date.Format("2006-01-02")+" "+startTime, time.Local)
+		if err != nil {
+			return nil, err
+		}
+		if item.StartsAt.After(from) && !item.StartsAt.After(to) {
+			items = append(items, item)
+		}
+	}
+	return items, rows.Err()
+}
+
+// ListUpcomingTickets returns issued tickets of active users not opted out
+// of channel for events starting in (from, to].
+func (r *sqlNotificationRepository) ListUpcomingTickets(ctx context.Context, from, to time.Time, channel string) ([]models.UpcomingItem, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT t.id, u.id, u.username, u.email, e.name, e.location, e.date_time, t.created_at
+		FROM tickets t
+		JOIN events e ON e.id = t.event_id
+		JOIN users u ON u.id = t.user_id
+		WHERE t.status = $1
+		  AND e.date_time > $2 AND e.date_time <= $3
+		  AND u.deleted_at IS NULL
+		  AND NOT EXISTS (
+		      SELECT 1 FROM notification_opt_outs o WHERE o.user_id = u.id AND o.channel = $4
+		  )
+	`, models.TicketStatusIssued, from, to, channel)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.UpcomingItem{}
+	for rows.Next() {
+		item := models.UpcomingItem{Kind: models.ReminderKindTicket}
+		var location sql.NullString
+		if err := rows.Scan(&item.ID, &item.UserID, &item.Username, &item.Email, &item.Title, &location,
+			&item.StartsAt, &item.CreatedAt); err != nil {
+			return nil, err
+		}
+		item.Location = location.String
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// ClaimReminder records that the reminder offset before the item's start
+// was sent on channel. It returns false if it already was.
+func (r *sqlNotificationRepository) ClaimReminder(ctx context.Context, kind, itemID string, offset time.Duration, channel string, at time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO reminders_sent (kind, item_id, offset_minutes, channel, sent_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 ON CONFLICT (kind, item_id, offset_minutes, channel) DO NOTHING`,
+		kind, itemID, int(offset/time.Minute), channel, at,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// DeleteRemindersBefore forgets reminders sent before the given time.
+func (r *sqlNotificationRepository) DeleteRemindersBefore(ctx context.Context, before time.Time) (int64, error) {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM reminders_sent WHERE sent_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ---- OPT-OUTS ----
+
+// ListOptOuts returns the channels the user opted out of.
+func (r *sqlNotificationRepository) ListOptOuts(ctx context.Context, userID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT channel FROM notification_opt_outs WHERE user_id = $1 ORDER BY channel`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	channels := []string{}
+	for rows.Next() {
+		var channel string
+		if err := rows.Scan(&channel); err != nil {
+			return nil, err
+		}
+		channels = append(channels, channel)
+	}
+	return channels, rows.Err()
+}
+
+func (r *sqlNotificationRepository) SetOptOut(ctx context.Context, userID, channel string, optedOut bool, at time.Time) error {
+	if !optedOut {
+		_, err := r.db.ExecContext(ctx,
+			`DELETE FROM notification_opt_outs WHERE user_id = $1 AND channel = $2`, userID, channel)
+		return err
+	}
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO notification_opt_outs (user_id, channel, created_at)
+		 VALUES ($1, $2, $3)
+		 ON CONFLICT (user_id, channel) DO NOTHING`,
+		userID, channel, at,
+	)
+	return err
+}
@@ -2,56 +2,209 @@ package repositories
 
 import (
 	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/FiraBro/local-go/internal/models"
 )
 
-type RefreshTokenRepository struct {
+// ErrRefreshTokenRotated is returned by Rotate when the token was already
+// rotated or revoked, e.g. by a concurrent refresh with the same token.
+var ErrRefreshTokenRotated = errors.New("refresh token already rotated")
+
+// RefreshTokenRepository stores refresh tokens issued at login.
+type RefreshTokenRepository interface {
+	Save(token *models.RefreshToken) error
+	Get(tokenHash string) (*models.RefreshToken, error)
+	Rotate(oldHash string, next *models.RefreshToken) error
+	RevokeFamily(familyID string) error
+	RevokeSession(userID, familyID string) (bool, error)
+	RevokeAllForUser(userID, exceptFamilyID string) error
+	ListSessions(userID string) ([]models.Session, error)
+	IsSessionActive(userID, familyID string) (bool, error)
+	Delete(tokenHash string) error
+}
+
+type sqlRefreshTokenRepository struct {
 	db *sql.DB
 }
 
-func NewRefreshTokenRepository(db *sql.DB) *RefreshTokenRepository {
-	return &RefreshTokenRepository{db: db}
+func NewRefreshTokenRepository(db *sql.DB) RefreshTokenRepository {
+	return &sqlRefreshTokenRepository{db: db}
 }
 
-func (r *RefreshTokenRepository) Save(token *models.RefreshToken) error {
-	_, err := r.db.Exec(
-		`INSERT INTO refresh_tokens (token, user_id, expires_at)
-		 VALUES ($1, $2, $3)`,
-		token.Token,
-		token.UserID,
-		token.ExpiresAt,
-	)
-	return err
+func (r *sqlRefreshTokenRepository) Save(token *models.RefreshToken) error {
+	return insertRefreshToken(r.db, token)
 }
 
-func (r *RefreshTokenRepository) Get(token string) (*models.RefreshToken, error) {
+func (r *sqlRefreshTokenRepository) Get(tokenHash string) (*models.RefreshToken, error) {
 	row := r.db.QueryRow(
-		`SELECT token, user_id, expires_at
+		`SELECT token_hash, user_id, family_id, user_agent, ip_address,
+		        expires_at, created_at, last_used_at, revoked_at
 		 FROM refresh_tokens
-		 WHERE token = $1`,
-		token,
+		 WHERE token_hash = $1`,
+		tokenHash,
 	)
 
 	var t models.RefreshToken
-	if err := row.Scan(&t.Token, &t.UserID, &t.ExpiresAt); err != nil {
+	if err := row.Scan(
+		&t.TokenHash, &t.UserID, &t.FamilyID, &t.UserAgent, &t.IPAddress,
+		&t.ExpiresAt, &t.CreatedAt, &t.LastUsedAt, &t.RevokedAt,
+	); err != nil {
 		return nil, err
 	}
 	return &t, nil
 }
 
-func (r *RefreshTokenRepository) Delete(token string) error {
+// Rotate marks oldHash as used and stores next in one transaction. Only one
+// caller can win the conditional UPDATE, so a token can be rotated only once.
+func (r *sqlRefreshTokenRepository) Rotate(oldHash string, next *models.RefreshToken) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(
+		`UPDATE refresh_tokens
+		 SET revoked_at = $1, replaced_by = $2
+		 WHERE token_hash = $3 AND revoked_at IS NULL`,
+		time.Now(),
+		next.TokenHash,
+		oldHash,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrRefreshTokenRotated
+	}
+
+	if err := insertRefreshToken(tx, next); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *sqlRefreshTokenRepository) RevokeFamily(familyID string) error {
 	_, err := r.db.Exec(
-		`DELETE FROM refresh_tokens WHERE token = $1`,
-		token,
+		`UPDATE refresh_tokens
+		 SET revoked_at = $1
+		 WHERE family_id = $2 AND revoked_at IS NULL`,
+		time.Now(),
+		familyID,
 	)
 	return err
 }
 
-func (r *RefreshTokenRepository) DeleteByUser(userID string) error {
+// RevokeSession revokes familyID if it belongs to userID and is still
+// active. It reports whether anything was revoked.
+func (r *sqlRefreshTokenRepository) RevokeSession(userID, familyID string) (bool, error) {
+	res, err := r.db.Exec(
+		`UPDATE refresh_tokens
+		 SET revoked_at = $1
+		 WHERE family_id = $2 AND user_id = $3 AND revoked_at IS NULL`,
+		time.Now(),
+		familyID,
+		userID,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RevokeAllForUser revokes every session of userID except exceptFamilyID;
+// pass "" to revoke them all.
+func (r *sqlRefreshTokenRepository) RevokeAllForUser(userID, exceptFamilyID string) error {
 	_, err := r.db.Exec(
-		`DELETE FROM refresh_tokens WHERE user_id = $1`,
+		`UPDATE refresh_tokens
+		 SET revoked_at = $1
+		 WHERE user_id = $2 AND revoked_at IS NULL
+		   AND CAST(family_id AS TEXT) <> $3`,
+		time.Now(),
 		userID,
+		exceptFamilyID,
+	)
+	return err
+}
+
+// ListSessions returns the user's unexpired sessions, most recently used
+// first. Each family has at most one unrevoked token, so one row is one session.
+func (r *sqlRefreshTokenRepository) ListSessions(userID string) ([]models.Session, error) {
+	rows, err := r.db.Query(
+		`SELECT family_id, user_agent, ip_address, created_at, last_used_at, expires_at
+		 FROM refresh_tokens
+		 WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > $2
+		 ORDER BY last_used_at DESC`,
+		userID,
+		time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var s models.Session
+		if err := rows.Scan(&s.ID, &s.UserAgent, &s.IPAddress, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
+
+// IsSessionActive reports whether familyID is one of userID's sessions, as
+// listed by ListSessions. familyID must be a UUID.
+func (r *sqlRefreshTokenRepository) IsSessionActive(userID, familyID string) (bool, error) {
+	var n int
+	err := r.db.QueryRow(
+		`SELECT COUNT(*)
+		 FROM refresh_tokens
+		 WHERE family_id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > $3`,
+		familyID,
+		userID,
+		time.Now(),
+	).Scan(&n)
+	return n > 0, err
+}
+
+func (r *sqlRefreshTokenRepository) Delete(tokenHash string) error {
+	_, err := r.db.Exec(
+		`DELETE FROM refresh_tokens WHERE token_hash = $1`,
+		tokenHash,
+	)
+	return err
+}
+
+type execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+func insertRefreshToken(db execer, token *models.RefreshToken) error {
+	now := time.Now()
+	if token.CreatedAt.IsZero() {
+		token.CreatedAt = now
+	}
+	if token.LastUsedAt.IsZero() {
+		token.LastUsedAt = now
+	}
+
+	_, err := db.Exec(
+		`INSERT INTO refresh_tokens
+		    (token_hash, user_id, family_id, user_agent, ip_address, expires_at, created_at, last_used_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		token.TokenHash,
+		token.UserID,
+		token.FamilyID,
+		token.UserAgent,
+		token.IPAddress,
+		token.ExpiresAt,
+		token.CreatedAt,
+		token.LastUsedAt,
 	)
 	return err
 }
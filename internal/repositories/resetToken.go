@@ -2,19 +2,28 @@ package repositories
 
 import (
 	"database/sql"
+	"errors"
 
 	"github.com/FiraBro/local-go/internal/models"
 )
 
-type ResetTokenRepository struct {
+// ResetTokenRepository stores password reset OTPs.
+type ResetTokenRepository interface {
+	Save(token *models.ResetToken) error
+	Get(email string) (*models.ResetToken, error)
+	AddAttempt(email string) (int, error)
+	Delete(email string) error
+}
+
+type sqlResetTokenRepository struct {
 	db *sql.DB
 }
 
-func NewResetTokenRepository(db *sql.DB) *ResetTokenRepository {
-	return &ResetTokenRepository{db: db}
+func NewResetTokenRepository(db *sql.DB) ResetTokenRepository {
+	return &sqlResetTokenRepository{db: db}
 }
 
-func (r *ResetTokenRepository) Save(token *models.ResetToken) error {
+func (r *sqlResetTokenRepository) Save(token *models.ResetToken) error {
 	_, err := r.db.Exec(
 		`INSERT INTO reset_tokens (email, otp, expires_at)
 		 VALUES ($1, $2, $3)`,
@@ -25,23 +34,40 @@ func (r *ResetTokenRepository) Save(token *models.ResetToken) error {
 	return err
 }
 
-func (r *ResetTokenRepository) Get(email, otp string) (*models.ResetToken, error) {
+// Get returns the pending reset for email (the OTP is a bcrypt hash, so it
+// cannot be looked up directly), or nil, nil when there is none.
+func (r *sqlResetTokenRepository) Get(email string) (*models.ResetToken, error) {
 	row := r.db.QueryRow(
-		`SELECT email, otp, expires_at
+		`SELECT email, otp, expires_at, attempts
 		 FROM reset_tokens
-		 WHERE email = $1 AND otp = $2`,
+		 WHERE email = $1`,
 		email,
-		otp,
 	)
 
 	var t models.ResetToken
-	if err := row.Scan(&t.Email, &t.OTP, &t.ExpiresAt); err != nil {
+	err := row.Scan(&t.Email, &t.OTP, &t.ExpiresAt, &t.Attempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
 		return nil, err
 	}
 	return &t, nil
 }
 
-func (r *ResetTokenRepository) Delete(email string) error {
+// AddAttempt counts one wrong OTP and returns the new total.
+func (r *sqlResetTokenRepository) AddAttempt(email string) (int, error) {
+	var attempts int
+	err := r.db.QueryRow(
+		`UPDATE reset_tokens SET attempts = attempts + 1
+		 WHERE email = $1
+		 RETURNING attempts`,
+		email,
+	).Scan(&attempts)
+	return attempts, err
+}
+
+func (r *sqlResetTokenRepository) Delete(email string) error {
 	_, err := r.db.Exec(
 		`DELETE FROM reset_tokens WHERE email = $1`,
 		email,
@@ -0,0 +1,183 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/models"
+)
+
+var ErrRoleExists = errors.New("role already exists")
+
+// RoleRepository stores roles, permissions and which role has which permission.
+type RoleRepository interface {
+	GetPermissions(role string) ([]string, error)
+	GetRole(name string) (*models.Role, error)
+	ListRoles() ([]models.Role, error)
+	CreateRole(role *models.Role) error
+	ListPermissions() ([]models.Permission, error)
+	PermissionExists(name string) (bool, error)
+	Grant(role, permission string) error
+	Revoke(role, permission string) error
+}
+
+type sqlRoleRepository struct {
+	db *sql.DB
+}
+
+func NewRoleRepository(db *sql.DB) RoleRepository {
+	return &sqlRoleRepository{db: db}
+}
+
+// ---- READ ----
+
+func (r *sqlRoleRepository) GetPermissions(role string) ([]string, error) {
+	rows, err := r.db.Query(
+		`SELECT permission FROM role_permissions WHERE role = $1 ORDER BY permission`,
+		role,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	perms := []string{}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		perms = append(perms, p)
+	}
+	return perms, rows.Err()
+}
+
+// GetRole returns nil, nil when the role does not exist.
+func (r *sqlRoleRepository) GetRole(name string) (*models.Role, error) {
+	var role models.Role
+	err := r.db.QueryRow(
+		`SELECT name, description, is_system, created_at FROM roles WHERE name = $1`,
+		name,
+	).Scan(&role.Name, &role.Description, &role.IsSystem, &role.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if role.Permissions, err = r.GetPermissions(role.Name); err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (r *sqlRoleRepository) ListRoles() ([]models.Role, error) {
+	rows, err := r.db.Query(
+		`SELECT name, description, is_system, created_at FROM roles ORDER BY name`,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := []models.Role{}
+	for rows.Next() {
+		var role models.Role
+		if err := rows.Scan(&role.Name, &role.Description, &role.IsSystem, &role.CreatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Fetched after closing rows: the SQLite pool has a single connection
+	for i := range roles {
+		if roles[i].Permissions, err = r.GetPermissions(roles[i].Name); err != nil {
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+func (r *sqlRoleRepository) ListPermissions() ([]models.Permission, error) {
+	rows, err := r.db.Query(`SELECT name, description FROM permissions ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	perms := []models.Permission{}
+	for rows.Next() {
+		var p models.Permission
+		if err := rows.Scan(&p.Name, &p.Description); err != nil {
+			return nil, err
+		}
+		perms = append(perms, p)
+	}
+	return perms, rows.Err()
+}
+
+func (r *sqlRoleRepository) PermissionExists(name string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(
+		`SELECT EXISTS(SELECT 1 FROM permissions WHERE name = $1)`,
+		name,
+	).Scan(&exists)
+	return exists, err
+}
+
+// ---- WRITE ----
+
+func (r *sqlRoleRepository) CreateRole(role *models.Role) error {
+	role.CreatedAt = time.Now()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(
+		`INSERT INTO roles (name, description, is_system, created_at)
+		 VALUES ($1, $2, $3, $4)`,
+		role.Name, role.Description, false, role.CreatedAt,
+	); err != nil {
+		if isConstraintViolation(err) {
+			return ErrRoleExists
+		}
+		return err
+	}
+
+	for _, p := range role.Permissions {
+		if _, err := tx.Exec(
+			`INSERT INTO role_permissions (role, permission) VALUES ($1, $2)`,
+			role.Name, p,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *sqlRoleRepository) Grant(role, permission string) error {
+	_, err := r.db.Exec(
+		`INSERT INTO role_permissions (role, permission)
+		 VALUES ($1, $2)
+		 ON CONFLICT (role, permission) DO NOTHING`,
+		role, permission,
+	)
+	return err
+}
+
+func (r *sqlRoleRepository) Revoke(role, permission string) error {
+	_, err := r.db.Exec(
+		`DELETE FROM role_permissions WHERE role = $1 AND permission = $2`,
+		role, permission,
+	)
+	return err
+}
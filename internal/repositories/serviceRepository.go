@@ -10,35 +10,50 @@ import (
 	"github.com/google/uuid"
 )
 
-type ServiceRepository struct {
+// ServiceRepository stores the bookable service catalogue.
+type ServiceRepository interface {
+	Create(ctx context.Context, service *models.Service) error
+	GetAll(ctx context.Context) ([]models.Service, error)
+	GetByIDs(ctx context.Context, id string) (*models.Service, error)
+	Update(ctx context.Context, id string, s *models.Service) error
+	Delete(ctx context.Context, id string) error
+	GetCategories(ctx context.Context) ([]string, error)
+}
+
+type sqlServiceRepository struct {
 	db *sql.DB
 }
 
-func NewServiceRepository(db *sql.DB) *ServiceRepository {
-	return &ServiceRepository{db: db}
+func NewServiceRepository(db *sql.DB) ServiceRepository {
+	return &sqlServiceRepository{db: db}
 }
 
 // --------------------
 // CREATE SERVICE
 // --------------------
-func (r *ServiceRepository) Create(ctx context.Context, service *models.Service) error {
+func (r *sqlServiceRepository) Create(ctx context.Context, service *models.Service) error {
 	service.ID = uuid.New().String()
 	service.CreatedAt = time.Now()
 	service.UpdatedAt = time.Now()
 
 	query := `
 		INSERT INTO services (
-			id, name, description, category, price, created_at, updated_at
+			id, name, description, category, price,
+			duration_minutes, buffer_before_minutes, buffer_after_minutes,
+			created_at, updated_at
 		)
-		VALUES ($1,$2,$3,$4,$5,$6,$7)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := conn(ctx, r.db).ExecContext(ctx, query,
 		service.ID,
 		service.Name,
 		service.Description,
 		service.Category,
 		service.Price,
+		service.DurationMinutes,
+		service.BufferBeforeMinutes,
+		service.BufferAfterMinutes,
 		service.CreatedAt,
 		service.UpdatedAt,
 	)
@@ -49,14 +64,16 @@ func (r *ServiceRepository) Create(ctx context.Context, service *models.Service)
 // --------------------
 // GET ALL SERVICES
 // --------------------
-func (r *ServiceRepository) GetAll(ctx context.Context) ([]models.Service, error) {
+func (r *sqlServiceRepository) GetAll(ctx context.Context) ([]models.Service, error) {
 	query := `
-		SELECT id, name, description, category, price, created_at, updated_at
+		SELECT id, name, description, category, price,
+		       duration_minutes, buffer_before_minutes, buffer_after_minutes,
+		       created_at, updated_at
 		FROM services
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -71,6 +88,9 @@ func (r *ServiceRepository) GetAll(ctx context.Context) ([]models.Service, error
 			&s.Description,
 			&s.Category,
 			&s.Price,
+			&s.DurationMinutes,
+			&s.BufferBeforeMinutes,
+			&s.BufferAfterMinutes,
 			&s.CreatedAt,
 			&s.UpdatedAt,
 		); err != nil {
@@ -85,20 +105,25 @@ func (r *ServiceRepository) GetAll(ctx context.Context) ([]models.Service, error
 // --------------------
 // GET SERVICE BY ID
 // --------------------
-func (r *ServiceRepository) GetByIDs(ctx context.Context, id string) (*models.Service, error) {
+func (r *sqlServiceRepository) GetByIDs(ctx context.Context, id string) (*models.Service, error) {
 	query := `
-		SELECT id, name, description, category, price, created_at, updated_at
+		SELECT id, name, description, category, price,
+		       duration_minutes, buffer_before_minutes, buffer_after_minutes,
+		       created_at, updated_at
 		FROM services
 		WHERE id = $1
 	`
 
 	var s models.Service
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&s.ID,
 		&s.Name,
 		&s.Description,
 		&s.Category,
 		&s.Price,
+		&s.DurationMinutes,
+		&s.BufferBeforeMinutes,
+		&s.BufferAfterMinutes,
 		&s.CreatedAt,
 		&s.UpdatedAt,
 	)
@@ -117,14 +142,18 @@ func (r *ServiceRepository) GetByIDs(ctx context.Context, id string) (*models.Se
 // --------------------
 // UPDATE SERVICE
 // --------------------
-func (r *ServiceRepository) Update(ctx context.Context, id string, s *models.Service) error {
+func (r *sqlServiceRepository) Update(ctx context.Context, id string, s *models.Service) error {
     query := `
         UPDATE services
-        SET name = $1, description = $2, category = $3, price = $4, updated_at = $5
-        WHERE id = $6
+        SET name = $1, description = $2, category = $3, price = $4,
+            duration_minutes = $5, buffer_before_minutes = $6, buffer_after_minutes = $7,
+            updated_at = $8
+        WHERE id = $9
     `
-    _, err := r.db.ExecContext(ctx, query, 
-        s.Name, s.Description, s.Category, s.Price, time.Now(), id,
+    _, err := conn(ctx, r.db).ExecContext(ctx, query, 
+        s.Name, s.Description, s.Category, s.Price,
+        s.DurationMinutes, s.BufferBeforeMinutes, s.BufferAfterMinutes,
+        time.Now(), id,
     )
     return err
 }
@@ -132,8 +161,8 @@ func (r *ServiceRepository) Update(ctx context.Context, id string, s *models.Ser
 // --------------------
 // DELETE SERVICE
 // --------------------
-func (r *ServiceRepository) Delete(ctx context.Context, id string) error {
-	_, err := r.db.ExecContext(
+func (r *sqlServiceRepository) Delete(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).ExecContext(
 		ctx,
 		`DELETE FROM services WHERE id = $1`,
 		id,
@@ -144,8 +173,8 @@ func (r *ServiceRepository) Delete(ctx context.Context, id string) error {
 // --------------------
 // GET SERVICE CATEGORIES
 // --------------------
-func (r *ServiceRepository) GetCategories(ctx context.Context) ([]string, error) {
-	rows, err := r.db.QueryContext(
+func (r *sqlServiceRepository) GetCategories(ctx context.Context) ([]string, error) {
+	rows, err := conn(ctx, r.db).QueryContext(
 		ctx,
 		`SELECT DISTINCT category FROM services ORDER BY category`,
 	)
@@ -4,24 +4,41 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/FiraBro/local-go/internal/models"
 	"github.com/google/uuid"
 )
 
-type StaffRepository struct {
+// StaffRepository stores staff members, their services, schedules and holidays.
+type StaffRepository interface {
+	GetAll(ctx context.Context) ([]models.Staff, error)
+	Create(ctx context.Context, staff *models.Staff) error
+	GetByID(ctx context.Context, id string) (*models.Staff, error)
+	Update(ctx context.Context, id string, staff *models.Staff) error
+	Delete(ctx context.Context, id string) error
+	GetServiceIDs(ctx context.Context, staffID string) ([]string, error)
+	GetStaffByService(ctx context.Context, serviceID string) ([]models.Staff, error)
+	AssignServices(ctx context.Context, staffID string, serviceIDs []string) error
+	SetSchedule(ctx context.Context, staffID string, entries []map[string]string) error
+	GetSchedule(ctx context.Context, staffID string) ([]map[string]string, error)
+	AddHoliday(ctx context.Context, staffID, date, reason string) error
+	GetAvailabilityData(ctx context.Context, staffID string) ([]map[string]string, []string, error)
+}
+
+type sqlStaffRepository struct {
     db *sql.DB
 }
 
-func NewStaffRepository(db *sql.DB) *StaffRepository {
-    return &StaffRepository{db: db}
+func NewStaffRepository(db *sql.DB) StaffRepository {
+    return &sqlStaffRepository{db: db}
 }
 
 // ------------------- STAFF CRUD -------------------
 
-func (r *StaffRepository) GetAll(ctx context.Context) ([]models.Staff, error) {
-    query := `SELECT id, name, email, phone, role FROM staff ORDER BY name ASC`
-    rows, err := r.db.QueryContext(ctx, query)
+func (r *sqlStaffRepository) GetAll(ctx context.Context) ([]models.Staff, error) {
+    query := `SELECT id, name, email, phone, title FROM staff ORDER BY name ASC`
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query)
     if err != nil {
         return nil, err
     }
@@ -30,7 +47,7 @@ func (r *StaffRepository) GetAll(ctx context.Context) ([]models.Staff, error) {
     var staffList []models.Staff
     for rows.Next() {
         var s models.Staff
-        if err := rows.Scan(&s.ID, &s.Name, &s.Email, &s.Phone, &s.Role); err != nil {
+        if err := rows.Scan(&s.ID, &s.Name, &s.Email, &s.Phone, &s.Title); err != nil {
             return nil, err
         }
         staffList = append(staffList, s)
@@ -38,23 +55,23 @@ func (r *StaffRepository) GetAll(ctx context.Context) ([]models.Staff, error) {
     return staffList, nil
 }
 
-func (r *StaffRepository) Create(ctx context.Context, staff *models.Staff) error {
+func (r *sqlStaffRepository) Create(ctx context.Context, staff *models.Staff) error {
     if staff.ID == "" {
         staff.ID = uuid.New().String()
     }
 
     query := `
-        INSERT INTO staff (id, name, email, phone, role)
+        INSERT INTO staff (id, name, email, phone, title)
         VALUES ($1, $2, $3, $4, $5)
     `
-    _, err := r.db.ExecContext(ctx, query, staff.ID, staff.Name, staff.Email, staff.Phone, staff.Role)
+    _, err := conn(ctx, r.db).ExecContext(ctx, query, staff.ID, staff.Name, staff.Email, staff.Phone, staff.Title)
     return err
 }
 
-func (r *StaffRepository) GetByID(ctx context.Context, id string) (*models.Staff, error) {
-    query := `SELECT id, name, email, phone, role FROM staff WHERE id = $1`
+func (r *sqlStaffRepository) GetByID(ctx context.Context, id string) (*models.Staff, error) {
+    query := `SELECT id, name, email, phone, title FROM staff WHERE id = $1`
     var s models.Staff
-    err := r.db.QueryRowContext(ctx, query, id).Scan(&s.ID, &s.Name, &s.Email, &s.Phone, &s.Role)
+    err := conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(&s.ID, &s.Name, &s.Email, &s.Phone, &s.Title)
     
     if err != nil {
         if errors.Is(err, sql.ErrNoRows) {
@@ -65,22 +82,22 @@ func (r *StaffRepository) GetByID(ctx context.Context, id string) (*models.Staff
     return &s, nil
 }
 
-func (r *StaffRepository) Update(ctx context.Context, id string, staff *models.Staff) error {
-    query := `UPDATE staff SET name = $1, email = $2, phone = $3, role = $4 WHERE id = $5`
-    _, err := r.db.ExecContext(ctx, query, staff.Name, staff.Email, staff.Phone, staff.Role, id)
+func (r *sqlStaffRepository) Update(ctx context.Context, id string, staff *models.Staff) error {
+    query := `UPDATE staff SET name = $1, email = $2, phone = $3, title = $4 WHERE id = $5`
+    _, err := conn(ctx, r.db).ExecContext(ctx, query, staff.Name, staff.Email, staff.Phone, staff.Title, id)
     return err
 }
 
-func (r *StaffRepository) Delete(ctx context.Context, id string) error {
-    _, err := r.db.ExecContext(ctx, `DELETE FROM staff WHERE id = $1`, id)
+func (r *sqlStaffRepository) Delete(ctx context.Context, id string) error {
+    _, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM staff WHERE id = $1`, id)
     return err
 }
 
 // ------------------- SERVICES (MANY-TO-MANY) -------------------
 
-func (r *StaffRepository) GetServiceIDs(ctx context.Context, staffID string) ([]string, error) {
+func (r *sqlStaffRepository) GetServiceIDs(ctx context.Context, staffID string) ([]string, error) {
     query := `SELECT service_id FROM staff_services WHERE staff_id = $1`
-    rows, err := r.db.QueryContext(ctx, query, staffID)
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, staffID)
     if err != nil {
         return nil, err
     }
@@ -96,15 +113,15 @@ func (r *StaffRepository) GetServiceIDs(ctx context.Context, staffID string) ([]
     }
     return ids, nil
 }
-func (r *StaffRepository) GetStaffByService(ctx context.Context, serviceID string) ([]models.Staff, error) {
+func (r *sqlStaffRepository) GetStaffByService(ctx context.Context, serviceID string) ([]models.Staff, error) {
     query := `
-        SELECT s.id, s.name, s.email, s.phone, s.role
+        SELECT s.id, s.name, s.email, s.phone, s.title
         FROM staff s
         JOIN staff_services ss ON s.id = ss.staff_id
         WHERE ss.service_id = $1
     `
 
-    rows, err := r.db.QueryContext(ctx, query, serviceID)
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, serviceID)
     if err != nil {
         return nil, err
     }
@@ -113,7 +130,7 @@ func (r *StaffRepository) GetStaffByService(ctx context.Context, serviceID strin
     var staffList []models.Staff
     for rows.Next() {
         var s models.Staff
-        if err := rows.Scan(&s.ID, &s.Name, &s.Email, &s.Phone, &s.Role); err != nil {
+        if err := rows.Scan(&s.ID, &s.Name, &s.Email, &s.Phone, &s.Title); err != nil {
             return nil, err
         }
         staffList = append(staffList, s)
@@ -122,62 +139,52 @@ func (r *StaffRepository) GetStaffByService(ctx context.Context, serviceID strin
 }
 
 // AssignServices uses a Sync pattern: Delete existing associations and re-insert
-func (r *StaffRepository) AssignServices(ctx context.Context, staffID string, serviceIDs []string) error {
-    tx, err := r.db.BeginTx(ctx, nil)
-    if err != nil {
-        return err
-    }
-    defer tx.Rollback()
-
-    // 1. Clear current assignments
-    if _, err := tx.ExecContext(ctx, `DELETE FROM staff_services WHERE staff_id = $1`, staffID); err != nil {
-        return err
-    }
-
-    // 2. Insert new ones
-    for _, svcID := range serviceIDs {
-        _, err := tx.ExecContext(ctx, `
-            INSERT INTO staff_services (id, staff_id, service_id)
-            VALUES ($1, $2, $3)
-        `, uuid.New().String(), staffID, svcID)
-        if err != nil {
+func (r *sqlStaffRepository) AssignServices(ctx context.Context, staffID string, serviceIDs []string) error {
+    return withTx(ctx, r.db, func(tx dbConn) error {
+        // 1. Clear current assignments
+        if _, err := tx.ExecContext(ctx, `DELETE FROM staff_services WHERE staff_id = $1`, staffID); err != nil {
             return err
         }
-    }
 
-    return tx.Commit()
+        // 2. Insert new ones
+        for _, svcID := range serviceIDs {
+            _, err := tx.ExecContext(ctx, `
+                INSERT INTO staff_services (id, staff_id, service_id)
+                VALUES ($1, $2, $3)
+            `, uuid.New().String(), staffID, svcID)
+            if err != nil {
+                return err
+            }
+        }
+        return nil
+    })
 }
 
 // ------------------- SCHEDULE -------------------
 
 
 
-func (r *StaffRepository) SetSchedule(ctx context.Context, staffID string, entries []map[string]string) error {
-    tx, err := r.db.BeginTx(ctx, nil)
-    if err != nil {
-        return err
-    }
-    defer tx.Rollback()
-
-    if _, err := tx.ExecContext(ctx, `DELETE FROM staff_schedule WHERE staff_id = $1`, staffID); err != nil {
-        return err
-    }
-
-    for _, entry := range entries {
-        _, err := tx.ExecContext(ctx, `
-            INSERT INTO staff_schedule (id, staff_id, day_of_week, start_time, end_time)
-            VALUES ($1, $2, $3, $4, $5)
-        `, uuid.New().String(), staffID, entry["day"], entry["start"], entry["end"])
-        if err != nil {
+func (r *sqlStaffRepository) SetSchedule(ctx context.Context, staffID string, entries []map[string]string) error {
+    return withTx(ctx, r.db, func(tx dbConn) error {
+        if _, err := tx.ExecContext(ctx, `DELETE FROM staff_schedule WHERE staff_id = $1`, staffID); err != nil {
             return err
         }
-    }
 
-    return tx.Commit()
+        for _, entry := range entries {
+            _, err := tx.ExecContext(ctx, `
+                INSERT INTO staff_schedule (id, staff_id, day_of_week, start_time, end_time)
+                VALUES ($1, $2, $3, $4, $5)
+            `, uuid.New().String(), staffID, entry["day"], entry["start"], entry["end"])
+            if err != nil {
+                return err
+            }
+        }
+        return nil
+    })
 }
-func (r *StaffRepository) GetSchedule(ctx context.Context, staffID string) ([]map[string]string, error) {
+func (r *sqlStaffRepository) GetSchedule(ctx context.Context, staffID string) ([]map[string]string, error) {
     query := `SELECT day_of_week, start_time, end_time FROM staff_schedule WHERE staff_id = $1`
-    rows, err := r.db.QueryContext(ctx, query, staffID)
+    rows, err := conn(ctx, r.db).QueryContext(ctx, query, staffID)
     if err != nil {
         return nil, err
     }
@@ -200,13 +207,13 @@ func (r *StaffRepository) GetSchedule(ctx context.Context, staffID string) ([]ma
 
 // ------------------- HOLIDAYS -------------------
 
-func (r *StaffRepository) AddHoliday(ctx context.Context, staffID, date, reason string) error {
+func (r *sqlStaffRepository) AddHoliday(ctx context.Context, staffID, date, reason string) error {
     query := `INSERT INTO staff_holidays (id, staff_id, date, reason) VALUES ($1, $2, $3, $4)`
-    _, err := r.db.ExecContext(ctx, query, uuid.New().String(), staffID, date, reason)
+    _, err := conn(ctx, r.db).ExecContext(ctx, query, uuid.New().String(), staffID, date, reason)
     return err
 }
 // Refactored to return both for the availability service
-func (r *StaffRepository) GetAvailabilityData(ctx context.Context, staffID string) ([]map[string]string, []string, error) {
+func (r *sqlStaffRepository) GetAvailabilityData(ctx context.Context, staffID string) ([]map[string]string, []string, error) {
     // 1. Reuse your GetSchedule logic
     schedule, err := r.GetSchedule(ctx, staffID)
     if err != nil {
@@ -214,19 +221,20 @@ func (r *StaffRepository) GetAvailabilityData(ctx context.Context, staffID strin
     }
 
     // 2. Get Holiday Dates
-    hRows, err := r.db.QueryContext(ctx, `SELECT date FROM staff_holidays WHERE staff_id = $1`, staffID)
+    hRows, err := conn(ctx, r.db).QueryContext(ctx, `SELECT date FROM staff_holidays WHERE staff_id = $1`, staffID)
     if err != nil {
         return nil, nil, err
     }
     defer hRows.Close()
 
+    // Both drivers hand DATE columns back as time.Time
     var holidays []string
     for hRows.Next() {
-        var date string
+        var date time.Time
         if err := hRows.Scan(&date); err != nil {
             return nil, nil, err
         }
-        holidays = append(holidays, date)
+        holidays = append(holidays, date.Format("2006-01-02"))
     }
 
     return schedule, holidays, nil
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Transactor runs several repository calls in one database transaction.
+// The transaction travels in the context: repository methods that take a
+// context use it when called with the context passed to fn. Keep other
+// database access out of fn; with SQLite's single connection it would wait
+// for the transaction forever.
+type Transactor interface {
+	// WithinTx runs fn in a transaction, committed if fn returns nil and
+	// rolled back otherwise. Inside another WithinTx it joins the outer
+	// transaction.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type sqlTransactor struct {
+	db *sql.DB
+}
+
+func NewTransactor(db *sql.DB) Transactor {
+	return &sqlTransactor{db: db}
+}
+
+type txKey struct{}
+
+type txState struct {
+	tx          *sql.Tx
+	done        bool
+	afterCommit []func()
+}
+
+func (t *sqlTransactor) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if currentTx(ctx) != nil {
+		return fn(ctx)
+	}
+
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	state := &txState{tx: tx}
+	defer func() { state.done = true }()
+
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	state.done = true
+	for _, f := range state.afterCommit {
+		f()
+	}
+	return nil
+}
+
+// AfterCommit defers fn until the transaction in ctx commits; it is dropped
+// if the transaction rolls back. It reports false if ctx carries no
+// transaction, leaving fn to the caller.
+func AfterCommit(ctx context.Context, fn func()) bool {
+	state := currentTx(ctx)
+	if state == nil {
+		return false
+	}
+	state.afterCommit = append(state.afterCommit, fn)
+	return true
+}
+
+// InTx reports whether ctx carries an open transaction.
+func InTx(ctx context.Context) bool {
+	return currentTx(ctx) != nil
+}
+
+func currentTx(ctx context.Context) *txState {
+	state, _ := ctx.Value(txKey{}).(*txState)
+	if state == nil || state.done {
+		return nil
+	}
+	return state
+}
+
+// dbConn is what *sql.DB and *sql.Tx have in common.
+type dbConn interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// conn returns the transaction in ctx, or db outside one.
+func conn(ctx context.Context, db *sql.DB) dbConn {
+	if state := currentTx(ctx); state != nil {
+		return state.tx
+	}
+	return db
+}
+
+// withTx runs fn in the transaction in ctx or, outside one, in a
+// transaction of its own.
+func withTx(ctx context.Context, db *sql.DB, fn func(tx dbConn) error) error {
+	if state := currentTx(ctx); state != nil {
+		return fn(state.tx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -1,7 +1,9 @@
 package repositories
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"strings"
 	"time"
 
@@ -9,18 +11,45 @@ import (
 	"github.com/google/uuid"
 )
 
-type UserRepository struct {
+// UserRepository stores user accounts. The methods taking a context write
+// in the transaction it carries, if any (see Transactor).
+type UserRepository interface {
+	CreateUser(ctx context.Context, user *models.User) error
+	GetByEmail(email string) (*models.User, error)
+	GetUserByID(id string) (*models.User, error)
+	UpdateUser(ctx context.Context, id string, user *models.User) error
+	UpdatePassword(id string, hashedPassword string) error
+	DeleteUser(id string) error
+	ExistsByEmail(email string) (bool, error)
+	FetchAllUsers() ([]models.User, error)
+	SoftDeleteUser(ctx context.Context, id string, deadline time.Time) (bool, error)
+	RestoreUser(ctx context.Context, id string) (bool, error)
+	IsUserDeleted(id string) (bool, error)
+	GetActiveByID(id string) (*models.User, error)
+	GetDeletedByID(id string) (*models.User, error)
+	GetDeletedByEmail(email string) (*models.User, error)
+	ListDeletedBefore(deadline time.Time, unremindedOnly bool) ([]models.User, error)
+	MarkDeletionReminded(id string, at time.Time) (bool, error)
+	AddRestoreToken(userID, tokenHash string, expiresAt time.Time) error
+	RestoreByToken(ctx context.Context, tokenHash string, now time.Time) (string, error)
+	PurgeUser(ctx context.Context, id string, now time.Time) (bool, error)
+	MarkEmailVerified(id, email string) (bool, error)
+	UpdateUserRole(ctx context.Context, id, role string) error
+	FetchUsersPaginated(page, limit int) ([]models.User, error)
+}
+
+type sqlUserRepository struct {
 	db *sql.DB
 }
 
-func NewUserRepository(db *sql.DB) *UserRepository {
-	return &UserRepository{db: db}
+func NewUserRepository(db *sql.DB) UserRepository {
+	return &sqlUserRepository{db: db}
 }
 
 // ----------------------------
 // CREATE USER
 // ----------------------------
-func (r *UserRepository) CreateUser(user *models.User) error {
+func (r *sqlUserRepository) CreateUser(ctx context.Context, user *models.User) error {
 	if user.ID == "" {
 		user.ID = uuid.New().String()
 	}
@@ -30,7 +59,7 @@ func (r *UserRepository) CreateUser(user *models.User) error {
 		VALUES ($1, $2, $3, $4, $5, NULL, NULL)
 	`
 
-	_, err := r.db.Exec(
+	_, err := conn(ctx, r.db).ExecContext(ctx,
 		query,
 		user.ID,
 		user.Username,
@@ -44,46 +73,58 @@ func (r *UserRepository) CreateUser(user *models.User) error {
 // ----------------------------
 // GET USER BY EMAIL
 // ----------------------------
-func (r *UserRepository) GetByEmail(email string) (*models.User, error) {
+func (r *sqlUserRepository) GetByEmail(email string) (*models.User, error) {
 	email = strings.ToLower(strings.TrimSpace(email))
 
 	row := r.db.QueryRow(`
-		SELECT id, username, email, password, role
+		SELECT id, username, email, password, role, verified_at
 		FROM users
 		WHERE LOWER(email) = $1 AND deleted_at IS NULL
 	`, email)
 
 	var u models.User
-	if err := row.Scan(&u.ID, &u.Username, &u.Email, &u.Password, &u.Role); err != nil {
+	var verifiedAt sql.NullTime
+	if err := row.Scan(&u.ID, &u.Username, &u.Email, &u.Password, &u.Role, &verifiedAt); err != nil {
 		return nil, err
 	}
+
+	if verifiedAt.Valid {
+		u.VerifiedAt = &verifiedAt.Time
+	}
 	return &u, nil
 }
 
 // ----------------------------
 // GET USER BY ID
 // ----------------------------
-func (r *UserRepository) GetUserByID(id string) (*models.User, error) {
+func (r *sqlUserRepository) GetUserByID(id string) (*models.User, error) {
 	row := r.db.QueryRow(`
-		SELECT id, username, email, password, role
+		SELECT id, username, email, password, role, verified_at
 		FROM users
 		WHERE id = $1 AND deleted_at IS NULL
 	`, id)
 
 	var u models.User
-	if err := row.Scan(&u.ID, &u.Username, &u.Email, &u.Password, &u.Role); err != nil {
+	var verifiedAt sql.NullTime
+	if err := row.Scan(&u.ID, &u.Username, &u.Email, &u.Password, &u.Role, &verifiedAt); err != nil {
 		return nil, err
 	}
+
+	if verifiedAt.Valid {
+		u.VerifiedAt = &verifiedAt.Time
+	}
 	return &u, nil
 }
 
 // ----------------------------
 // UPDATE USER PROFILE
 // ----------------------------
-func (r *UserRepository) UpdateUser(id string, user *models.User) error {
-	_, err := r.db.Exec(`
+func (r *sqlUserRepository) UpdateUser(ctx context.Context, id string, user *models.User) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
 		UPDATE users
-		SET username = $1, email = $2
+		SET username = $1,
+		    verified_at = CASE WHEN LOWER(email) = $2 THEN verified_at ELSE NULL END,
+		    email = $2
 		WHERE id = $3 AND deleted_at IS NULL
 	`, user.Username, strings.ToLower(user.Email), id)
 
@@ -93,7 +134,7 @@ func (r *UserRepository) UpdateUser(id string, user *models.User) error {
 // ----------------------------
 // UPDATE PASSWORD
 // ----------------------------
-func (r *UserRepository) UpdatePassword(id string, hashedPassword string) error {
+func (r *sqlUserRepository) UpdatePassword(id string, hashedPassword string) error {
 	_, err := r.db.Exec(`
 		UPDATE users
 		SET password = $1
@@ -106,7 +147,7 @@ func (r *UserRepository) UpdatePassword(id string, hashedPassword string) error
 // ----------------------------
 // HARD DELETE
 // ----------------------------
-func (r *UserRepository) DeleteUser(id string) error {
+func (r *sqlUserRepository) DeleteUser(id string) error {
 	_, err := r.db.Exec(`DELETE FROM users WHERE id = $1`, id)
 	return err
 }
@@ -114,7 +155,7 @@ func (r *UserRepository) DeleteUser(id string) error {
 // ----------------------------
 // CHECK IF EMAIL EXISTS
 // ----------------------------
-func (r *UserRepository) ExistsByEmail(email string) (bool, error) {
+func (r *sqlUserRepository) ExistsByEmail(email string) (bool, error) {
 	email = strings.ToLower(strings.TrimSpace(email))
 
 	row := r.db.QueryRow(`
@@ -134,7 +175,7 @@ func (r *UserRepository) ExistsByEmail(email string) (bool, error) {
 // ----------------------------
 // FETCH ALL USERS
 // ----------------------------
-func (r *UserRepository) FetchAllUsers() ([]models.User, error) {
+func (r *sqlUserRepository) FetchAllUsers() ([]models.User, error) {
 	rows, err := r.db.Query(`
 		SELECT id, username, email, role
 		FROM users
@@ -159,36 +200,59 @@ func (r *UserRepository) FetchAllUsers() ([]models.User, error) {
 // ----------------------------
 // SOFT DELETE USER
 // ----------------------------
-func (r *UserRepository) SoftDeleteUser(id string) error {
-	now := time.Now()
-	deadline := now.Add(14 * 24 * time.Hour)
-
-	_, err := r.db.Exec(`
+// SoftDeleteUser hides the account until deadline, when it is purged. It
+// returns false if the user does not exist or is already deleted.
+func (r *sqlUserRepository) SoftDeleteUser(ctx context.Context, id string, deadline time.Time) (bool, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
 		UPDATE users
-		SET deleted_at = $1, delete_deadline = $2
+		SET deleted_at = $1, delete_deadline = $2, deletion_reminded_at = NULL
 		WHERE id = $3 AND deleted_at IS NULL
-	`, now, deadline, id)
-
-	return err
+	`, time.Now(), deadline, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
 }
 
 // ----------------------------
 // RESTORE USER
 // ----------------------------
-func (r *UserRepository) RestoreUser(id string) error {
-	_, err := r.db.Exec(`
+// RestoreUser returns false if the user is not (or no longer) deleted.
+func (r *sqlUserRepository) RestoreUser(ctx context.Context, id string) (bool, error) {
+	var restored bool
+	err := withTx(ctx, r.db, func(tx dbConn) error {
+		var err error
+		restored, err = restoreUser(ctx, tx, id)
+		return err
+	})
+	return restored, err
+}
+
+// restoreUser undeletes the user and invalidates their restore tokens.
+func restoreUser(ctx context.Context, tx dbConn, id string) (bool, error) {
+	res, err := tx.ExecContext(ctx, `
 		UPDATE users
-		SET deleted_at = NULL, delete_deadline = NULL
-		WHERE id = $1
+		SET deleted_at = NULL, delete_deadline = NULL, deletion_reminded_at = NULL
+		WHERE id = $1 AND deleted_at IS NOT NULL
 	`, id)
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
 
-	return err
+	if _, err := tx.ExecContext(ctx, `DELETE FROM account_restore_tokens WHERE user_id = $1`, id); err != nil {
+		return false, err
+	}
+	return true, nil
 }
 
 // ----------------------------
 // CHECK IF USER IS DELETED
 // ----------------------------
-func (r *UserRepository) IsUserDeleted(id string) (bool, error) {
+func (r *sqlUserRepository) IsUserDeleted(id string) (bool, error) {
 	row := r.db.QueryRow(`
 		SELECT deleted_at
 		FROM users
@@ -207,19 +271,23 @@ func (r *UserRepository) IsUserDeleted(id string) (bool, error) {
 // ----------------------------
 // GET ACTIVE USER BY ID
 // ----------------------------
-func (r *UserRepository) GetActiveByID(id string) (*models.User, error) {
+func (r *sqlUserRepository) GetActiveByID(id string) (*models.User, error) {
 	row := r.db.QueryRow(`
-		SELECT id, username, email, role, deleted_at
+		SELECT id, username, email, role, deleted_at, verified_at
 		FROM users
 		WHERE id = $1 AND deleted_at IS NULL
 	`, id)
 
 	var u models.User
-	var deletedAt sql.NullTime
-	if err := row.Scan(&u.ID, &u.Username, &u.Email, &u.Role, &deletedAt); err != nil {
+	var deletedAt, verifiedAt sql.NullTime
+	if err := row.Scan(&u.ID, &u.Username, &u.Email, &u.Role, &deletedAt, &verifiedAt); err != nil {
 		return nil, err
 	}
 
+	if verifiedAt.Valid {
+		u.VerifiedAt = &verifiedAt.Time
+	}
+
 	if deletedAt.Valid {
 		u.DeletedAt = &deletedAt.Time
 	}
@@ -227,23 +295,193 @@ func (r *UserRepository) GetActiveByID(id string) (*models.User, error) {
 }
 
 // ----------------------------
-// PERMANENT DELETE EXPIRED
+// MARK EMAIL VERIFIED
+// ----------------------------
+// Only verifies if the account still has the email the link was sent to.
+func (r *sqlUserRepository) MarkEmailVerified(id, email string) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE users
+		SET verified_at = $1
+		WHERE id = $2 AND LOWER(email) = $3 AND deleted_at IS NULL AND verified_at IS NULL
+	`, time.Now(), id, strings.ToLower(email))
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ----------------------------
+// DELETED USERS
 // ----------------------------
-func (r *UserRepository) PermanentlyDeleteExpired() error {
+const deletedUserColumns = `id, username, email, password, role, deleted_at, delete_deadline, verified_at`
+
+func scanDeletedUser(row rowScanner) (*models.User, error) {
+	var u models.User
+	var deletedAt, deadline, verifiedAt sql.NullTime
+	if err := row.Scan(&u.ID, &u.Username, &u.Email, &u.Password, &u.Role, &deletedAt, &deadline, &verifiedAt); err != nil {
+		return nil, err
+	}
+
+	if deletedAt.Valid {
+		u.DeletedAt = &deletedAt.Time
+	}
+	if deadline.Valid {
+		u.DeleteDeadline = &deadline.Time
+	}
+	if verifiedAt.Valid {
+		u.VerifiedAt = &verifiedAt.Time
+	}
+	return &u, nil
+}
+
+// GetDeletedByID returns a user scheduled for deletion, or sql.ErrNoRows.
+func (r *sqlUserRepository) GetDeletedByID(id string) (*models.User, error) {
+	return scanDeletedUser(r.db.QueryRow(`
+		SELECT `+deletedUserColumns+`
+		FROM users
+		WHERE id = $1 AND deleted_at IS NOT NULL
+	`, id))
+}
+
+// GetDeletedByEmail returns a user scheduled for deletion, or sql.ErrNoRows.
+func (r *sqlUserRepository) GetDeletedByEmail(email string) (*models.User, error) {
+	return scanDeletedUser(r.db.QueryRow(`
+		SELECT `+deletedUserColumns+`
+		FROM users
+		WHERE LOWER(email) = $1 AND deleted_at IS NOT NULL
+	`, strings.ToLower(strings.TrimSpace(email))))
+}
+
+// ListDeletedBefore returns deleted users whose deadline is at or before
+// deadline; with unremindedOnly, only those not reminded yet.
+func (r *sqlUserRepository) ListDeletedBefore(deadline time.Time, unremindedOnly bool) ([]models.User, error) {
+	query := `
+		SELECT ` + deletedUserColumns + `
+		FROM users
+		WHERE deleted_at IS NOT NULL AND delete_deadline <= $1`
+	if unremindedOnly {
+		query += ` AND deletion_reminded_at IS NULL`
+	}
+
+	rows, err := r.db.Query(query+` ORDER BY delete_deadline`, deadline)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		u, err := scanDeletedUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *u)
+	}
+	return users, rows.Err()
+}
+
+// MarkDeletionReminded claims the deletion reminder for a user; it returns
+// false if the reminder was already sent (e.g. by another instance).
+func (r *sqlUserRepository) MarkDeletionReminded(id string, at time.Time) (bool, error) {
+	res, err := r.db.Exec(`
+		UPDATE users
+		SET deletion_reminded_at = $1
+		WHERE id = $2 AND deleted_at IS NOT NULL AND deletion_reminded_at IS NULL
+	`, at, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// AddRestoreToken stores a restore token for a deleted user.
+func (r *sqlUserRepository) AddRestoreToken(userID, tokenHash string, expiresAt time.Time) error {
 	_, err := r.db.Exec(`
-		DELETE FROM users
-		WHERE deleted_at IS NOT NULL
-		AND delete_deadline <= NOW()
-	`)
+		INSERT INTO account_restore_tokens (token_hash, user_id, expires_at)
+		VALUES ($1, $2, $3)
+	`, tokenHash, userID, expiresAt)
 	return err
 }
 
+// RestoreByToken restores the user an unexpired restore token belongs to and
+// returns their ID, or "" if the token is unknown or expired.
+func (r *sqlUserRepository) RestoreByToken(ctx context.Context, tokenHash string, now time.Time) (string, error) {
+	var restoredID string
+	err := withTx(ctx, r.db, func(tx dbConn) error {
+		var userID string
+		err := tx.QueryRowContext(ctx, `
+			SELECT user_id FROM account_restore_tokens
+			WHERE token_hash = $1 AND expires_at > $2
+		`, tokenHash, now).Scan(&userID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		restored, err := restoreUser(ctx, tx, userID)
+		if restored {
+			restoredID = userID
+		}
+		return err
+	})
+	return restoredID, err
+}
+
+// ----------------------------
+// PURGE USER
+// ----------------------------
+// PurgeUser permanently removes a deleted user whose deadline has passed.
+// Events they organised stay, without an organiser; their sessions,
+// bookings, tickets, MFA, API keys, identities and restore tokens go with the
+// account (ON DELETE CASCADE), along with reset codes and lockouts kept by
+// email. It returns false if the user was restored (or purged) in the
+// meantime.
+func (r *sqlUserRepository) PurgeUser(ctx context.Context, id string, now time.Time) (bool, error) {
+	var purged bool
+	err := withTx(ctx, r.db, func(tx dbConn) error {
+		var email string
+		err := tx.QueryRowContext(ctx, `
+			SELECT email FROM users
+			WHERE id = $1 AND deleted_at IS NOT NULL AND delete_deadline <= $2
+		`, id, now).Scan(&email)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.ExecContext(ctx, `UPDATE events SET user_id = NULL WHERE user_id = $1`, id); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM reset_tokens WHERE email = $1`, email); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx,
+			`DELETE FROM login_attempts WHERE scope = $1 AND identifier = $2`,
+			models.ThrottleScopeAccount, strings.ToLower(email),
+		); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE id = $1`, id); err != nil {
+			return err
+		}
+		purged = true
+		return nil
+	})
+	return purged, err
+}
+
 
 // ----------------------------
 // UPDATE USER ROLE
 // ----------------------------
-func (r *UserRepository) UpdateUserRole(id, role string) error {
-	_, err := r.db.Exec(`
+func (r *sqlUserRepository) UpdateUserRole(ctx context.Context, id, role string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
 		UPDATE users
 		SET role = $1
 		WHERE id = $2 AND deleted_at IS NULL
@@ -256,7 +494,7 @@ func (r *UserRepository) UpdateUserRole(id, role string) error {
 // ----------------------------
 // page: current page number (starting from 1)
 // limit: number of users per page
-func (r *UserRepository) FetchUsersPaginated(page, limit int) ([]models.User, error) {
+func (r *sqlUserRepository) FetchUsersPaginated(page, limit int) ([]models.User, error) {
 	if page < 1 {
 		page = 1
 	}
@@ -0,0 +1,290 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/models"
+)
+
+// WebhookRepository stores webhook endpoints and the deliveries to them.
+type WebhookRepository interface {
+	// Endpoints
+	CreateEndpoint(e *models.WebhookEndpoint) error
+	GetEndpoint(id string) (*models.WebhookEndpoint, error)
+	ListEndpoints() ([]models.WebhookEndpoint, error)
+	ListEnabledEndpoints() ([]models.WebhookEndpoint, error)
+	UpdateEndpoint(e *models.WebhookEndpoint) error
+	DeleteEndpoint(id string) (bool, error)
+
+	// Deliveries
+	EnqueueDeliveries(deliveries []models.WebhookDelivery) error
+	GetDelivery(endpointID, id string) (*models.WebhookDelivery, error)
+	ListDeliveries(filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error)
+	ListDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error)
+	ClaimDelivery(id string, now, leaseUntil time.Time) (bool, error)
+	MarkDelivered(id string, at time.Time, status int, body string) error
+	MarkRetry(id string, next time.Time, status int, body, lastError string) error
+	MarkDead(id string, status int, body, lastError string) error
+	DeleteDeliveriesBefore(before time.Time) (int64, error)
+}
+
+type sqlWebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) WebhookRepository {
+	return &sqlWebhookRepository{db: db}
+}
+
+// ---- ENDPOINTS ----
+
+const webhookEndpointColumns = `id, url, description, event_types, secret, enabled, created_by, created_at, updated_at`
+
+func scanWebhookEndpoint(row rowScanner) (*models.WebhookEndpoint, error) {
+	var e models.WebhookEndpoint
+	var eventTypes string
+	var createdBy sql.NullString
+	if err := row.Scan(&e.ID, &e.URL, &e.Description, &eventTypes, &e.Secret, &e.Enabled,
+		&createdBy, &e.CreatedAt, &e.UpdatedAt); err != nil {
+		return nil, err
+	}
+	e.EventTypes = strings.Fields(eventTypes)
+	e.CreatedBy = createdBy.String
+	return &e, nil
+}
+
+func (r *sqlWebhookRepository) CreateEndpoint(e *models.WebhookEndpoint) error {
+	_, err := r.db.Exec(
+		`INSERT INTO webhook_endpoints (`+webhookEndpointColumns+`)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+		e.ID, e.URL, e.Description, strings.Join(e.EventTypes, " "), e.Secret, e.Enabled,
+		nullIfEmpty(e.CreatedBy), e.CreatedAt, e.UpdatedAt,
+	)
+	return err
+}
+
+// GetEndpoint returns nil, nil for unknown endpoints.
+func (r *sqlWebhookRepository) GetEndpoint(id string) (*models.WebhookEndpoint, error) {
+	e, err := scanWebhookEndpoint(r.db.QueryRow(
+		`SELECT `+webhookEndpointColumns+` FROM webhook_endpoints WHERE id = $1`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return e, err
+}
+
+func (r *sqlWebhookRepository) ListEndpoints() ([]models.WebhookEndpoint, error) {
+	return r.listEndpoints(`SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints ORDER BY created_at`)
+}
+
+func (r *sqlWebhookRepository) ListEnabledEndpoints() ([]models.WebhookEndpoint, error) {
+	return r.listEndpoints(`SELECT `+webhookEndpointColumns+` FROM webhook_endpoints WHERE enabled = $1 ORDER BY created_at`, true)
+}
+
+func (r *sqlWebhookRepository) listEndpoints(query string, args ...any) ([]models.WebhookEndpoint, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	endpoints := []models.WebhookEndpoint{}
+	for rows.Next() {
+		e, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, *e)
+	}
+	return endpoints, rows.Err()
+}
+
+func (r *sqlWebhookRepository) UpdateEndpoint(e *models.WebhookEndpoint) error {
+	_, err := r.db.Exec(
+		`UPDATE webhook_endpoints
+		 SET url = $1, description = $2, event_types = $3, secret = $4, enabled = $5, updated_at = $6
+		 WHERE id = $7`,
+		e.URL, e.Description, strings.Join(e.EventTypes, " "), e.Secret, e.Enabled, e.UpdatedAt, e.ID,
+	)
+	return err
+}
+
+// DeleteEndpoint removes the endpoint and its delivery log.
+func (r *sqlWebhookRepository) DeleteEndpoint(id string) (bool, error) {
+	res, err := r.db.Exec(`DELETE FROM webhook_endpoints WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// ---- DELIVERIES ----
+
+const webhookDeliveryColumns = `id, endpoint_id, event_id, event_type, payload, status, attempts,
+	next_attempt_at, response_status, response_body, last_error, created_at, delivered_at`
+
+func scanWebhookDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	var payload string
+	var deliveredAt sql.NullTime
+	if err := row.Scan(&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts,
+		&d.NextAttemptAt, &d.ResponseStatus, &d.ResponseBody, &d.LastError, &d.CreatedAt, &deliveredAt); err != nil {
+		return nil, err
+	}
+	d.Payload = []byte(payload)
+	if deliveredAt.Valid {
+		d.DeliveredAt = &deliveredAt.Time
+	}
+	return &d, nil
+}
+
+// EnqueueDeliveries stores the deliveries of one event in a single
+// transaction.
+func (r *sqlWebhookRepository) EnqueueDeliveries(deliveries []models.WebhookDelivery) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, d := range deliveries {
+		if _, err := tx.Exec(
+			`INSERT INTO webhook_deliveries (id, endpoint_id, event_id, event_type, payload, status,
+				attempts, next_attempt_at, created_at)
+			 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			d.ID, d.EndpointID, d.EventID, d.EventType, string(d.Payload), d.Status,
+			d.Attempts, d.NextAttemptAt, d.CreatedAt,
+		); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetDelivery returns nil, nil unless the delivery exists and belongs to the
+// endpoint.
+func (r *sqlWebhookRepository) GetDelivery(endpointID, id string) (*models.WebhookDelivery, error) {
+	d, err := scanWebhookDelivery(r.db.QueryRow(
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = $1 AND endpoint_id = $2`,
+		id, endpointID,
+	))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return d, err
+}
+
+// ListDeliveries returns an endpoint's deliveries, newest first.
+func (r *sqlWebhookRepository) ListDeliveries(f models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + ` FROM webhook_deliveries WHERE endpoint_id = $1`
+	args := []any{f.EndpointID}
+	where := func(clause string, value any) {
+		args = append(args, value)
+		query += fmt.Sprintf(" AND "+clause, len(args))
+	}
+
+	if f.Status != "" {
+		where("status = $%d", f.Status)
+	}
+	if f.EventType != "" {
+		where("event_type = $%d", f.EventType)
+	}
+	args = append(args, f.Limit, f.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	return r.listDeliveries(query, args...)
+}
+
+// ListDueDeliveries returns pending deliveries whose next attempt is due,
+// oldest first.
+func (r *sqlWebhookRepository) ListDueDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	return r.listDeliveries(
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries
+		 WHERE status = $1 AND next_attempt_at <= $2
+		 ORDER BY next_attempt_at
+		 LIMIT $3`,
+		models.WebhookDeliveryPending, now, limit,
+	)
+}
+
+func (r *sqlWebhookRepository) listDeliveries(query string, args ...any) ([]models.WebhookDelivery, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+// ClaimDelivery counts an attempt and hides the delivery from other workers
+// until leaseUntil. It returns false if another worker claimed it first.
+func (r *sqlWebhookRepository) ClaimDelivery(id string, now, leaseUntil time.Time) (bool, error) {
+	res, err := r.db.Exec(
+		`UPDATE webhook_deliveries
+		 SET attempts = attempts + 1, next_attempt_at = $1
+		 WHERE id = $2 AND status = $3 AND next_attempt_at <= $4`,
+		leaseUntil, id, models.WebhookDeliveryPending, now,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+func (r *sqlWebhookRepository) MarkDelivered(id string, at time.Time, status int, body string) error {
+	_, err := r.db.Exec(
+		`UPDATE webhook_deliveries
+		 SET status = $1, delivered_at = $2, response_status = $3, response_body = $4, last_error = ''
+		 WHERE id = $5`,
+		models.WebhookDeliveryDelivered, at, status, body, id,
+	)
+	return err
+}
+
+func (r *sqlWebhookRepository) MarkRetry(id string, next time.Time, status int, body, lastError string) error {
+	_, err := r.db.Exec(
+		`UPDATE webhook_deliveries
+		 SET next_attempt_at = $1, response_status = $2, response_body = $3, last_error = $4
+		 WHERE id = $5`,
+		next, status, body, lastError, id,
+	)
+	return err
+}
+
+func (r *sqlWebhookRepository) MarkDead(id string, status int, body, lastError string) error {
+	_, err := r.db.Exec(
+		`UPDATE webhook_deliveries
+		 SET status = $1, response_status = $2, response_body = $3, last_error = $4
+		 WHERE id = $5`,
+		models.WebhookDeliveryDead, status, body, lastError, id,
+	)
+	return err
+}
+
+// DeleteDeliveriesBefore removes delivered and dead deliveries created
+// before the given time.
+func (r *sqlWebhookRepository) DeleteDeliveriesBefore(before time.Time) (int64, error) {
+	res, err := r.db.Exec(
+		`DELETE FROM webhook_deliveries WHERE status <> $1 AND created_at < $2`,
+		models.WebhookDeliveryPending, before,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/FiraBro/local-go/internal/handlers"
+	"github.com/FiraBro/local-go/internal/middlewares"
+	"github.com/FiraBro/local-go/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyRoutes sets up personal API key management. Keys can only be
+// managed from a logged-in session, never with another API key or while
+// impersonating.
+func APIKeyRoutes(api *gin.RouterGroup, handler *handlers.APIKeyHandler, authMW gin.HandlerFunc) {
+	keys := api.Group("/auth/api-keys")
+	keys.Use(
+		authMW,
+		middlewares.RateLimit(ratelimit.User),
+		middlewares.SessionRequired(),
+		middlewares.NotImpersonated(),
+	)
+	{
+		keys.GET("", handler.List)
+		keys.POST("", handler.Create)
+		keys.DELETE("/:id", handler.Revoke)
+	}
+}
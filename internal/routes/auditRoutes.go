@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"github.com/FiraBro/local-go/internal/handlers"
+	"github.com/FiraBro/local-go/internal/middlewares"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditRoutes sets up read access to the audit log.
+func AuditRoutes(api *gin.RouterGroup, handler *handlers.AuditHandler, authMW gin.HandlerFunc) {
+	api.GET("/audit",
+		authMW,
+		middlewares.RateLimit(ratelimit.Admin),
+		middlewares.RequirePermission(models.PermAuditRead),
+		handler.List,
+	)
+}
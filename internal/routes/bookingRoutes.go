@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/FiraBro/local-go/internal/handlers"
+	"github.com/FiraBro/local-go/internal/middlewares"
+	"github.com/FiraBro/local-go/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// BookingRoutes sets up routes for appointment bookings
+func BookingRoutes(api *gin.RouterGroup, handler *handlers.BookingHandler, authMW gin.HandlerFunc) {
+	bookings := api.Group("/bookings")
+	bookings.Use(authMW, middlewares.RateLimit(ratelimit.User))
+	{
+		bookings.POST("", middlewares.VerifiedEmailRequired(), handler.Create)
+		bookings.GET("", handler.ListMine)
+		bookings.GET("/:id", handler.GetByID)
+		bookings.POST("/:id/cancel", handler.Cancel)
+	}
+}
@@ -3,23 +3,34 @@ package routes
 import (
 	"github.com/FiraBro/local-go/internal/handlers"
 	"github.com/FiraBro/local-go/internal/middlewares"
-	"github.com/FiraBro/local-go/internal/repositories"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/ratelimit"
 
 	"github.com/gin-gonic/gin"
 )
 
 // SetupEventRoutes sets up public and protected event routes
-func SetupEventRoutes(rg *gin.RouterGroup, eventHandler *handlers.EventHandler, userRepo *repositories.UserRepository) {
-	// Public routes
-	rg.GET("/events", eventHandler.GetEvents)
-	rg.GET("/events/:id", eventHandler.GetEventByID)
+func SetupEventRoutes(rg *gin.RouterGroup, eventHandler *handlers.EventHandler, authMW gin.HandlerFunc) {
+	// Public routes, limited per IP
+	publicLimit := middlewares.RateLimit(ratelimit.Public)
+	rg.GET("/events", publicLimit, eventHandler.GetEvents)
+	rg.GET("/events/:id", publicLimit, eventHandler.GetEventByID)
+	rg.GET("/events/:id/ticket-types", publicLimit, eventHandler.GetTicketTypes)
 
 	// Protected routes (requires authentication)
 	authGroup := rg.Group("/")
-	authGroup.Use(middlewares.AuthMiddleware(userRepo))
+	authGroup.Use(authMW, middlewares.RateLimit(ratelimit.User))
 	{
 		authGroup.POST("/events", eventHandler.CreateEvent)
-		authGroup.PUT("/events/:id", eventHandler.UpdateEvent)
-		authGroup.DELETE("/events/:id", eventHandler.DeleteEvent)
+		ownerMW := middlewares.OwnerOrPermission(models.PermEventsManage, eventHandler.EventOwnerID)
+		authGroup.PUT("/events/:id", ownerMW, eventHandler.UpdateEvent)
+		authGroup.DELETE("/events/:id", ownerMW, eventHandler.DeleteEvent)
+
+		// Ticketing
+		authGroup.POST("/events/:id/ticket-types", eventHandler.CreateTicketType)
+		authGroup.POST("/events/:id/register", middlewares.VerifiedEmailRequired(), eventHandler.Register)
+		authGroup.DELETE("/events/:id/register", eventHandler.CancelRegistration)
+		authGroup.GET("/events/:id/attendees", eventHandler.GetAttendees)
+		authGroup.GET("/tickets", eventHandler.GetMyTickets)
 	}
 }
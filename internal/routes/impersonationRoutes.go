@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"github.com/FiraBro/local-go/internal/handlers"
+	"github.com/FiraBro/local-go/internal/middlewares"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// ImpersonationRoutes sets up admin impersonation and its audit trail.
+func ImpersonationRoutes(api *gin.RouterGroup, handler *handlers.ImpersonationHandler, authMW gin.HandlerFunc) {
+	userLimit := middlewares.RateLimit(ratelimit.User)
+	adminLimit := middlewares.RateLimit(ratelimit.Admin)
+	securityMW := middlewares.RequirePermission(models.PermSecurityManage)
+
+	// Only from an admin's own login: not with an API key, and not nested
+	api.POST("/users/:id/impersonate", authMW, adminLimit,
+		middlewares.SessionRequired(),
+		middlewares.NotImpersonated(),
+		middlewares.RequirePermission(models.PermUsersImpersonate),
+		handler.Start,
+	)
+
+	// Called with the impersonation token itself
+	api.DELETE("/auth/impersonation", authMW, userLimit, handler.End)
+
+	api.GET("/impersonations", authMW, adminLimit, securityMW, handler.List)
+	api.GET("/impersonations/:id/requests", authMW, adminLimit, securityMW, handler.Requests)
+}
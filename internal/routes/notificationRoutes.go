@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/FiraBro/local-go/internal/handlers"
+	"github.com/FiraBro/local-go/internal/middlewares"
+	"github.com/FiraBro/local-go/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// NotificationRoutes sets up the user's reminder preferences.
+func NotificationRoutes(api *gin.RouterGroup, handler *handlers.NotificationHandler, authMW gin.HandlerFunc) {
+	userLimit := middlewares.RateLimit(ratelimit.User)
+	sessionMW := middlewares.SessionRequired()
+
+	api.GET("/auth/profile/notifications", authMW, userLimit, handler.GetPreferences)
+	api.PUT("/auth/profile/notifications", authMW, userLimit, sessionMW, handler.UpdatePreferences)
+}
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/FiraBro/local-go/internal/handlers"
+	"github.com/FiraBro/local-go/internal/middlewares"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// RoleRoutes sets up role and permission administration
+func RoleRoutes(api *gin.RouterGroup, handler *handlers.RoleHandler, authMW gin.HandlerFunc) {
+	group := api.Group("")
+	group.Use(
+		authMW,
+		middlewares.RateLimit(ratelimit.Admin),
+		middlewares.RequirePermission(models.PermRolesManage),
+	)
+	{
+		group.GET("/roles", handler.ListRoles)
+		group.POST("/roles", handler.CreateRole)
+		group.PUT("/roles/:name/permissions/:permission", handler.Grant)
+		group.DELETE("/roles/:name/permissions/:permission", handler.Revoke)
+		group.GET("/permissions", handler.ListPermissions)
+	}
+}
@@ -3,23 +3,25 @@ package routes
 import (
 	"github.com/FiraBro/local-go/internal/handlers"
 	"github.com/FiraBro/local-go/internal/middlewares"
-	"github.com/FiraBro/local-go/internal/repositories"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/ratelimit"
 	"github.com/gin-gonic/gin"
 )
 
 // ServiceRoutes sets up routes for services
-func ServiceRoutes(api *gin.RouterGroup, handler *handlers.ServiceHandler, userRepo *repositories.UserRepository) {
-	// Public routes
-	api.GET("/services", handler.GetAll)
-	api.GET("/services/:id", handler.GetByID)
-	api.GET("/services/categories", handler.Categories)
+func ServiceRoutes(api *gin.RouterGroup, handler *handlers.ServiceHandler, authMW gin.HandlerFunc) {
+	// Public routes, limited per IP
+	publicLimit := middlewares.RateLimit(ratelimit.Public)
+	api.GET("/services", publicLimit, handler.GetAll)
+	api.GET("/services/:id", publicLimit, handler.GetByID)
+	api.GET("/services/categories", publicLimit, handler.Categories)
 
 	// Authenticated middleware
-	authMW := middlewares.AuthMiddleware(userRepo)
-	adminMW := middlewares.AdminOnly()
+	adminLimit := middlewares.RateLimit(ratelimit.Admin)
+	writeMW := middlewares.RequirePermission(models.PermServicesWrite)
 
-	// Admin-only routes
-	api.POST("/services", authMW, adminMW, handler.Create)
-	api.PATCH("/services/:id", authMW, adminMW, handler.Update)
-	api.DELETE("/services/:id", authMW, adminMW, handler.Delete)
+	// Catalogue management
+	api.POST("/services", authMW, adminLimit, writeMW, handler.Create)
+	api.PATCH("/services/:id", authMW, adminLimit, writeMW, handler.Update)
+	api.DELETE("/services/:id", authMW, adminLimit, writeMW, handler.Delete)
 }
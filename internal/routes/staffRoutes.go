@@ -3,39 +3,42 @@ package routes
 import (
 	"github.com/FiraBro/local-go/internal/handlers"
 	"github.com/FiraBro/local-go/internal/middlewares"
-	"github.com/FiraBro/local-go/internal/repositories"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/ratelimit"
 	"github.com/gin-gonic/gin"
 )
 
 func StaffRoutes(
     api *gin.RouterGroup,
     handler *handlers.StaffHandler,
-    userRepo *repositories.UserRepository,
+    authMW gin.HandlerFunc,
 ) {
-    authMW := middlewares.AuthMiddleware(userRepo)
-    adminMW := middlewares.AdminOnly()
+    writeMW := middlewares.RequirePermission(models.PermStaffWrite)
+    scheduleMW := middlewares.RequirePermission(models.PermStaffSchedule)
+    userLimit := middlewares.RateLimit(ratelimit.User)
+    adminLimit := middlewares.RateLimit(ratelimit.Admin)
 
     // 1. Staff Resource Routes (Under /staff)
     staff := api.Group("/staff")
     staff.Use(authMW) 
     {
-        staff.GET("", handler.ListStaff)           
-        staff.GET("/:id", handler.GetStaffDetails)       
-        staff.GET("/:id/services", handler.GetServices)
-        staff.GET("/:id/schedule", handler.GetSchedule)
+        staff.GET("", userLimit, handler.ListStaff)           
+        staff.GET("/:id", userLimit, handler.GetStaffDetails)       
+        staff.GET("/:id/services", userLimit, handler.GetServices)
+        staff.GET("/:id/schedule", userLimit, handler.GetSchedule)
 
-        staff.POST("", adminMW, handler.Create)                  
-        staff.PATCH("/:id", adminMW, handler.Update)            
-        staff.DELETE("/:id", adminMW, handler.Delete)           
-        staff.POST("/:id/services", adminMW, handler.AssignServices)
-        staff.POST("/:id/schedule", adminMW, handler.SetSchedule)
-        staff.POST("/:id/holidays", adminMW, handler.AddHoliday)
+        staff.POST("", adminLimit, writeMW, handler.Create)                  
+        staff.PATCH("/:id", adminLimit, writeMW, handler.Update)            
+        staff.DELETE("/:id", adminLimit, writeMW, handler.Delete)           
+        staff.POST("/:id/services", adminLimit, writeMW, handler.AssignServices)
+        staff.POST("/:id/schedule", adminLimit, scheduleMW, handler.SetSchedule)
+        staff.POST("/:id/holidays", adminLimit, scheduleMW, handler.AddHoliday)
     }
 
     // 2. Availability Routes (Under /availability)
     // These are usually public or require basic auth
     availability := api.Group("/availability")
-    availability.Use(authMW) 
+    availability.Use(authMW, userLimit)
     {
         // GET /api/v1/availability/services/:serviceId
         availability.GET("/services/:serviceId", handler.GetServiceAvailability)
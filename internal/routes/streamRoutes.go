@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/FiraBro/local-go/internal/handlers"
+	"github.com/FiraBro/local-go/internal/middlewares"
+	"github.com/FiraBro/local-go/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// StreamRoutes sets up the server-sent events stream of live updates.
+func StreamRoutes(api *gin.RouterGroup, handler *handlers.StreamHandler, authMW gin.HandlerFunc) {
+	userLimit := middlewares.RateLimit(ratelimit.User)
+
+	api.GET("/stream", authMW, userLimit, handler.Stream)
+}
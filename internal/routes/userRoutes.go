@@ -3,41 +3,84 @@ package routes
 import (
 	"github.com/FiraBro/local-go/internal/handlers"
 	"github.com/FiraBro/local-go/internal/middlewares"
-	"github.com/FiraBro/local-go/internal/repositories"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/ratelimit"
 	"github.com/gin-gonic/gin"
 )
 
 // AuthRoutes sets up authentication routes
-func AuthRoutes(api *gin.RouterGroup, authHandler *handlers.AuthHandler, userRepo *repositories.UserRepository) {
-	// Public auth endpoints
-	api.POST("/auth/register", authHandler.Register)
-	api.POST("/auth/login", authHandler.Login)
-	api.POST("/auth/refresh", authHandler.Refresh)
-	api.POST("/auth/logout", authHandler.Logout)
-	api.POST("/auth/forgot-password", authHandler.ForgotPassword)
-	api.POST("/auth/reset-password", authHandler.ResetPassword)
+func AuthRoutes(api *gin.RouterGroup, authHandler *handlers.AuthHandler, authMW gin.HandlerFunc) {
+	// Public auth endpoints, limited per IP
+	authLimit := middlewares.RateLimit(ratelimit.Auth)
+	api.POST("/auth/register", authLimit, authHandler.Register)
+	api.POST("/auth/login", authLimit, authHandler.Login)
+	api.POST("/auth/login/mfa", authLimit, authHandler.LoginMFA)
+	api.POST("/auth/login/mfa/setup", authLimit, authHandler.LoginMFASetup)
+	api.POST("/auth/refresh", authLimit, authHandler.Refresh)
+	api.POST("/auth/logout", authLimit, authHandler.Logout)
+	api.GET("/auth/verify-email", authLimit, authHandler.VerifyEmail)
+	api.POST("/auth/verify-email", authLimit, authHandler.VerifyEmail)
+	api.POST("/auth/resend-verification", authLimit, authHandler.ResendVerification)
+	api.POST("/auth/forgot-password", authLimit, authHandler.ForgotPassword)
+	api.POST("/auth/reset-password", authLimit, authHandler.ResetPassword)
+	api.GET("/auth/password-policy", authLimit, authHandler.PasswordPolicy)
+	api.GET("/auth/restore-account", authLimit, authHandler.RestoreAccount)
+	api.POST("/auth/restore-account", authLimit, authHandler.RestoreAccount)
+
+	// Social login (OIDC authorization code + PKCE)
+	api.GET("/auth/oidc/providers", authLimit, authHandler.OIDCProviders)
+	api.GET("/auth/oidc/:provider/login", authLimit, authHandler.OIDCLogin)
+	api.GET("/auth/oidc/:provider/callback", authLimit, authHandler.OIDCCallback)
 
 	// Authenticated routes
-	authMW := middlewares.AuthMiddleware(userRepo)
-	api.GET("/auth/profile", authMW, authHandler.GetProfile)
-	api.PATCH("/auth/profile", authMW, authHandler.UpdateProfile)
-	api.PATCH("/auth/change-password", authMW, authHandler.ChangePassword)
-	api.DELETE("/auth/delete-account", authMW, authHandler.DeleteUser)
-	api.POST("/auth/restore-account", authMW, authHandler.RestoreUser)
+	userLimit := middlewares.RateLimit(ratelimit.User)
+	adminLimit := middlewares.RateLimit(ratelimit.Admin)
+	sessionMW := middlewares.SessionRequired() // credentials and sessions are off-limits to API keys
+	notImpMW := middlewares.NotImpersonated()  // ...and to admins impersonating the user
+	api.GET("/auth/profile", authMW, userLimit, authHandler.GetProfile)
+	api.PATCH("/auth/profile", authMW, userLimit, sessionMW, authHandler.UpdateProfile)
+	api.PATCH("/auth/change-password", authMW, userLimit, sessionMW, notImpMW, authHandler.ChangePassword)
+	api.DELETE("/auth/delete-account", authMW, userLimit, sessionMW, notImpMW, authHandler.DeleteUser)
+
+	// Sessions (one per login, across refreshes)
+	api.GET("/auth/sessions", authMW, userLimit, sessionMW, notImpMW, authHandler.ListSessions)
+	api.DELETE("/auth/sessions/:id", authMW, userLimit, sessionMW, notImpMW, authHandler.RevokeSession)
+	api.POST("/auth/logout-all", authMW, userLimit, sessionMW, notImpMW, authHandler.LogoutAll)
+
+	// Linked social login accounts
+	api.GET("/auth/identities", authMW, userLimit, sessionMW, notImpMW, authHandler.ListIdentities)
+	api.DELETE("/auth/identities/:id", authMW, userLimit, sessionMW, notImpMW, authHandler.UnlinkIdentity)
+
+	// Two-factor authentication (TOTP)
+	api.GET("/auth/mfa", authMW, userLimit, sessionMW, notImpMW, authHandler.GetMFAStatus)
+	api.POST("/auth/mfa/setup", authMW, userLimit, sessionMW, notImpMW, authHandler.SetupMFA)
+	api.POST("/auth/mfa/confirm", authMW, userLimit, sessionMW, notImpMW, authHandler.ConfirmMFA)
+	api.POST("/auth/mfa/disable", authMW, userLimit, sessionMW, notImpMW, authHandler.DisableMFA)
+	api.POST("/auth/mfa/recovery-codes", authMW, userLimit, sessionMW, notImpMW, authHandler.RegenerateRecoveryCodes)
+	securityMW := middlewares.RequirePermission(models.PermSecurityManage)
+	api.GET("/auth/mfa/policy", authMW, adminLimit, securityMW, authHandler.GetMFAPolicy)
+	api.PUT("/auth/mfa/policy", authMW, adminLimit, securityMW, authHandler.SetMFAPolicy)
+
+	// Failed-login lockouts
+	api.GET("/auth/lockouts", authMW, adminLimit, securityMW, authHandler.ListLoginAttempts)
+	api.DELETE("/auth/lockouts/:scope/:identifier", authMW, adminLimit, securityMW, authHandler.ClearLoginAttempts)
 }
 
-func UserRoutes(api *gin.RouterGroup, authHandler *handlers.AuthHandler, userRepo *repositories.UserRepository) {
-	authMW := middlewares.AuthMiddleware(userRepo)
-	adminMW := middlewares.AdminOnly()
+func UserRoutes(api *gin.RouterGroup, authHandler *handlers.AuthHandler, authMW gin.HandlerFunc) {
+	userLimit := middlewares.RateLimit(ratelimit.User)
+	adminLimit := middlewares.RateLimit(ratelimit.Admin)
+	readMW := middlewares.RequirePermission(models.PermUsersRead)
+	writeMW := middlewares.RequirePermission(models.PermUsersWrite)
 
-	// Admin-only routes under /users
-	api.GET("/users", authMW, adminMW, authHandler.GetPaginatedUsers)       // GET /api/v1/users?page=1&limit=10
-	api.POST("/users", authMW, adminMW, authHandler.CreateUserHandler)      // POST /api/v1/users
-	api.PATCH("/users/:id", authMW, adminMW, authHandler.UpdateUserHandler) // PATCH /api/v1/users/:id
-	api.PATCH("/users/:id/role", authMW, adminMW, authHandler.UpdateUserRole)
-	api.DELETE("/users/:id", authMW, adminMW, authHandler.DeleteUser)
+	// User administration under /users
+	api.GET("/users", authMW, adminLimit, readMW, authHandler.GetPaginatedUsers)         // GET /api/v1/users?page=1&limit=10
+	api.POST("/users", authMW, adminLimit, writeMW, authHandler.CreateUserHandler)       // POST /api/v1/users
+	api.PATCH("/users/:id", authMW, adminLimit, writeMW, authHandler.UpdateUserHandler)  // PATCH /api/v1/users/:id
+	api.PATCH("/users/:id/role", authMW, adminLimit, writeMW, authHandler.UpdateUserRole)
+	api.DELETE("/users/:id", authMW, adminLimit, writeMW, authHandler.DeleteUserByID)
+	api.POST("/users/:id/restore", authMW, adminLimit, writeMW, authHandler.RestoreUserByID)
 
 	// Single user fetch (any authenticated user)
-	api.GET("/users/:id", authMW, authHandler.GetUserByID)
+	api.GET("/users/:id", authMW, userLimit, authHandler.GetUserByID)
 }
 
@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"github.com/FiraBro/local-go/internal/handlers"
+	"github.com/FiraBro/local-go/internal/middlewares"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/ratelimit"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookRoutes sets up webhook endpoint administration and the delivery log.
+func WebhookRoutes(api *gin.RouterGroup, handler *handlers.WebhookHandler, authMW gin.HandlerFunc) {
+	group := api.Group("/webhooks")
+	group.Use(
+		authMW,
+		middlewares.RateLimit(ratelimit.Admin),
+		middlewares.RequirePermission(models.PermWebhooksManage),
+	)
+	{
+		group.GET("/event-types", handler.EventTypes)
+		group.GET("", handler.List)
+		group.POST("", handler.Create)
+		group.GET("/:id", handler.Get)
+		group.PATCH("/:id", handler.Update)
+		group.DELETE("/:id", handler.Delete)
+		group.POST("/:id/rotate-secret", handler.RotateSecret)
+		group.GET("/:id/deliveries", handler.ListDeliveries)
+		group.POST("/:id/deliveries/:deliveryId/redeliver", handler.Redeliver)
+	}
+}
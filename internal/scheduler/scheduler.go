@@ -0,0 +1,74 @@
+// Package scheduler runs background jobs at fixed intervals. Jobs must be
+// safe to run from several instances at once: each instance runs its own
+// scheduler, so jobs claim their work in the database before doing it.
+package scheduler
+
+import (
+	"context"
+	"log"
+	"runtime/debug"
+	"time"
+)
+
+// Job is one unit of periodic work. An error is logged and the job runs
+// again at its next tick.
+type Job func(ctx context.Context) error
+
+type entry struct {
+	name     string
+	interval time.Duration
+	run      Job
+}
+
+// Scheduler runs registered jobs, each in its own goroutine, once at Start
+// and then every interval. A run never overlaps the previous run of the
+// same job.
+type Scheduler struct {
+	jobs []entry
+}
+
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+// Every registers job to run every interval. Jobs registered after Start
+// are not run.
+func (s *Scheduler) Every(name string, interval time.Duration, job Job) {
+	s.jobs = append(s.jobs, entry{name: name, interval: interval, run: job})
+}
+
+// Start runs every registered job in the background until ctx is done.
+func (s *Scheduler) Start(ctx context.Context) {
+	for _, e := range s.jobs {
+		go e.loop(ctx)
+	}
+}
+
+func (e entry) loop(ctx context.Context) {
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+	for {
+		e.runOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// runOnce runs the job, logging errors and recovering from panics so one
+// bad run does not stop the job (or the server).
+func (e entry) runOnce(ctx context.Context) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("⚠️ Job %s panicked: %v\n%s", e.name, r, debug.Stack())
+		}
+	}()
+
+	started := time.Now()
+	if err := e.run(ctx); err != nil {
+		log.Printf("⚠️ Job %s failed after %s: %v", e.name, time.Since(started).Round(time.Millisecond), err)
+	}
+}
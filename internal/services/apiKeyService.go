@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/repositories"
+	"github.com/FiraBro/local-go/internal/utils"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAPIKeyDays = 90
+	maxAPIKeyDays     = 365
+	maxAPIKeysPerUser = 20
+)
+
+var (
+	ErrAPIKeyNotFound    = errors.New("API key not found")
+	ErrInvalidAPIKeyName = errors.New("name is required (at most 100 characters)")
+	ErrAPIKeyScope       = errors.New("scope is not a permission you have")
+	ErrAPIKeyExpiry      = fmt.Errorf("expires_in_days must be between 1 and %d", maxAPIKeyDays)
+	ErrTooManyAPIKeys    = fmt.Errorf("at most %d active API keys per user", maxAPIKeysPerUser)
+)
+
+type APIKeyService struct {
+	repo     repositories.APIKeyRepository
+	roleRepo repositories.RoleRepository
+	audit    *AuditService
+}
+
+func NewAPIKeyService(repo repositories.APIKeyRepository, roleRepo repositories.RoleRepository, auditService *AuditService) *APIKeyService {
+	return &APIKeyService{repo: repo, roleRepo: roleRepo, audit: auditService}
+}
+
+// Create issues a key for the user and returns it alongside its record; the
+// raw key is never retrievable again. Scopes must be permissions the user's
+// role has now. expiresInDays of 0 means the default (90 days).
+func (s *APIKeyService) Create(ctx context.Context, userID, role, name string, scopes []string, expiresInDays int) (string, *models.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return "", nil, ErrInvalidAPIKeyName
+	}
+
+	if expiresInDays == 0 {
+		expiresInDays = defaultAPIKeyDays
+	}
+	if expiresInDays < 1 || expiresInDays > maxAPIKeyDays {
+		return "", nil, ErrAPIKeyExpiry
+	}
+
+	granted, err := s.roleRepo.GetPermissions(role)
+	if err != nil {
+		return "", nil, err
+	}
+	cleaned := []string{}
+	for _, scope := range scopes {
+		if !slices.Contains(granted, scope) {
+			return "", nil, fmt.Errorf("%w: %s", ErrAPIKeyScope, scope)
+		}
+		if !slices.Contains(cleaned, scope) {
+			cleaned = append(cleaned, scope)
+		}
+	}
+
+	existing, err := s.repo.ListByUser(userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(existing) >= maxAPIKeysPerUser {
+		return "", nil, ErrTooManyAPIKeys
+	}
+
+	raw, prefix, err := utils.GenerateAPIKey(models.APIKeyPrefix)
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
+	key := &models.APIKey{
+		ID:        uuid.New().String(),
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   utils.HashAPIKey(raw),
+		Scopes:    cleaned,
+		ExpiresAt: now.AddDate(0, 0, expiresInDays),
+		CreatedAt: now,
+	}
+	if err := s.repo.Create(key); err != nil {
+		return "", nil, err
+	}
+	s.audit.Record(ctx, "api_key.created", models.AuditTargetAPIKey, key.ID, nil, key)
+	return raw, key, nil
+}
+
+func (s *APIKeyService) List(userID string) ([]models.APIKey, error) {
+	return s.repo.ListByUser(userID)
+}
+
+func (s *APIKeyService) Revoke(ctx context.Context, userID, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrAPIKeyNotFound
+	}
+
+	ok, err := s.repo.Revoke(userID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	s.audit.Record(ctx, "api_key.revoked", models.AuditTargetAPIKey, id, nil, nil)
+	return nil
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/audit"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/repositories"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultAuditPageSize = 50
+	maxAuditPageSize     = 500
+)
+
+// AuditService writes the audit log on behalf of the other services, which
+// call Record after a change has been saved.
+type AuditService struct {
+	repo repositories.AuditRepository
+}
+
+func NewAuditService(repo repositories.AuditRepository) *AuditService {
+	return &AuditService{repo: repo}
+}
+
+// Record appends an event attributed to the actor in ctx. before and after
+// are diffed field by field; pass nil for either on creations and deletions,
+// or for both when there is nothing to show (e.g. a password change). A
+// failure is logged rather than returned: the action itself already happened.
+func (s *AuditService) Record(ctx context.Context, action, targetType, targetID string, before, after any) {
+	event := &models.AuditEvent{
+		ID:         uuid.New().String(),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		CreatedAt:  time.Now(),
+	}
+	if actor, ok := audit.ActorFrom(ctx); ok {
+		event.ActorID = actor.UserID
+		event.ImpersonatorID = actor.ImpersonatorID
+		event.APIKeyID = actor.APIKeyID
+		event.IP = actor.IP
+	}
+
+	changes, err := audit.Diff(before, after)
+	if err == nil && changes != nil {
+		event.Changes, err = json.Marshal(changes)
+	}
+	if err != nil {
+		log.Printf("⚠️ Failed to diff audit event %s: %v", action, err)
+	}
+
+	if err := s.repo.Append(event); err != nil {
+		log.Printf("⚠️ Failed to record audit event %s on %s %s: %v", action, targetType, targetID, err)
+	}
+}
+
+// userActor attributes actions to a user who is not authenticated yet, e.g.
+// while resetting their password or enrolling in MFA at login.
+func userActor(userID string, client models.ClientInfo) context.Context {
+	return audit.WithActor(context.Background(), audit.Actor{UserID: userID, IP: client.IPAddress})
+}
+
+// List returns matching events, newest first, at most 500 at a time.
+func (s *AuditService) List(filter models.AuditFilter) ([]models.AuditEvent, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditPageSize
+	}
+	filter.Limit = min(filter.Limit, maxAuditPageSize)
+	filter.Offset = max(filter.Offset, 0)
+	return s.repo.List(filter)
+}
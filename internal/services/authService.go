@@ -1,59 +1,123 @@
 package services
 
 import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"log"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/FiraBro/local-go/internal/bus"
 	"github.com/FiraBro/local-go/internal/config"
+	"github.com/FiraBro/local-go/internal/domain"
+	"github.com/FiraBro/local-go/internal/mailer"
 	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/oidc"
+	"github.com/FiraBro/local-go/internal/passwords"
 	"github.com/FiraBro/local-go/internal/repositories"
+	"github.com/FiraBro/local-go/internal/tokens"
 	"github.com/FiraBro/local-go/internal/utils"
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
-	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	accessTokenTTL       = 72 * time.Hour
+	refreshTokenTTL      = 7 * 24 * time.Hour
+	emailVerificationTTL = 24 * time.Hour
+	resetOTPTTL          = 5 * time.Minute
+	resetRequestCooldown = time.Minute // between OTP emails to one address
+)
+
+var (
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected, please log in again")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrUserNotFound       = errors.New("user not found")
+
+	ErrEmailNotVerified         = errors.New("email address has not been verified")
+	ErrVerificationTokenInvalid = errors.New("invalid or expired verification link")
+
+	ErrInvalidOTP = errors.New("invalid or expired OTP")
+	ErrOTPBurned  = errors.New("too many wrong codes, request a new OTP")
+
+	ErrWeakPassword     = passwords.ErrWeak
+	ErrBreachedPassword = passwords.ErrBreached
 )
 
 type AuthService struct {
-	userRepo       *repositories.UserRepository
-	refreshRepo    *repositories.RefreshTokenRepository
-	resetTokenRepo *repositories.ResetTokenRepository
+	userRepo       repositories.UserRepository
+	refreshRepo    repositories.RefreshTokenRepository
+	resetTokenRepo repositories.ResetTokenRepository
+	mfaRepo        repositories.MFARepository
+	roleRepo       repositories.RoleRepository
+	attemptRepo    repositories.LoginAttemptRepository
+	identityRepo   repositories.IdentityRepository
+	tx             repositories.Transactor
+	providers      map[string]oidc.Provider
+	audit          *AuditService
+	mail           *MailService
+	events         *bus.Bus
 }
 
 func NewAuthService(
-	userRepo *repositories.UserRepository,
-	refreshRepo *repositories.RefreshTokenRepository,
-	resetTokenRepo *repositories.ResetTokenRepository,
+	userRepo repositories.UserRepository,
+	refreshRepo repositories.RefreshTokenRepository,
+	resetTokenRepo repositories.ResetTokenRepository,
+	mfaRepo repositories.MFARepository,
+	roleRepo repositories.RoleRepository,
+	attemptRepo repositories.LoginAttemptRepository,
+	identityRepo repositories.IdentityRepository,
+	transactor repositories.Transactor,
+	providers []oidc.Provider,
+	auditService *AuditService,
+	mailService *MailService,
+	eventBus *bus.Bus,
 ) *AuthService {
+	byName := make(map[string]oidc.Provider, len(providers))
+	for _, p := range providers {
+		byName[p.Name()] = p
+	}
+
 	return &AuthService{
 		userRepo:       userRepo,
 		refreshRepo:    refreshRepo,
 		resetTokenRepo: resetTokenRepo,
+		mfaRepo:        mfaRepo,
+		roleRepo:       roleRepo,
+		attemptRepo:    attemptRepo,
+		identityRepo:   identityRepo,
+		tx:             transactor,
+		providers:      byName,
+		audit:          auditService,
+		mail:           mailService,
+		events:         eventBus,
 	}
 }
 
-// ----------------------------
-// PASSWORD HELPERS
-// ----------------------------
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 12)
-	return string(bytes), err
-}
-
-func CheckPassword(hash, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+// PasswordPolicy returns the rules new passwords must follow.
+func (s *AuthService) PasswordPolicy() passwords.Policy {
+	return passwords.CurrentPolicy()
 }
 
 // ----------------------------
 // REGISTER
 // ----------------------------
-func (s *AuthService) Register(user *models.User) error {
+func (s *AuthService) Register(ctx context.Context, user *models.User) error {
 	// Validate required fields
 	if user.Username == "" || user.Email == "" || user.Password == "" {
 		return errors.New("username, email, and password are required")
 	}
+	if err := passwords.Validate(user.Password, user.Username, user.Email); err != nil {
+		return err
+	}
 
 	// Check if email already exists
 	exists, err := s.userRepo.ExistsByEmail(user.Email)
@@ -65,93 +129,421 @@ func (s *AuthService) Register(user *models.User) error {
 	}
 
 	// Hash password
-	hashed, err := HashPassword(user.Password)
+	hashed, err := passwords.Hash(user.Password)
 	if err != nil {
 		return err
 	}
 	user.Password = hashed
 
 	// Create user
-	return s.userRepo.CreateUser(user)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.CreateUser(ctx, user); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.UserCreated{User: domain.UserOf(user)})
+	})
+	if err != nil {
+		return err
+	}
+
+	s.sendVerification(user.ID, user.Email)
+	return nil
 }
 
 
 // ----------------------------
 // LOGIN
 // ----------------------------
-func (s *AuthService) Login(email, password string) (string, string, *models.User, error) {
-	email = strings.ToLower(strings.TrimSpace(email))
+// LoginResult is either a full session (AccessToken/RefreshToken) or, when
+// the user has or needs MFA, a short-lived MFAToken to finish logging in with.
+type LoginResult struct {
+	AccessToken  string
+	RefreshToken string
+	User         *models.User
+
+	MFAToken      string
+	MFAEnrollment bool // the user's role requires MFA but none is set up yet
+
+	RecoveryCodes []string // only when MFA was enrolled as part of this login
+
+	// The account is scheduled for deletion: instead of a session, a token
+	// to restore it (see RestoreAccount)
+	RestoreToken   string
+	DeleteDeadline *time.Time
+}
+
+func (s *AuthService) Login(email, password string, client models.ClientInfo) (*LoginResult, error) {
+	email = normalizeEmail(email)
+
+	keys := throttleKeys(email, client)
+	if err := s.checkThrottle(keys); err != nil {
+		return nil, err
+	}
 
 	user, err := s.userRepo.GetByEmail(email)
 	if err != nil {
-		return "", "", nil, errors.New("invalid email or password")
+		if deleted, err := s.userRepo.GetDeletedByEmail(email); err == nil {
+			return s.pendingDeletionLogin(deleted, password, keys, client)
+		}
+		s.recordFailure(keys)
+		s.auditLoginFailure(email, "", "unknown_email", client)
+		return nil, errors.New("invalid email or password")
 	}
 
-	if err := CheckPassword(user.Password, password); err != nil {
-		return "", "", nil, errors.New("invalid email or password")
+	needsRehash, err := passwords.Verify(user.Password, password)
+	if err != nil {
+		s.recordFailure(keys)
+		s.auditLoginFailure(email, user.ID, "wrong_password", client)
+		return nil, errors.New("invalid email or password")
+	}
+	if needsRehash {
+		s.rehashPassword(user, password)
 	}
 
-	// Create access token
-	accessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"role":    user.Role,
-		"exp":     time.Now().Add(72 * time.Hour).Unix(),
-	}).SignedString([]byte(config.JWTSecret))
+	return s.finishLogin(user, client)
+}
+
+// auditLoginFailure records a refused sign-in against the account, or
+// against the address when no account has it. The actor is anonymous: the
+// caller has not proven who they are.
+func (s *AuthService) auditLoginFailure(email, userID, reason string, client models.ClientInfo) {
+	targetType, targetID := models.AuditTargetUser, userID
+	if userID == "" {
+		targetType, targetID = models.AuditTargetLoginAttempts, models.ThrottleScopeAccount+":"+normalizeEmail(email)
+	}
+	s.audit.Record(userActor("", client), "user.login_failed", targetType, targetID,
+		nil, map[string]any{"email": normalizeEmail(email), "reason": reason})
+}
+
+// rehashPassword upgrades a stored hash made with an outdated algorithm or
+// cost, now that the plaintext is at hand. Failing only means trying again at
+// the next login.
+func (s *AuthService) rehashPassword(user *models.User, password string) {
+	hashed, err := passwords.Hash(password)
+	if err == nil {
+		err = s.userRepo.UpdatePassword(user.ID, hashed)
+	}
+	if err != nil {
+		log.Println("⚠️ Failed to upgrade password hash:", err)
+		return
+	}
+	user.Password = hashed
+}
+
+// finishLogin runs once the user has proven who they are, with a password or
+// an external identity: it applies the verification policy and MFA, then
+// starts the session.
+func (s *AuthService) finishLogin(user *models.User, client models.ClientInfo) (*LoginResult, error) {
+	if config.EmailVerificationPolicy == config.VerifyPolicyLogin && user.VerifiedAt == nil {
+		return nil, ErrEmailNotVerified
+	}
+
+	// A password or external login alone is not enough for users with (or
+	// required to have) MFA
+	step, err := s.mfaStepFor(user)
+	if err != nil {
+		return nil, err
+	}
+	if step != "" {
+		token, err := generateMFAToken(user.ID, step)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{User: user, MFAToken: token, MFAEnrollment: step == mfaStepEnroll}, nil
+	}
+
+	accessToken, refreshToken, err := s.startSession(user, client)
 	if err != nil {
-		return "", "", nil, err
+		return nil, err
 	}
+	s.clearAccountFailures(user.Email)
+	return &LoginResult{AccessToken: accessToken, RefreshToken: refreshToken, User: user}, nil
+}
 
-	// Create refresh token
-	refreshToken := uuid.New().String()
-	rt := &models.RefreshToken{
-		Token:     refreshToken,
-		UserID:    user.ID,
-		ExpiresAt: time.Now().Add(7 * 24 * time.Hour),
+// startSession issues the access/refresh pair for a fully authenticated user.
+func (s *AuthService) startSession(user *models.User, client models.ClientInfo) (string, string, error) {
+	// Each login starts a new refresh token family, i.e. a new session
+	familyID := uuid.New().String()
+	refreshToken, rt, err := newRefreshToken(user.ID, familyID, client)
+	if err != nil {
+		return "", "", err
+	}
+
+	accessToken, err := generateAccessToken(user, familyID)
+	if err != nil {
+		return "", "", err
 	}
 
 	if err := s.refreshRepo.Save(rt); err != nil {
-		return "", "", nil, err
+		return "", "", err
 	}
+	s.audit.Record(userActor(user.ID, client), "user.logged_in", models.AuditTargetUser, user.ID,
+		nil, map[string]any{"session_id": familyID, "user_agent": client.UserAgent})
 
-	return accessToken, refreshToken, user, nil
+	return accessToken, refreshToken, nil
 }
 
 // ----------------------------
-// REFRESH TOKEN
+// REFRESH TOKEN (rotation + reuse detection)
 // ----------------------------
-func (s *AuthService) RefreshToken(token string) (string, error) {
-	rt, err := s.refreshRepo.Get(token)
+// Every refresh swaps the presented token for a new one in the same family.
+// Presenting a token that was already rotated means it leaked (or was
+// replayed), so the whole family is revoked and the user must log in again.
+func (s *AuthService) RefreshToken(token string, client models.ClientInfo) (string, string, error) {
+	rt, err := s.refreshRepo.Get(hashToken(token))
 	if err != nil {
-		return "", errors.New("invalid refresh token")
+		return "", "", errors.New("invalid refresh token")
+	}
+
+	if rt.RevokedAt != nil {
+		s.revokeFamily(rt, client)
+		return "", "", ErrRefreshTokenReused
 	}
 
 	if rt.ExpiresAt.Before(time.Now()) {
-		_ = s.refreshRepo.Delete(token)
-		return "", errors.New("refresh token expired")
+		_ = s.refreshRepo.Delete(rt.TokenHash)
+		return "", "", errors.New("refresh token expired")
 	}
 
-	newAccessToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": rt.UserID,
-		"exp":     time.Now().Add(72 * time.Hour).Unix(),
-	}).SignedString([]byte(config.JWTSecret))
+	user, err := s.userRepo.GetActiveByID(rt.UserID)
 	if err != nil {
-		return "", err
+		if err := s.refreshRepo.RevokeFamily(rt.FamilyID); err != nil {
+			log.Println("⚠️ Failed to revoke refresh token family:", err)
+		}
+		return "", "", errors.New("invalid refresh token")
+	}
+
+	newToken, next, err := newRefreshToken(user.ID, rt.FamilyID, client)
+	if err != nil {
+		return "", "", err
+	}
+	next.CreatedAt = rt.CreatedAt
+
+	if err := s.refreshRepo.Rotate(rt.TokenHash, next); err != nil {
+		if errors.Is(err, repositories.ErrRefreshTokenRotated) {
+			// Lost a race with another refresh using the same token
+			s.revokeFamily(rt, client)
+			return "", "", ErrRefreshTokenReused
+		}
+		return "", "", err
 	}
 
-	return newAccessToken, nil
+	accessToken, err := generateAccessToken(user, rt.FamilyID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, newToken, nil
+}
+
+// revokeFamily ends the session of a refresh token that was presented again
+// after being rotated.
+func (s *AuthService) revokeFamily(rt *models.RefreshToken, client models.ClientInfo) {
+	log.Printf("⚠️ Refresh token reuse detected for user %s, revoking family %s", rt.UserID, rt.FamilyID)
+	if err := s.refreshRepo.RevokeFamily(rt.FamilyID); err != nil {
+		log.Println("⚠️ Failed to revoke refresh token family:", err)
+		return
+	}
+	s.audit.Record(userActor("", client), "user.refresh_token_reused", models.AuditTargetUser, rt.UserID,
+		nil, map[string]any{"session_id": rt.FamilyID})
+}
+
+// ----------------------------
+// TOKEN HELPERS
+// ----------------------------
+
+// generateAccessToken signs the access token handed out by Login and Refresh.
+// sid names the session (refresh token family) the token was issued for.
+func generateAccessToken(user *models.User, sessionID string) (string, error) {
+	return tokens.Sign(jwt.MapClaims{
+		"user_id": user.ID,
+		"role":    user.Role,
+		"sid":     sessionID,
+	}, config.JWTAudience, accessTokenTTL)
+}
+
+// userSnapshot is what the audit log shows of a user; never the password.
+func userSnapshot(u *models.User) map[string]any {
+	return map[string]any{"username": u.Username, "email": u.Email, "role": u.Role}
+}
+
+// newRefreshToken returns a random token for the client and the record to
+// store for it.
+func newRefreshToken(userID, familyID string, client models.ClientInfo) (string, *models.RefreshToken, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	return token, &models.RefreshToken{
+		TokenHash: hashToken(token),
+		UserID:    userID,
+		FamilyID:  familyID,
+		UserAgent: client.UserAgent,
+		IPAddress: client.IPAddress,
+		ExpiresAt: time.Now().Add(refreshTokenTTL),
+	}, nil
+}
+
+// purposeAudience scopes tokens that are not access tokens (MFA challenges,
+// email links) to their own audience, so they can never pass as one another.
+func purposeAudience(purpose string) string {
+	return config.JWTAudience + ":" + purpose
+}
+
+// hashToken is how refresh tokens are looked up; the raw value is never stored.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
 
 // ----------------------------
 // LOGOUT
 // ----------------------------
-func (s *AuthService) Logout(token string) error {
-	return s.refreshRepo.Delete(token)
+// Logout ends the session the refresh token belongs to, including any
+// tokens rotated from it.
+func (s *AuthService) Logout(token string, client models.ClientInfo) error {
+	rt, err := s.refreshRepo.Get(hashToken(token))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+	if err := s.refreshRepo.RevokeFamily(rt.FamilyID); err != nil {
+		return err
+	}
+	s.audit.Record(userActor(rt.UserID, client), "user.logged_out", models.AuditTargetUser, rt.UserID,
+		nil, map[string]any{"session_id": rt.FamilyID})
+	return nil
+}
+
+// ----------------------------
+// SESSIONS
+// ----------------------------
+
+// ListSessions returns the user's active sessions, flagging currentID.
+func (s *AuthService) ListSessions(userID, currentID string) ([]models.Session, error) {
+	sessions, err := s.refreshRepo.ListSessions(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, nil
+}
+
+// RevokeSession logs the user out of one session.
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return ErrSessionNotFound
+	}
+
+	revoked, err := s.refreshRepo.RevokeSession(userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+	s.audit.Record(ctx, "user.session_revoked", models.AuditTargetUser, userID,
+		nil, map[string]any{"session_id": sessionID})
+	return nil
+}
+
+// LogoutAll revokes every session of the user, including the current one.
+func (s *AuthService) LogoutAll(ctx context.Context, userID string) error {
+	if err := s.refreshRepo.RevokeAllForUser(userID, ""); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, "user.logged_out_everywhere", models.AuditTargetUser, userID, nil, nil)
+	return nil
+}
+
+// ----------------------------
+// EMAIL VERIFICATION
+// ----------------------------
+
+// VerifyEmail marks the address in a verification link as verified. Links are
+// bound to the address they were sent to, so changing the email voids them.
+func (s *AuthService) VerifyEmail(token string) error {
+	claims := jwt.MapClaims{}
+	if err := tokens.Parse(token, claims, purposeAudience("verify-email")); err != nil {
+		return ErrVerificationTokenInvalid
+	}
+
+	userID, _ := claims["user_id"].(string)
+	email, _ := claims["email"].(string)
+	if userID == "" || email == "" {
+		return ErrVerificationTokenInvalid
+	}
+
+	user, err := s.userRepo.GetActiveByID(userID)
+	if err != nil || !strings.EqualFold(user.Email, email) {
+		return ErrVerificationTokenInvalid
+	}
+	if user.VerifiedAt != nil {
+		return nil
+	}
+
+	if _, err := s.userRepo.MarkEmailVerified(userID, email); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ResendVerification sends a new link. Like ForgotPassword it never reveals
+// whether the address has an account.
+func (s *AuthService) ResendVerification(email string) {
+	user, err := s.userRepo.GetByEmail(email)
+	if err != nil || user.VerifiedAt != nil {
+		return
+	}
+	s.sendVerification(user.ID, user.Email)
+}
+
+// sendVerification queues an email with a signed link; failing to queue it
+// must not fail registration, the user can ask for another.
+func (s *AuthService) sendVerification(userID, email string) {
+	token, err := tokens.Sign(jwt.MapClaims{
+		"user_id": userID,
+		"email":   strings.ToLower(email),
+	}, purposeAudience("verify-email"), emailVerificationTTL)
+	if err != nil {
+		log.Println("⚠️ Failed to sign verification token:", err)
+		return
+	}
+
+	link := config.AppBaseURL + "/api/v1/auth/verify-email?token=" + url.QueryEscape(token)
+	err = s.mail.Send(mailer.TemplateVerifyEmail, email, map[string]any{
+		"Link":      link,
+		"ExpiresIn": "24 hours",
+	})
+	if err != nil {
+		log.Println("⚠️ Failed to queue verification email:", err)
+	}
 }
 
 // ----------------------------
 // FORGOT PASSWORD (hashed OTP)
 // ----------------------------
-func (s *AuthService) ForgotPassword(email string) error {
+func (s *AuthService) ForgotPassword(email string, client models.ClientInfo) error {
+	email = normalizeEmail(email)
+	if err := s.checkThrottle(throttleKeys(email, client)); err != nil {
+		return err
+	}
+
+	// Don't let the endpoint be used to flood an inbox
+	existing, err := s.resetTokenRepo.Get(email)
+	if err != nil {
+		return err
+	}
+	if existing != nil && time.Until(existing.ExpiresAt) > resetOTPTTL-resetRequestCooldown {
+		return nil
+	}
+
 	otp := utils.GenerateOTP()
 
 	// Delete old OTPs
@@ -160,7 +552,7 @@ func (s *AuthService) ForgotPassword(email string) error {
 	}
 
 	// Hash OTP before saving
-	hashedOtp, err := HashPassword(otp)
+	hashedOtp, err := passwords.Hash(otp)
 	if err != nil {
 		return err
 	}
@@ -168,32 +560,50 @@ func (s *AuthService) ForgotPassword(email string) error {
 	rt := &models.ResetToken{
 		Email:     email,
 		OTP:       hashedOtp,
-		ExpiresAt: time.Now().Add(5 * time.Minute),
+		ExpiresAt: time.Now().Add(resetOTPTTL),
 	}
 
 	if err := s.resetTokenRepo.Save(rt); err != nil {
 		return err
 	}
 
-	// Send email (can be async)
-	if err := utils.SendOTPEmail(email, otp); err != nil {
-		return err
-	}
-
-	return nil
+	return s.mail.Send(mailer.TemplatePasswordReset, email, map[string]any{
+		"OTP":       otp,
+		"ExpiresIn": "5 minutes",
+	})
 }
 
 // ----------------------------
 // RESET PASSWORD
 // ----------------------------
-func (s *AuthService) ResetPassword(email, otp, newPassword string) error {
-	rt, err := s.resetTokenRepo.Get(email, otp)
+// Every wrong OTP counts as a failed sign-in, and the OTP is burned after
+// config.ResetOTPMaxAttempts of them, so it cannot be brute-forced.
+func (s *AuthService) ResetPassword(email, otp, newPassword string, client models.ClientInfo) error {
+	email = normalizeEmail(email)
+	keys := throttleKeys(email, client)
+	if err := s.checkThrottle(keys); err != nil {
+		return err
+	}
+
+	rt, err := s.resetTokenRepo.Get(email)
 	if err != nil {
-		return errors.New("invalid or expired OTP")
+		return err
+	}
+	if rt == nil || rt.ExpiresAt.Before(time.Now()) {
+		return ErrInvalidOTP
 	}
 
-	if rt.ExpiresAt.Before(time.Now()) {
-		return errors.New("invalid or expired OTP")
+	if err := passwords.Check(rt.OTP, otp); err != nil {
+		s.recordFailure(keys)
+		attempts, err := s.resetTokenRepo.AddAttempt(email)
+		if err != nil {
+			return err
+		}
+		if attempts >= config.ResetOTPMaxAttempts {
+			_ = s.resetTokenRepo.Delete(email)
+			return ErrOTPBurned
+		}
+		return ErrInvalidOTP
 	}
 
 	user, err := s.userRepo.GetByEmail(email)
@@ -201,7 +611,12 @@ func (s *AuthService) ResetPassword(email, otp, newPassword string) error {
 		return errors.New("user not found")
 	}
 
-	hashed, err := HashPassword(newPassword)
+	// The code stays valid, so the user can try another password
+	if err := passwords.Validate(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
+
+	hashed, err := passwords.Hash(newPassword)
 	if err != nil {
 		return err
 	}
@@ -209,9 +624,16 @@ func (s *AuthService) ResetPassword(email, otp, newPassword string) error {
 	if err := s.userRepo.UpdatePassword(user.ID, hashed); err != nil {
 		return err
 	}
+	s.audit.Record(userActor(user.ID, client), "user.password_reset", models.AuditTargetUser, user.ID, nil, nil)
 
 	// Delete OTP after success
 	_ = s.resetTokenRepo.Delete(email)
+	s.clearAccountFailures(email)
+
+	// Whoever knew the old password should not stay logged in
+	if err := s.refreshRepo.RevokeAllForUser(user.ID, ""); err != nil {
+		log.Println("⚠️ Failed to revoke sessions after password reset:", err)
+	}
 
 	return nil
 }
@@ -226,55 +648,133 @@ func (s *AuthService) FetchUser(id string) (*models.User, error) {
 // ----------------------------
 // UPDATE PROFILE
 // ----------------------------
-func (s *AuthService) UpdateProfile(id, username, email string) error {
+func (s *AuthService) UpdateProfile(ctx context.Context, id, username, email string) error {
+	current, err := s.userRepo.GetUserByID(id)
+	if err != nil {
+		return err
+	}
+
+	// Fields left out stay as they are
 	user := &models.User{
-		Username: username,
-		Email:    email,
+		Username: strings.TrimSpace(username),
+		Email:    normalizeEmail(email),
+	}
+	if user.Username == "" {
+		user.Username = current.Username
+	}
+	if user.Email == "" {
+		user.Email = current.Email
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.UpdateUser(ctx, id, user); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.UserUpdated{User: domain.User{
+			ID: id, Username: user.Username, Email: user.Email, Role: current.Role,
+		}})
+	})
+	if err != nil {
+		return err
 	}
-	return s.userRepo.UpdateUser(id, user)
+	s.audit.Record(ctx, "user.profile_updated", models.AuditTargetUser, id,
+		userSnapshot(current), map[string]any{"username": user.Username, "email": user.Email, "role": current.Role})
+
+	// A new address has to be verified again
+	if !strings.EqualFold(current.Email, user.Email) {
+		s.sendVerification(id, user.Email)
+	}
+	return nil
 }
 
 // ----------------------------
 // CHANGE PASSWORD
 // ----------------------------
-func (s *AuthService) ChangePassword(id, oldPassword, newPassword string) error {
+// ChangePassword keeps currentSession logged in and revokes every other one.
+func (s *AuthService) ChangePassword(ctx context.Context, id, oldPassword, newPassword, currentSession string) error {
 	user, err := s.userRepo.GetUserByID(id)
 	if err != nil {
 		return err
 	}
 
-	if err := CheckPassword(user.Password, oldPassword); err != nil {
+	if err := passwords.Check(user.Password, oldPassword); err != nil {
 		return errors.New("old password is incorrect")
 	}
+	if err := passwords.Validate(newPassword, user.Username, user.Email); err != nil {
+		return err
+	}
 
-	hashed, err := HashPassword(newPassword)
+	hashed, err := passwords.Hash(newPassword)
 	if err != nil {
 		return err
 	}
 
-	return s.userRepo.UpdatePassword(id, hashed)
+	if err := s.userRepo.UpdatePassword(id, hashed); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, "user.password_changed", models.AuditTargetUser, id, nil, nil)
+
+	return s.refreshRepo.RevokeAllForUser(id, currentSession)
 }
 
 // ----------------------------
 // SOFT DELETE USER
 // ----------------------------
-func (s *AuthService) SoftDeleteUser(id string) error {
-	return s.userRepo.SoftDeleteUser(id)
+// SoftDeleteUser schedules the account for deletion after
+// ACCOUNT_DELETION_GRACE, logs it out everywhere and emails a restore link.
+func (s *AuthService) SoftDeleteUser(ctx context.Context, id string) (time.Time, error) {
+	user, err := s.userRepo.GetActiveByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return time.Time{}, ErrUserNotFound
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	deadline := time.Now().Add(config.AccountDeletionGrace)
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		deleted, err := s.userRepo.SoftDeleteUser(ctx, id, deadline)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return ErrUserNotFound
+		}
+		return s.events.Publish(ctx, domain.UserDeleted{ID: id, DeleteDeadline: deadline})
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	s.audit.Record(ctx, "user.deleted", models.AuditTargetUser, id, userSnapshot(user), nil)
+
+	if err := s.refreshRepo.RevokeAllForUser(id, ""); err != nil {
+		log.Println("⚠️ Failed to revoke sessions of deleted user:", err)
+	}
+	s.sendDeletionEmail(id)
+	return deadline, nil
 }
 
 // ----------------------------
 // RESTORE USER
 // ----------------------------
-func (s *AuthService) RestoreUser(id string) error {
-	isDeleted, err := s.userRepo.IsUserDeleted(id)
+// RestoreUser restores a deleted account on an admin's behalf; owners use
+// RestoreAccount.
+func (s *AuthService) RestoreUser(ctx context.Context, id string) error {
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		restored, err := s.userRepo.RestoreUser(ctx, id)
+		if err != nil {
+			return err
+		}
+		if !restored {
+			return ErrUserNotDeleted
+		}
+		return s.events.Publish(ctx, domain.UserRestored{ID: id})
+	})
 	if err != nil {
 		return err
 	}
-	if !isDeleted {
-		return errors.New("user is not deleted")
-	}
-
-	return s.userRepo.RestoreUser(id)
+	s.audit.Record(ctx, "user.restored", models.AuditTargetUser, id, nil, nil)
+	return nil
 }
 
 // ----------------------------
@@ -284,14 +784,6 @@ func (s *AuthService) FetchAllUsers() ([]models.User, error) {
 	return s.userRepo.FetchAllUsers()
 }
 
-// ----------------------------
-// PURGE EXPIRED DELETED USERS
-// ----------------------------
-func (s *AuthService) PurgeExpiredDeletedUsers() {
-	if err := s.userRepo.PermanentlyDeleteExpired(); err != nil {
-		log.Println("⚠️ Failed to purge expired users:", err)
-	}
-}
 
 
 
@@ -317,23 +809,59 @@ func (s *AuthService) FetchUsersPaginated(pageStr, limitStr string) ([]models.Us
 }
 
 // Update user role
-func (s *AuthService) UpdateUserRole(id, role string) error {
+func (s *AuthService) UpdateUserRole(ctx context.Context, id, role string) error {
 	if id == "" || role == "" {
 		return errors.New("id and role cannot be empty")
 	}
+	if err := s.checkRole(role); err != nil {
+		return err
+	}
 
-	return s.userRepo.UpdateUserRole(id, role)
-}
-
+	user, err := s.userRepo.GetActiveByID(id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrUserNotFound
+	}
+	if err != nil {
+		return err
+	}
 
+	previous := user.Role
+	user.Role = role
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.UpdateUserRole(ctx, id, role); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.UserUpdated{User: domain.UserOf(user)})
+	})
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, "user.role_changed", models.AuditTargetUser, id,
+		map[string]any{"role": previous}, map[string]any{"role": role})
+	return nil
+}
 
+// checkRole rejects role names that are not configured roles.
+func (s *AuthService) checkRole(role string) error {
+	r, err := s.roleRepo.GetRole(role)
+	if err != nil {
+		return err
+	}
+	if r == nil {
+		return fmt.Errorf("%w: %s", ErrRoleNotFound, role)
+	}
+	return nil
+}
 
 // CreateUser handles hashing password and validating
-func (s *AuthService) CreateUser(user *models.User) error {
+func (s *AuthService) CreateUser(ctx context.Context, user *models.User) error {
 	user.Email = strings.TrimSpace(strings.ToLower(user.Email))
 	if user.Email == "" || user.Password == "" || user.Username == "" {
 		return errors.New("username, email, and password are required")
 	}
+	if err := passwords.Validate(user.Password, user.Username, user.Email); err != nil {
+		return err
+	}
 
 	// Check if user already exists
 	exists, err := s.userRepo.ExistsByEmail(user.Email)
@@ -345,22 +873,38 @@ func (s *AuthService) CreateUser(user *models.User) error {
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
+	hashedPassword, err := passwords.Hash(user.Password)
 	if err != nil {
 		return err
 	}
-	user.Password = string(hashedPassword)
+	user.Password = hashedPassword
 
 	// Default role
 	if user.Role == "" {
-		user.Role = "user"
+		user.Role = models.DefaultRole
+	}
+	if err := s.checkRole(user.Role); err != nil {
+		return err
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.CreateUser(ctx, user); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.UserCreated{User: domain.UserOf(user)})
+	})
+	if err != nil {
+		return err
 	}
+	s.audit.Record(ctx, "user.created", models.AuditTargetUser, user.ID, nil, userSnapshot(user))
 
-	return s.userRepo.CreateUser(user)
+	s.sendVerification(user.ID, user.Email)
+	return nil
 }
 
-// UpdateUser handles updating user info (username, email, role)
-func (s *AuthService) UpdateUser(user *models.User) error {
+// UpdateUser handles updating user info (username, email, role). An empty
+// email keeps the current one.
+func (s *AuthService) UpdateUser(ctx context.Context, user *models.User) error {
 	user.Email = strings.TrimSpace(strings.ToLower(user.Email))
 	if user.Username == "" {
 		return errors.New("username cannot be empty")
@@ -370,5 +914,30 @@ func (s *AuthService) UpdateUser(user *models.User) error {
 		return errors.New("user ID is required")
 	}
 
-	return s.userRepo.UpdateUser(user.ID, user)
+	current, err := s.userRepo.GetUserByID(user.ID)
+	if err != nil {
+		return err
+	}
+	if user.Email == "" {
+		user.Email = current.Email
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.UpdateUser(ctx, user.ID, user); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.UserUpdated{User: domain.User{
+			ID: user.ID, Username: user.Username, Email: user.Email, Role: current.Role,
+		}})
+	})
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, "user.updated", models.AuditTargetUser, user.ID,
+		userSnapshot(current), map[string]any{"username": user.Username, "email": user.Email, "role": current.Role})
+
+	if !strings.EqualFold(current.Email, user.Email) {
+		s.sendVerification(user.ID, user.Email)
+	}
+	return nil
 }
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/bus"
+	"github.com/FiraBro/local-go/internal/config"
+	"github.com/FiraBro/local-go/internal/db"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/repositories"
+	"github.com/FiraBro/local-go/internal/tokens"
+)
+
+// newTestAuthService runs AuthService against a fresh SQLite database with
+// every migration applied and signing keys in a temporary directory.
+func newTestAuthService(t *testing.T) (*AuthService, *sql.DB) {
+	t.Helper()
+	dir := t.TempDir()
+	driver, addr, keyDir := config.DBDriver, config.DBAddr, config.JWTKeyDir
+	t.Cleanup(func() { config.DBDriver, config.DBAddr, config.JWTKeyDir = driver, addr, keyDir })
+	config.DBDriver = config.DriverSQLite
+	config.DBAddr = "file:" + filepath.Join(dir, "test.db") + "?_foreign_keys=on&_busy_timeout=5000"
+	config.JWTKeyDir = filepath.Join(dir, "keys")
+
+	if err := db.MigrateUp(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if err := tokens.Init(); err != nil {
+		t.Fatalf("signing keys: %v", err)
+	}
+	conn := db.InitDB()
+	t.Cleanup(func() { conn.Close() })
+
+	s := NewAuthService(
+		repositories.NewUserRepository(conn),
+		repositories.NewRefreshTokenRepository(conn),
+		repositories.NewResetTokenRepository(conn),
+		repositories.NewMFARepository(conn),
+		repositories.NewRoleRepository(conn),
+		repositories.NewLoginAttemptRepository(conn),
+		repositories.NewIdentityRepository(conn),
+		repositories.NewTransactor(conn),
+		nil,
+		NewAuditService(repositories.NewAuditRepository(conn)),
+		nil,
+		bus.New(bus.Options{AfterCommit: repositories.AfterCommit}),
+	)
+	return s, conn
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	client := models.ClientInfo{IPAddress: "192.0.2.1", UserAgent: "test"}
+
+	tests := []struct {
+		name string
+		// present returns the token to refresh with, given the session's
+		// first refresh token
+		present      func(t *testing.T, s *AuthService, conn *sql.DB, user *models.User, first string) string
+		wantErr      error // nil for a successful rotation
+		wantRevoked  bool  // the session is over afterwards
+		everySession bool  // ...and so are the user's other sessions
+	}{
+		{
+			name:    "current token rotates",
+			present: func(t *testing.T, s *AuthService, conn *sql.DB, user *models.User, first string) string { return first },
+		},
+		{
+			name: "rotated token rotates again",
+			present: func(t *testing.T, s *AuthService, conn *sql.DB, user *models.User, first string) string {
+				return mustRefresh(t, s, first, client)
+			},
+		},
+		{
+			name: "reused token revokes the session",
+			present: func(t *testing.T, s *AuthService, conn *sql.DB, user *models.User, first string) string {
+				mustRefresh(t, s, first, client)
+				return first
+			},
+			wantErr:     ErrRefreshTokenReused,
+			wantRevoked: true,
+		},
+		{
+			name: "unknown token",
+			present: func(t *testing.T, s *AuthService, conn *sql.DB, user *models.User, first string) string {
+				return "not-a-token"
+			},
+			wantErr: errInvalidRefreshToken,
+		},
+		{
+			name: "expired token",
+			present: func(t *testing.T, s *AuthService, conn *sql.DB, user *models.User, first string) string {
+				if _, err := conn.Exec(`UPDATE refresh_tokens SET expires_at = $1 WHERE token_hash = $2`,
+					time.Now().Add(-time.Minute), hashToken(first)); err != nil {
+					t.Fatal(err)
+				}
+				return first
+			},
+			wantErr:     errInvalidRefreshToken,
+			wantRevoked: true,
+		},
+		{
+			name: "deleted user",
+			present: func(t *testing.T, s *AuthService, conn *sql.DB, user *models.User, first string) string {
+				if _, err := s.userRepo.SoftDeleteUser(context.Background(), user.ID, time.Now().Add(time.Hour)); err != nil {
+					t.Fatal(err)
+				}
+				return first
+			},
+			wantErr:      errInvalidRefreshToken,
+			wantRevoked:  true,
+			everySession: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, conn := newTestAuthService(t)
+			user := &models.User{Username: "ada", Email: "ada@example.com", Password: "x", Role: "customer"}
+			if err := s.userRepo.CreateUser(context.Background(), user); err != nil {
+				t.Fatal(err)
+			}
+			_, first, err := s.startSession(user, client)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// A second session, which nothing below may end
+			_, other, err := s.startSession(user, client)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			presented := tt.present(t, s, conn, user, first)
+			access, next, err := s.RefreshToken(presented, client)
+			switch {
+			case tt.wantErr == errInvalidRefreshToken:
+				if err == nil || errors.Is(err, ErrRefreshTokenReused) {
+					t.Fatalf("RefreshToken error = %v, want an invalid token error", err)
+				}
+			case !errors.Is(err, tt.wantErr):
+				t.Fatalf("RefreshToken error = %v, want %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr == nil {
+				if access == "" || next == "" || next == presented {
+					t.Fatalf("RefreshToken = %q, %q; want a new pair", access, next)
+				}
+				if _, _, err := s.RefreshToken(next, client); err != nil {
+					t.Errorf("refreshing with the new token: %v", err)
+				}
+			}
+
+			if tt.wantRevoked {
+				if _, _, err := s.RefreshToken(first, client); err == nil {
+					t.Error("the session still refreshes after being revoked")
+				}
+			}
+			if !tt.everySession {
+				if _, _, err := s.RefreshToken(other, client); err != nil {
+					t.Errorf("the other session no longer refreshes: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// errInvalidRefreshToken stands for the unexported errors RefreshToken
+// returns for tokens that are unknown, expired or belong to a deleted user.
+var errInvalidRefreshToken = errors.New("invalid refresh token")
+
+func mustRefresh(t *testing.T, s *AuthService, token string, client models.ClientInfo) string {
+	t.Helper()
+	_, next, err := s.RefreshToken(token, client)
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	return next
+}
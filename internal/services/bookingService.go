@@ -0,0 +1,178 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/bus"
+	"github.com/FiraBro/local-go/internal/domain"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/repositories"
+)
+
+var (
+	ErrBookingNotFound  = errors.New("booking not found")
+	ErrStaffNotFound    = errors.New("staff member not found")
+	ErrSlotUnavailable  = errors.New("requested slot is not available")
+	ErrBookingForbidden = errors.New("not allowed to access this booking")
+)
+
+type BookingService struct {
+	bookingRepo  repositories.BookingRepository
+	staffRepo    repositories.StaffRepository
+	serviceRepo  repositories.ServiceRepository
+	availability *AvailabilityService
+	tx           repositories.Transactor
+	events       *bus.Bus
+}
+
+func NewBookingService(
+	bookingRepo repositories.BookingRepository,
+	staffRepo repositories.StaffRepository,
+	serviceRepo repositories.ServiceRepository,
+	availability *AvailabilityService,
+	transactor repositories.Transactor,
+	eventBus *bus.Bus,
+) *BookingService {
+	return &BookingService{
+		bookingRepo:  bookingRepo,
+		staffRepo:    staffRepo,
+		serviceRepo:  serviceRepo,
+		availability: availability,
+		tx:           transactor,
+		events:       eventBus,
+	}
+}
+
+// Create reserves a slot for userID. The availability check gives a friendly
+// error for the common case; the overlap constraint in the database is what
+// actually stops two concurrent requests from taking the same time.
+func (s *BookingService) Create(ctx context.Context, userID string, req *models.CreateBookingRequest) (*models.Booking, error) {
+	if _, err := time.Parse("2006-01-02", req.Date); err != nil {
+		return nil, fmt.Errorf("%w: date must be YYYY-MM-DD", ErrInvalidInput)
+	}
+	if _, err := time.Parse("15:04", req.StartTime); err != nil {
+		return nil, fmt.Errorf("%w: start_time must be HH:MM", ErrInvalidInput)
+	}
+	if req.Date < time.Now().Format("2006-01-02") {
+		return nil, fmt.Errorf("%w: cannot book a date in the past", ErrInvalidInput)
+	}
+
+	service, err := s.serviceRepo.GetByIDs(ctx, req.ServiceID)
+	if err != nil {
+		return nil, err
+	}
+	if service == nil {
+		return nil, ErrServiceNotFound
+	}
+
+	staff, err := s.staffRepo.GetByID(ctx, req.StaffID)
+	if err != nil {
+		return nil, err
+	}
+	if staff == nil {
+		return nil, ErrStaffNotFound
+	}
+
+	serviceIDs, err := s.staffRepo.GetServiceIDs(ctx, staff.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !contains(serviceIDs, service.ID) {
+		return nil, fmt.Errorf("%w: staff member does not offer this service", ErrInvalidInput)
+	}
+
+	slots, err := s.availability.GetStaffSlots(ctx, staff.ID, req.Date, service)
+	if err != nil {
+		return nil, err
+	}
+	if !contains(slots, req.StartTime) {
+		return nil, ErrSlotUnavailable
+	}
+
+	endTime, block, err := BookingInterval(service, req.StartTime)
+	if err != nil {
+		return nil, fmt.Errorf("%w: start_time must be HH:MM", ErrInvalidInput)
+	}
+
+	booking := &models.Booking{
+		UserID:           userID,
+		ServiceID:        service.ID,
+		StaffID:          staff.ID,
+		Date:             req.Date,
+		StartTime:        req.StartTime,
+		EndTime:          endTime,
+		BlockStartMinute: block.StartMinute,
+		BlockEndMinute:   block.EndMinute,
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.bookingRepo.Create(ctx, booking); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.BookingCreated{Booking: *booking})
+	})
+	if errors.Is(err, repositories.ErrSlotTaken) {
+		return nil, ErrSlotUnavailable
+	}
+	if err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+// GetByID returns the booking if userID owns it or canManage is set.
+func (s *BookingService) GetByID(ctx context.Context, id, userID string, canManage bool) (*models.Booking, error) {
+	booking, err := s.bookingRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if booking == nil {
+		return nil, ErrBookingNotFound
+	}
+	if !canManage && booking.UserID != userID {
+		return nil, ErrBookingForbidden
+	}
+	return booking, nil
+}
+
+func (s *BookingService) ListForUser(ctx context.Context, userID string) ([]models.Booking, error) {
+	return s.bookingRepo.GetByUser(ctx, userID)
+}
+
+// Cancel frees the slot again; only the booking owner or a booking manager
+// may cancel.
+func (s *BookingService) Cancel(ctx context.Context, id, userID string, canManage bool) (*models.Booking, error) {
+	booking, err := s.GetByID(ctx, id, userID, canManage)
+	if err != nil {
+		return nil, err
+	}
+	if booking.Status == models.BookingStatusCancelled {
+		return booking, nil
+	}
+
+	booking.Status = models.BookingStatusCancelled
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		cancelled, err := s.bookingRepo.UpdateStatus(ctx, id, models.BookingStatusCancelled)
+		if err != nil || !cancelled {
+			// Not cancelled here means a concurrent request already did it
+			// and announced it
+			return err
+		}
+		return s.events.Publish(ctx, domain.BookingCancelled{Booking: *booking})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,181 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/config"
+	"github.com/FiraBro/local-go/internal/domain"
+	"github.com/FiraBro/local-go/internal/mailer"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/passwords"
+)
+
+var (
+	ErrUserNotDeleted      = errors.New("account is not scheduled for deletion")
+	ErrRestoreTokenInvalid = errors.New("invalid or expired restore link")
+)
+
+// newRestoreToken returns a random token that restores the deleted account
+// until its deadline, without logging in. Every email and login attempt gets
+// its own; restoring the account invalidates them all.
+func (s *AuthService) newRestoreToken(user *models.User) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+
+	if err := s.userRepo.AddRestoreToken(user.ID, hashToken(token), *user.DeleteDeadline); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+func (s *AuthService) restoreLink(user *models.User) (string, error) {
+	token, err := s.newRestoreToken(user)
+	if err != nil {
+		return "", err
+	}
+	return config.AppBaseURL + "/api/v1/auth/restore-account?token=" + url.QueryEscape(token), nil
+}
+
+// sendDeletionEmail tells the owner their account was deleted and how to
+// restore it.
+func (s *AuthService) sendDeletionEmail(id string) {
+	user, err := s.userRepo.GetDeletedByID(id)
+	if err == nil {
+		err = s.sendRestoreLink(mailer.TemplateAccountDeleted, user)
+	}
+	if err != nil {
+		log.Println("⚠️ Failed to queue account deletion email:", err)
+	}
+}
+
+// sendRestoreLink queues a deletion email with a fresh restore link.
+func (s *AuthService) sendRestoreLink(template string, user *models.User) error {
+	link, err := s.restoreLink(user)
+	if err != nil {
+		return err
+	}
+	return s.mail.Send(template, user.Email, map[string]any{
+		"Deadline": user.DeleteDeadline.UTC().Format("2 January 2006 15:04 MST"),
+		"Link":     link,
+	})
+}
+
+// pendingDeletionLogin answers a login to an account scheduled for deletion:
+// with the right password the user gets a token to restore it, not a session.
+func (s *AuthService) pendingDeletionLogin(user *models.User, password string, keys []throttleKey, client models.ClientInfo) (*LoginResult, error) {
+	if err := passwords.Check(user.Password, password); err != nil {
+		s.recordFailure(keys)
+		s.auditLoginFailure(user.Email, user.ID, "wrong_password", client)
+		return nil, errors.New("invalid email or password")
+	}
+
+	token, err := s.newRestoreToken(user)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{RestoreToken: token, DeleteDeadline: user.DeleteDeadline}, nil
+}
+
+// RestoreAccount restores the account a restore token (from the deletion
+// emails or a login attempt) was issued for. The user logs in afterwards.
+func (s *AuthService) RestoreAccount(ctx context.Context, token string, client models.ClientInfo) error {
+	var userID string
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		userID, err = s.userRepo.RestoreByToken(ctx, hashToken(token), time.Now())
+		if err != nil {
+			return err
+		}
+		if userID == "" {
+			return ErrRestoreTokenInvalid
+		}
+		return s.events.Publish(ctx, domain.UserRestored{ID: userID})
+	})
+	if err != nil {
+		return err
+	}
+	s.audit.Record(userActor(userID, client), "user.restored", models.AuditTargetUser, userID, nil, nil)
+	return nil
+}
+
+// RunDeletionJobs reminds owners of deleted accounts shortly before their
+// deadline and purges accounts past it. Both steps are safe to run from
+// several instances at once; failures are logged per account.
+func (s *AuthService) RunDeletionJobs(ctx context.Context) error {
+	s.SendDeletionReminders()
+	s.PurgeExpiredDeletedUsers(ctx)
+	return nil
+}
+
+// SendDeletionReminders emails every deleted account due for purging within
+// ACCOUNT_DELETION_REMINDER that has not been reminded yet.
+func (s *AuthService) SendDeletionReminders() {
+	if config.AccountDeletionReminder == 0 {
+		return
+	}
+
+	now := time.Now()
+	users, err := s.userRepo.ListDeletedBefore(now.Add(config.AccountDeletionReminder), true)
+	if err != nil {
+		log.Println("⚠️ Failed to list accounts due for a deletion reminder:", err)
+		return
+	}
+
+	for i := range users {
+		user := &users[i]
+		if !user.DeleteDeadline.After(now) {
+			continue // about to be purged anyway
+		}
+		claimed, err := s.userRepo.MarkDeletionReminded(user.ID, now)
+		if err != nil || !claimed {
+			continue
+		}
+		if err := s.sendRestoreLink(mailer.TemplateAccountDeletionReminder, user); err != nil {
+			log.Println("⚠️ Failed to queue account deletion reminder:", err)
+		}
+	}
+}
+
+// ----------------------------
+// PURGE EXPIRED DELETED USERS
+// ----------------------------
+func (s *AuthService) PurgeExpiredDeletedUsers(ctx context.Context) {
+	now := time.Now()
+	users, err := s.userRepo.ListDeletedBefore(now, false)
+	if err != nil {
+		log.Println("⚠️ Failed to list expired users:", err)
+		return
+	}
+
+	count := 0
+	for _, user := range users {
+		var purged bool
+		err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			var err error
+			if purged, err = s.userRepo.PurgeUser(ctx, user.ID, now); err != nil || !purged {
+				return err
+			}
+			return s.events.Publish(ctx, domain.UserPurged{ID: user.ID})
+		})
+		if err != nil {
+			log.Printf("⚠️ Failed to purge user %s: %v", user.ID, err)
+			continue
+		}
+		if purged {
+			count++
+			s.audit.Record(ctx, "user.purged", models.AuditTargetUser, user.ID, nil, nil)
+		}
+	}
+	if count > 0 {
+		log.Printf("🧹 Purged %d deleted accounts", count)
+	}
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/bus"
+	"github.com/FiraBro/local-go/internal/domain"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/repositories"
+	"github.com/google/uuid"
+)
+
+const (
+	eventRelayBatchSize  = 50
+	eventRelayLease      = time.Minute // a claimed event is relayed again after this if its relay died
+	eventOutboxRetention = 7 * 24 * time.Hour
+)
+
+// EventOutbox is the bus's transactional outbox. Events published inside a
+// transaction are written by that transaction, so they exist exactly when
+// the change does, and a relay hands them to the bus after the commit. An
+// event is relayed at least once: should the relaying instance die half-way,
+// another relays it again once the lease runs out.
+type EventOutbox struct {
+	repo repositories.EventOutboxRepository
+	wake chan struct{}
+}
+
+func NewEventOutbox(repo repositories.EventOutboxRepository) *EventOutbox {
+	return &EventOutbox{repo: repo, wake: make(chan struct{}, 1)}
+}
+
+// Store implements bus.Outbox.
+func (o *EventOutbox) Store(ctx context.Context, e bus.Event) (bool, error) {
+	if !repositories.InTx(ctx) {
+		return false, nil
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return true, err
+	}
+	now := time.Now()
+	err = o.repo.Append(ctx, &models.OutboxEvent{
+		ID:            uuid.New().String(),
+		Name:          e.EventName(),
+		Payload:       payload,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	})
+	if err != nil {
+		return true, err
+	}
+
+	// Relay as soon as the change is committed rather than at the next poll
+	repositories.AfterCommit(ctx, func() {
+		select {
+		case o.wake <- struct{}{}:
+		default:
+		}
+	})
+	return true, nil
+}
+
+// StartRelay hands stored events to b whenever a transaction stored some
+// and every interval. Several instances may run relays at once; each event
+// then reaches the subscribers of one of them.
+func (o *EventOutbox) StartRelay(b *bus.Bus, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			o.relayDue(b)
+
+			select {
+			case <-ticker.C:
+			case <-o.wake:
+			}
+		}
+	}()
+}
+
+// relayDue relays every due event, a batch at a time, in the order they
+// were stored.
+func (o *EventOutbox) relayDue(b *bus.Bus) {
+	for {
+		now := time.Now()
+		due, err := o.repo.ListDue(now, eventRelayBatchSize)
+		if err != nil {
+			log.Println("⚠️ Failed to read the event outbox:", err)
+			return
+		}
+
+		for i := range due {
+			o.relay(b, &due[i], now)
+		}
+		if len(due) < eventRelayBatchSize {
+			return
+		}
+	}
+}
+
+func (o *EventOutbox) relay(b *bus.Bus, stored *models.OutboxEvent, now time.Time) {
+	claimed, err := o.repo.Claim(stored.ID, now, now.Add(eventRelayLease))
+	if err != nil || !claimed {
+		return
+	}
+
+	// An event this build cannot read never will be; it is dropped, not retried
+	if e, err := domain.Decode(stored.Name, stored.Payload); err != nil {
+		log.Printf("⚠️ Dropping stored event %s: %v", stored.ID, err)
+	} else {
+		b.Dispatch(context.Background(), e)
+	}
+
+	if err := o.repo.MarkPublished(stored.ID, time.Now()); err != nil {
+		log.Println("⚠️ Failed to update the event outbox:", err)
+	}
+}
+
+// Cleanup forgets events relayed more than eventOutboxRetention ago.
+func (o *EventOutbox) Cleanup(context.Context) error {
+	_, err := o.repo.DeletePublishedBefore(time.Now().Add(-eventOutboxRetention))
+	return err
+}
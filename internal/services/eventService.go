@@ -1,34 +1,256 @@
 package services
 
 import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/bus"
+	"github.com/FiraBro/local-go/internal/domain"
 	"github.com/FiraBro/local-go/internal/models"
 	"github.com/FiraBro/local-go/internal/repositories"
+	"github.com/google/uuid"
+)
+
+var (
+	ErrEventNotFound      = errors.New("event not found")
+	ErrTicketTypeNotFound = errors.New("ticket type not found")
+	ErrTicketNotFound     = errors.New("no active ticket for this event")
+	ErrNotEventOwner      = errors.New("only the event owner can do this")
+	ErrTicketNotOnSale    = errors.New("ticket type is not on sale")
+	ErrEventPast          = errors.New("event has already taken place")
 )
 
 type EventService struct {
-	repo *repositories.EventRepository
+	repo   repositories.EventRepository
+	tx     repositories.Transactor
+	events *bus.Bus
+}
+
+func NewEventService(repo repositories.EventRepository, transactor repositories.Transactor, eventBus *bus.Bus) *EventService {
+	return &EventService{repo: repo, tx: transactor, events: eventBus}
+}
+
+func (s *EventService) CreateEvent(ctx context.Context, event *models.Event) error {
+	if event.Capacity < 0 {
+		return fmt.Errorf("%w: capacity cannot be negative", ErrInvalidInput)
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, event); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.EventCreated{Event: *event})
+	})
+}
+
+func (s *EventService) GetAllEvents(ctx context.Context) ([]models.Event, error) {
+	return s.repo.GetAll(ctx)
+}
+
+func (s *EventService) GetEventByID(ctx context.Context, id string) (*models.Event, error) {
+	return s.event(ctx, id)
+}
+
+// UpdateEvent overwrites the editable fields of an event. Ownership and the
+// sold counter are kept from the stored row so a request body cannot move an
+// event to another user.
+func (s *EventService) UpdateEvent(ctx context.Context, event *models.Event) error {
+	existing, err := s.event(ctx, event.ID)
+	if err != nil {
+		return err
+	}
+
+	if event.Capacity < 0 {
+		return fmt.Errorf("%w: capacity cannot be negative", ErrInvalidInput)
+	}
+	if event.Capacity != 0 && event.Capacity < existing.TicketsSold {
+		return fmt.Errorf("%w: capacity cannot be below the %d tickets already issued", ErrInvalidInput, existing.TicketsSold)
+	}
+	if event.DateTime.IsZero() {
+		event.DateTime = existing.DateTime
+	}
+
+	event.UserId = existing.UserId
+	event.TicketsSold = existing.TicketsSold
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, event); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.EventUpdated{Event: *event})
+	})
+}
+
+func (s *EventService) DeleteEvent(ctx context.Context, id string) error {
+	existing, err := s.event(ctx, id)
+	if errors.Is(err, ErrEventNotFound) {
+		return nil // nothing to delete
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.EventDeleted{Event: *existing})
+	})
+}
+
+// ---------- TICKET TYPES ----------
+
+// AddTicketType creates a ticket type for an event owned by userID (or any
+// event when canManage is set).
+func (s *EventService) AddTicketType(ctx context.Context, eventID, userID string, canManage bool, tt *models.TicketType) error {
+	if _, err := s.ownedEvent(ctx, eventID, userID, canManage); err != nil {
+		return err
+	}
+
+	if err := validateTicketType(tt); err != nil {
+		return err
+	}
+
+	tt.EventID = eventID
+	tt.Sold = 0
+	return s.repo.CreateTicketType(ctx, tt)
+}
+
+func (s *EventService) GetTicketTypes(ctx context.Context, eventID string) ([]models.TicketType, error) {
+	if _, err := s.event(ctx, eventID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetTicketTypes(ctx, eventID)
+}
+
+func validateTicketType(tt *models.TicketType) error {
+	tt.Kind = strings.ToLower(strings.TrimSpace(tt.Kind))
+
+	switch tt.Kind {
+	case models.TicketKindFree:
+		if tt.Price != 0 {
+			return fmt.Errorf("%w: free tickets cannot have a price", ErrInvalidInput)
+		}
+	case models.TicketKindPaid, models.TicketKindVIP:
+		if tt.Price <= 0 {
+			return fmt.Errorf("%w: %s tickets need a positive price", ErrInvalidInput, tt.Kind)
+		}
+	case models.TicketKindEarlyBird:
+		if tt.Price < 0 {
+			return fmt.Errorf("%w: price cannot be negative", ErrInvalidInput)
+		}
+		if tt.SalesEnd == nil {
+			return fmt.Errorf("%w: early bird tickets need a sales_end", ErrInvalidInput)
+		}
+	default:
+		return fmt.Errorf("%w: kind must be one of free, paid, vip, early_bird", ErrInvalidInput)
+	}
+
+	if tt.Quantity < 0 {
+		return fmt.Errorf("%w: quantity cannot be negative", ErrInvalidInput)
+	}
+	if tt.SalesStart != nil && tt.SalesEnd != nil && !tt.SalesEnd.After(*tt.SalesStart) {
+		return fmt.Errorf("%w: sales_end must be after sales_start", ErrInvalidInput)
+	}
+	return nil
+}
+
+// ---------- REGISTRATION ----------
+
+// Register issues a ticket of the given type to userID. Capacity is enforced
+// by the repository inside a single transaction.
+func (s *EventService) Register(ctx context.Context, eventID, ticketTypeID, userID string) (*models.Ticket, error) {
+	event, err := s.event(ctx, eventID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if event.DateTime.Before(now) {
+		return nil, ErrEventPast
+	}
+
+	tt, err := s.repo.GetTicketType(ctx, eventID, ticketTypeID)
+	if err != nil {
+		return nil, err
+	}
+	if tt == nil {
+		return nil, ErrTicketTypeNotFound
+	}
+	if !tt.OnSale(now) {
+		return nil, ErrTicketNotOnSale
+	}
+
+	ticket := &models.Ticket{
+		EventID:      eventID,
+		TicketTypeID: tt.ID,
+		UserID:       userID,
+		Code:         newTicketCode(),
+		Price:        tt.Price,
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.IssueTicket(ctx, ticket); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.TicketIssued{Ticket: *ticket})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ticket, nil
 }
 
-func NewEventService(repo *repositories.EventRepository) *EventService {
-	return &EventService{repo: repo}
+func (s *EventService) CancelRegistration(ctx context.Context, eventID, userID string) error {
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CancelTicket(ctx, eventID, userID); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.TicketCancelled{EventID: eventID, UserID: userID})
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrTicketNotFound
+	}
+	return err
 }
 
-func (s *EventService) CreateEvent(event *models.Event) error {
-	return s.repo.Create(event)
+func (s *EventService) GetMyTickets(ctx context.Context, userID string) ([]models.Ticket, error) {
+	return s.repo.GetTicketsByUser(ctx, userID)
 }
 
-func (s *EventService) GetAllEvents() ([]models.Event, error) {
-	return s.repo.GetAll()
+// GetAttendees lists ticket holders; only the event owner or an event manager
+// may see it.
+func (s *EventService) GetAttendees(ctx context.Context, eventID, userID string, canManage bool) ([]models.Attendee, error) {
+	if _, err := s.ownedEvent(ctx, eventID, userID, canManage); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAttendees(ctx, eventID)
 }
 
-func (s *EventService) GetEventByID(id string) (*models.Event, error) {
-	return s.repo.GetByID(id)
+func (s *EventService) event(ctx context.Context, id string) (*models.Event, error) {
+	event, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrEventNotFound
+		}
+		return nil, err
+	}
+	return event, nil
 }
 
-func (s *EventService) UpdateEvent(event *models.Event) error {
-	return s.repo.Update(event)
+func (s *EventService) ownedEvent(ctx context.Context, id, userID string, canManage bool) (*models.Event, error) {
+	event, err := s.event(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !canManage && event.UserId != userID {
+		return nil, ErrNotEventOwner
+	}
+	return event, nil
 }
 
-func (s *EventService) DeleteEvent(id string) error {
-	return s.repo.Delete(id)
+// newTicketCode returns a short, human-readable code printed on the ticket.
+func newTicketCode() string {
+	return strings.ToUpper(strings.ReplaceAll(uuid.New().String(), "-", "")[:12])
 }
@@ -0,0 +1,139 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/config"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/repositories"
+	"github.com/FiraBro/local-go/internal/tokens"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+)
+
+const maxImpersonationListSize = 100
+
+var (
+	ErrImpersonateSelf          = errors.New("you cannot impersonate yourself")
+	ErrImpersonationReason      = errors.New("reason is required (at most 500 characters)")
+	ErrImpersonationTarget      = errors.New("user not found")
+	ErrImpersonationEscalation  = errors.New("cannot impersonate a user with permissions you do not have")
+	ErrImpersonationNotFound    = errors.New("impersonation not found")
+	ErrImpersonationAlreadyOver = errors.New("impersonation already ended")
+)
+
+type ImpersonationService struct {
+	repo     repositories.ImpersonationRepository
+	userRepo repositories.UserRepository
+	roleRepo repositories.RoleRepository
+	audit    *AuditService
+}
+
+func NewImpersonationService(repo repositories.ImpersonationRepository, userRepo repositories.UserRepository, roleRepo repositories.RoleRepository, auditService *AuditService) *ImpersonationService {
+	return &ImpersonationService{repo: repo, userRepo: userRepo, roleRepo: roleRepo, audit: auditService}
+}
+
+// Start lets actorID act as targetID for IMPERSONATION_TTL. The returned
+// access token is the target's, with the actor in its act claim (RFC 8693)
+// and the impersonation as its sid. There is no refresh token: when it
+// expires, the actor starts again.
+func (s *ImpersonationService) Start(ctx context.Context, actorID, actorRole, targetID, reason, ip string) (string, *models.Impersonation, error) {
+	if actorID == targetID {
+		return "", nil, ErrImpersonateSelf
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > 500 {
+		return "", nil, ErrImpersonationReason
+	}
+
+	target, err := s.userRepo.GetActiveByID(targetID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil, ErrImpersonationTarget
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Impersonating must not be a way to gain permissions
+	actorPerms, err := s.roleRepo.GetPermissions(actorRole)
+	if err != nil {
+		return "", nil, err
+	}
+	targetPerms, err := s.roleRepo.GetPermissions(target.Role)
+	if err != nil {
+		return "", nil, err
+	}
+	for _, p := range targetPerms {
+		if !slices.Contains(actorPerms, p) {
+			return "", nil, ErrImpersonationEscalation
+		}
+	}
+
+	now := time.Now()
+	imp := &models.Impersonation{
+		ID:        uuid.New().String(),
+		ActorID:   actorID,
+		TargetID:  target.ID,
+		Reason:    reason,
+		IP:        ip,
+		ExpiresAt: now.Add(config.ImpersonationTTL),
+		CreatedAt: now,
+	}
+	if err := s.repo.Create(imp); err != nil {
+		return "", nil, err
+	}
+
+	token, err := tokens.Sign(jwt.MapClaims{
+		"user_id": target.ID,
+		"role":    target.Role,
+		"sid":     imp.ID,
+		"act":     map[string]string{"sub": actorID},
+	}, config.JWTAudience, config.ImpersonationTTL)
+	if err != nil {
+		return "", nil, err
+	}
+	s.audit.Record(ctx, "impersonation.started", models.AuditTargetUser, target.ID, nil,
+		map[string]any{"impersonation_id": imp.ID, "reason": reason})
+	return token, imp, nil
+}
+
+// End stops an impersonation before its token expires; the token is refused
+// from then on.
+func (s *ImpersonationService) End(ctx context.Context, id string) error {
+	ended, err := s.repo.End(id, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ended {
+		return ErrImpersonationAlreadyOver
+	}
+	s.audit.Record(ctx, "impersonation.ended", models.AuditTargetImpersonation, id, nil, nil)
+	return nil
+}
+
+// List returns recent impersonations, optionally filtered by actor and target.
+func (s *ImpersonationService) List(actorID, targetID string) ([]models.Impersonation, error) {
+	return s.repo.List(actorID, targetID, maxImpersonationListSize)
+}
+
+// Requests returns the impersonation and every request made during it.
+func (s *ImpersonationService) Requests(id string) (*models.Impersonation, []models.ImpersonatedRequest, error) {
+	imp, err := s.repo.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if imp == nil {
+		return nil, nil, ErrImpersonationNotFound
+	}
+
+	requests, err := s.repo.ListRequests(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return imp, requests, nil
+}
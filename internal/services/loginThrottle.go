@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/config"
+	"github.com/FiraBro/local-go/internal/models"
+)
+
+// Failed-attempt throttling for AuthService. Wrong passwords, MFA codes and
+// reset OTPs count against both the account and the client IP. Each failure
+// doubles the wait before the next attempt, and reaching the limit locks the
+// account (or IP) for config.LoginLockout.
+
+const throttleBackoffBase = time.Second
+
+var (
+	ErrTooManyAttempts = errors.New("too many failed attempts")
+	ErrLockoutNotFound = errors.New("no failed attempts recorded")
+	ErrInvalidScope    = errors.New("scope must be account or ip")
+)
+
+// TooManyAttemptsError is returned while attempts are refused; it matches
+// ErrTooManyAttempts with errors.Is.
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("%s, try again in %s", ErrTooManyAttempts, e.RetryAfter.Round(time.Second))
+}
+
+func (e *TooManyAttemptsError) Is(target error) bool {
+	return target == ErrTooManyAttempts
+}
+
+type throttleKey struct {
+	scope, identifier string
+}
+
+func throttleKeys(email string, client models.ClientInfo) []throttleKey {
+	keys := []throttleKey{{models.ThrottleScopeAccount, normalizeEmail(email)}}
+	if client.IPAddress != "" {
+		keys = append(keys, throttleKey{models.ThrottleScopeIP, client.IPAddress})
+	}
+	return keys
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// An IP may be shared (NAT, offices), so it gets a higher limit and only
+// starts backing off in the second half of it.
+func throttleLimits(scope string) (maxFailures, freeFailures int) {
+	if scope == models.ThrottleScopeIP {
+		return config.LoginIPMaxFailures, config.LoginIPMaxFailures / 2
+	}
+	return config.LoginMaxFailures, 0
+}
+
+// blockedUntil fills in LockedUntil/LockedOut for a record inside the window.
+func blockedUntil(a *models.LoginAttempt, now time.Time) {
+	maxFailures, free := throttleLimits(a.Scope)
+
+	var wait time.Duration
+	switch {
+	case a.Failures >= maxFailures:
+		wait = config.LoginLockout
+		a.LockedOut = true
+	case a.Failures > free:
+		wait = config.LoginLockout
+		if exp := a.Failures - free - 1; exp < 30 {
+			wait = min(throttleBackoffBase<<exp, config.LoginLockout)
+		}
+	}
+
+	if until := a.LastFailureAt.Add(wait); until.After(now) {
+		a.LockedUntil = &until
+	}
+}
+
+// checkThrottle refuses the attempt while any of the keys is backing off or
+// locked out.
+func (s *AuthService) checkThrottle(keys []throttleKey) error {
+	now := time.Now()
+	var retryAfter time.Duration
+
+	for _, k := range keys {
+		a, err := s.attemptRepo.Get(k.scope, k.identifier)
+		if err != nil {
+			return err
+		}
+		if a == nil || a.LastFailureAt.Before(now.Add(-config.LoginLockout)) {
+			continue
+		}
+
+		blockedUntil(a, now)
+		if a.LockedUntil != nil {
+			retryAfter = max(retryAfter, a.LockedUntil.Sub(now))
+		}
+	}
+
+	if retryAfter > 0 {
+		return &TooManyAttemptsError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+func (s *AuthService) recordFailure(keys []throttleKey) {
+	now := time.Now()
+	windowStart := now.Add(-config.LoginLockout)
+
+	for _, k := range keys {
+		failures, err := s.attemptRepo.RecordFailure(k.scope, k.identifier, now, windowStart)
+		if err != nil {
+			log.Println("⚠️ Failed to record failed attempt:", err)
+			continue
+		}
+		if maxFailures, _ := throttleLimits(k.scope); failures == maxFailures {
+			log.Printf("⚠️ Locking out %s %s after %d failed attempts", k.scope, k.identifier, failures)
+			s.audit.Record(context.Background(), "login_attempts.locked", models.AuditTargetLoginAttempts, k.scope+":"+k.identifier,
+				nil, map[string]any{"failures": failures, "locked_for": config.LoginLockout.String()})
+		}
+	}
+
+	// Keep the table to what is still inside the window
+	if err := s.attemptRepo.DeleteBefore(windowStart); err != nil {
+		log.Println("⚠️ Failed to prune failed attempts:", err)
+	}
+}
+
+// clearAccountFailures runs after a successful sign-in. The IP record is
+// left alone: one valid account must not reset an attacker's IP budget.
+func (s *AuthService) clearAccountFailures(email string) {
+	if _, err := s.attemptRepo.Clear(models.ThrottleScopeAccount, normalizeEmail(email)); err != nil {
+		log.Println("⚠️ Failed to clear failed attempts:", err)
+	}
+}
+
+// ----------------------------
+// ADMIN
+// ----------------------------
+
+// ListLoginAttempts returns every account and IP with failures inside the
+// current window; LockedUntil is set on those currently refused.
+func (s *AuthService) ListLoginAttempts() ([]models.LoginAttempt, error) {
+	now := time.Now()
+	attempts, err := s.attemptRepo.ListSince(now.Add(-config.LoginLockout))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range attempts {
+		blockedUntil(&attempts[i], now)
+	}
+	return attempts, nil
+}
+
+// ClearLoginAttempts lifts a lockout (and any backoff) early.
+func (s *AuthService) ClearLoginAttempts(ctx context.Context, scope, identifier string) error {
+	switch scope {
+	case models.ThrottleScopeAccount:
+		identifier = normalizeEmail(identifier)
+	case models.ThrottleScopeIP:
+	default:
+		return ErrInvalidScope
+	}
+
+	ok, err := s.attemptRepo.Clear(scope, identifier)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLockoutNotFound
+	}
+	s.audit.Record(ctx, "login_attempts.cleared", models.AuditTargetLoginAttempts, scope+":"+identifier, nil, nil)
+	return nil
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/config"
+	"github.com/FiraBro/local-go/internal/models"
+)
+
+func TestBlockedUntil(t *testing.T) {
+	accountMax, ipMax, lockout := config.LoginMaxFailures, config.LoginIPMaxFailures, config.LoginLockout
+	t.Cleanup(func() {
+		config.LoginMaxFailures, config.LoginIPMaxFailures, config.LoginLockout = accountMax, ipMax, lockout
+	})
+	// A high account limit, to reach the cap on the backoff before the lockout
+	config.LoginMaxFailures, config.LoginIPMaxFailures, config.LoginLockout = 40, 50, 15*time.Minute
+
+	last := time.Date(2030, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		scope      string
+		failures   int
+		since      time.Duration // from the last failure to now
+		wantWait   time.Duration // from the last failure; 0 when not blocked
+		wantLocked bool
+	}{
+		{"account, no failures", models.ThrottleScopeAccount, 0, 0, 0, false},
+		{"account, first failure", models.ThrottleScopeAccount, 1, 0, time.Second, false},
+		{"account, doubles", models.ThrottleScopeAccount, 2, 0, 2 * time.Second, false},
+		{"account, doubles again", models.ThrottleScopeAccount, 4, 0, 8 * time.Second, false},
+		{"account, wait is over", models.ThrottleScopeAccount, 3, 5 * time.Second, 0, false},
+		{"account, still waiting", models.ThrottleScopeAccount, 3, 3 * time.Second, 4 * time.Second, false},
+		{"account, backoff capped at the lockout", models.ThrottleScopeAccount, 12, 0, 15 * time.Minute, false},
+		{"account, huge exponent", models.ThrottleScopeAccount, 35, 0, 15 * time.Minute, false},
+		{"account, limit reached", models.ThrottleScopeAccount, 40, 0, 15 * time.Minute, true},
+		{"account, lockout over", models.ThrottleScopeAccount, 40, 15 * time.Minute, 0, true},
+		{"ip, free failures", models.ThrottleScopeIP, 25, 0, 0, false},
+		{"ip, first counted failure", models.ThrottleScopeIP, 26, 0, time.Second, false},
+		{"ip, doubles", models.ThrottleScopeIP, 28, 0, 4 * time.Second, false},
+		{"ip, limit reached", models.ThrottleScopeIP, 50, 0, 15 * time.Minute, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &models.LoginAttempt{Scope: tt.scope, Failures: tt.failures, LastFailureAt: last}
+			blockedUntil(a, last.Add(tt.since))
+
+			if a.LockedOut != tt.wantLocked {
+				t.Errorf("LockedOut = %v, want %v", a.LockedOut, tt.wantLocked)
+			}
+			switch {
+			case tt.wantWait == 0 && a.LockedUntil != nil:
+				t.Errorf("LockedUntil = %s, want not blocked", a.LockedUntil)
+			case tt.wantWait != 0 && a.LockedUntil == nil:
+				t.Errorf("not blocked, want LockedUntil = last failure + %s", tt.wantWait)
+			case tt.wantWait != 0 && !a.LockedUntil.Equal(last.Add(tt.wantWait)):
+				t.Errorf("LockedUntil = last failure + %s, want + %s", a.LockedUntil.Sub(last), tt.wantWait)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"net/textproto"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/config"
+	"github.com/FiraBro/local-go/internal/mailer"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/repositories"
+	"github.com/google/uuid"
+)
+
+const (
+	mailBatchSize    = 20
+	mailSendLease    = 2 * time.Minute // a claimed message is retried after this if its worker died
+	mailRetryBase    = 30 * time.Second
+	mailRetryMax     = time.Hour
+	mailRetention    = 30 * 24 * time.Hour // sent and failed messages are deleted after this
+	mailCleanupEvery = time.Hour
+)
+
+// MailService queues transactional email in the outbox and delivers it in
+// the background, so requests never wait on (or fail because of) SMTP.
+type MailService struct {
+	repo   repositories.EmailOutboxRepository
+	mailer mailer.Mailer
+	wake   chan struct{}
+}
+
+func NewMailService(repo repositories.EmailOutboxRepository, m mailer.Mailer) *MailService {
+	return &MailService{repo: repo, mailer: m, wake: make(chan struct{}, 1)}
+}
+
+// Send renders the template for data and queues it for to. It fails only if
+// the message cannot be rendered or stored; delivery is retried until it
+// succeeds or MAIL_MAX_ATTEMPTS runs out.
+func (s *MailService) Send(template, to string, data any) error {
+	if _, err := mail.ParseAddress(to); err != nil {
+		return fmt.Errorf("invalid recipient %q: %w", to, err)
+	}
+	msg, err := mailer.Render(template, data)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	err = s.repo.Enqueue(&models.OutboxEmail{
+		ID:            uuid.New().String(),
+		Template:      template,
+		Recipient:     to,
+		Subject:       msg.Subject,
+		TextBody:      msg.Text,
+		HTMLBody:      msg.HTML,
+		Status:        models.EmailStatusPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Deliver now rather than at the next poll
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// StartWorker delivers queued mail whenever some is queued and every
+// interval, to pick up retries. Several instances may run workers at once.
+func (s *MailService) StartWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var lastCleanup time.Time
+		for {
+			s.deliverDue()
+			if time.Since(lastCleanup) >= mailCleanupEvery {
+				s.cleanup()
+				lastCleanup = time.Now()
+			}
+
+			select {
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+}
+
+// deliverDue sends every due message, a batch at a time.
+func (s *MailService) deliverDue() {
+	for {
+		now := time.Now()
+		due, err := s.repo.ListDue(now, mailBatchSize)
+		if err != nil {
+			log.Println("⚠️ Failed to read the email outbox:", err)
+			return
+		}
+
+		for i := range due {
+			s.deliver(&due[i], now)
+		}
+		if len(due) < mailBatchSize {
+			return
+		}
+	}
+}
+
+func (s *MailService) deliver(email *models.OutboxEmail, now time.Time) {
+	claimed, err := s.repo.Claim(email.ID, now, now.Add(mailSendLease))
+	if err != nil || !claimed {
+		return
+	}
+	attempt := email.Attempts + 1
+
+	ctx, cancel := context.WithTimeout(context.Background(), mailSendLease/2)
+	defer cancel()
+	err = s.mailer.Send(ctx, mailer.Message{
+		To:      email.Recipient,
+		Subject: email.Subject,
+		Text:    email.TextBody,
+		HTML:    email.HTMLBody,
+	})
+
+	switch {
+	case err == nil:
+		err = s.repo.MarkSent(email.ID, time.Now())
+	case permanentMailError(err) || attempt >= config.MailMaxAttempts:
+		log.Printf("⚠️ Giving up on %s email to %s after %d attempts: %v", email.Template, email.Recipient, attempt, err)
+		err = s.repo.MarkFailed(email.ID, err.Error())
+	default:
+		log.Printf("⚠️ Failed to send %s email to %s (attempt %d), retrying: %v", email.Template, email.Recipient, attempt, err)
+		err = s.repo.MarkRetry(email.ID, time.Now().Add(mailRetryDelay(attempt)), err.Error())
+	}
+	if err != nil {
+		log.Println("⚠️ Failed to update the email outbox:", err)
+	}
+}
+
+// mailRetryDelay doubles from mailRetryBase after each failed attempt, up to
+// mailRetryMax.
+func mailRetryDelay(attempt int) time.Duration {
+	delay := mailRetryBase
+	for i := 1; i < attempt && delay < mailRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, mailRetryMax)
+}
+
+// permanentMailError reports whether the SMTP server refused the recipient
+// for good (550-553: unknown or disallowed mailbox); retrying would not help.
+// Other errors, including authentication failures, are retried so mail
+// survives a misconfiguration until it is fixed.
+func permanentMailError(err error) bool {
+	var smtpErr *textproto.Error
+	return errors.As(err, &smtpErr) && smtpErr.Code >= 550 && smtpErr.Code <= 553
+}
+
+func (s *MailService) cleanup() {
+	if _, err := s.repo.DeleteFinishedBefore(time.Now().Add(-mailRetention)); err != nil {
+		log.Println("⚠️ Failed to clean up the email outbox:", err)
+	}
+}
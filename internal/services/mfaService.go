@@ -0,0 +1,428 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/config"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/passwords"
+	"github.com/FiraBro/local-go/internal/tokens"
+	"github.com/FiraBro/local-go/internal/utils"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// TOTP two-factor authentication for AuthService: enrollment, recovery codes,
+// the second login step and the per-role requirement.
+
+const (
+	mfaStepVerify = "verify" // user has MFA, must present a code
+	mfaStepEnroll = "enroll" // role requires MFA, user must set it up first
+
+	mfaTokenTTL       = 5 * time.Minute
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrMFAInvalidCode    = errors.New("invalid authentication code")
+	ErrMFATokenInvalid   = errors.New("invalid or expired mfa token")
+	ErrMFAAlreadyEnabled = errors.New("mfa is already enabled")
+	ErrMFANotEnabled     = errors.New("mfa is not enabled")
+	ErrMFANotSetUp       = errors.New("mfa setup has not been started")
+	ErrMFARequired       = errors.New("mfa is required for your role")
+)
+
+// ----------------------------
+// ENROLLMENT (authenticated)
+// ----------------------------
+
+// BeginMFASetup generates a new secret for the user. It is not enforced until
+// ConfirmMFA proves the user's authenticator produces matching codes.
+func (s *AuthService) BeginMFASetup(userID string) (*models.MFASetup, error) {
+	user, err := s.userRepo.GetActiveByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.beginMFASetup(user)
+}
+
+// ConfirmMFA enables MFA and returns the recovery codes, which are shown
+// only this once.
+func (s *AuthService) ConfirmMFA(ctx context.Context, userID, code string) ([]string, error) {
+	m, err := s.mfaRepo.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, err := s.confirmMFA(userID, m, code)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, "user.mfa_enabled", models.AuditTargetUser, userID, nil, nil)
+	return codes, nil
+}
+
+// DisableMFA needs both the password and a current code (or recovery code).
+// Wrong ones count toward the login lockout, so a stolen access token is no
+// shortcut to guessing them.
+func (s *AuthService) DisableMFA(ctx context.Context, userID, password, code string, client models.ClientInfo) error {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+
+	keys := throttleKeys(user.Email, client)
+	if err := s.checkThrottle(keys); err != nil {
+		return err
+	}
+	if err := passwords.Check(user.Password, password); err != nil {
+		if errors.Is(err, passwords.ErrMismatch) {
+			s.recordFailure(keys)
+		}
+		return errors.New("password is incorrect")
+	}
+
+	required, err := s.mfaRepo.IsRequiredForRole(user.Role)
+	if err != nil {
+		return err
+	}
+	if required {
+		return ErrMFARequired
+	}
+
+	m, err := s.mfaRepo.Get(userID)
+	if err != nil {
+		return err
+	}
+	if m == nil || !m.Enabled {
+		return ErrMFANotEnabled
+	}
+	if err := s.verifySecondFactor(m, code); err != nil {
+		if errors.Is(err, ErrMFAInvalidCode) {
+			s.recordFailure(keys)
+		}
+		return err
+	}
+
+	if err := s.mfaRepo.Disable(userID); err != nil {
+		return err
+	}
+	s.clearAccountFailures(user.Email)
+	s.audit.Record(ctx, "user.mfa_disabled", models.AuditTargetUser, userID, nil, nil)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a TOTP
+// code. Wrong codes count toward the login lockout, as in DisableMFA.
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID, code string, client models.ClientInfo) ([]string, error) {
+	user, err := s.userRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := throttleKeys(user.Email, client)
+	if err := s.checkThrottle(keys); err != nil {
+		return nil, err
+	}
+
+	m, err := s.mfaRepo.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if m == nil || !m.Enabled {
+		return nil, ErrMFANotEnabled
+	}
+	if err := s.verifyTOTP(m, code); err != nil {
+		if errors.Is(err, ErrMFAInvalidCode) {
+			s.recordFailure(keys)
+		}
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	s.clearAccountFailures(user.Email)
+	s.audit.Record(ctx, "user.recovery_codes_regenerated", models.AuditTargetUser, userID, nil, nil)
+	return codes, nil
+}
+
+func (s *AuthService) MFAStatus(userID string) (*models.MFAStatus, error) {
+	user, err := s.userRepo.GetActiveByID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &models.MFAStatus{}
+	if status.Required, err = s.mfaRepo.IsRequiredForRole(user.Role); err != nil {
+		return nil, err
+	}
+
+	m, err := s.mfaRepo.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil && m.Enabled {
+		status.Enabled = true
+		if status.RecoveryCodesRemaining, err = s.mfaRepo.CountRecoveryCodes(userID); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// ----------------------------
+// LOGIN SECOND STEP
+// ----------------------------
+
+// BeginMFALoginSetup lets a user whose role requires MFA enroll using the
+// token from Login, since they cannot get an access token without MFA.
+func (s *AuthService) BeginMFALoginSetup(mfaToken string) (*models.MFASetup, error) {
+	userID, step, err := parseMFAToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if step != mfaStepEnroll {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	user, err := s.userRepo.GetActiveByID(userID)
+	if err != nil {
+		return nil, ErrMFATokenInvalid
+	}
+	return s.beginMFASetup(user)
+}
+
+// CompleteMFALogin exchanges the token from Login plus a TOTP code (or a
+// recovery code) for a session. For an enrollment token the code also
+// confirms the new MFA setup.
+func (s *AuthService) CompleteMFALogin(mfaToken, code string, client models.ClientInfo) (*LoginResult, error) {
+	userID, step, err := parseMFAToken(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetActiveByID(userID)
+	if err != nil {
+		return nil, ErrMFATokenInvalid
+	}
+
+	// Wrong codes count toward the same lockout as wrong passwords
+	keys := throttleKeys(user.Email, client)
+	if err := s.checkThrottle(keys); err != nil {
+		return nil, err
+	}
+
+	m, err := s.mfaRepo.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &LoginResult{User: user}
+	switch {
+	case m != nil && m.Enabled:
+		err = s.verifySecondFactor(m, code)
+	case step == mfaStepEnroll:
+		result.RecoveryCodes, err = s.confirmMFA(userID, m, code)
+	default:
+		// MFA was disabled after the token was issued
+		return nil, ErrMFATokenInvalid
+	}
+
+	if errors.Is(err, ErrMFAInvalidCode) {
+		s.recordFailure(keys)
+		s.auditLoginFailure(user.Email, user.ID, "wrong_mfa_code", client)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if result.RecoveryCodes != nil {
+		s.audit.Record(userActor(userID, client), "user.mfa_enabled", models.AuditTargetUser, userID, nil, nil)
+	}
+
+	if result.AccessToken, result.RefreshToken, err = s.startSession(user, client); err != nil {
+		return nil, err
+	}
+	s.clearAccountFailures(user.Email)
+	return result, nil
+}
+
+// mfaStepFor decides whether Login has to stop at an MFA challenge.
+func (s *AuthService) mfaStepFor(user *models.User) (string, error) {
+	m, err := s.mfaRepo.Get(user.ID)
+	if err != nil {
+		return "", err
+	}
+	if m != nil && m.Enabled {
+		return mfaStepVerify, nil
+	}
+
+	required, err := s.mfaRepo.IsRequiredForRole(user.Role)
+	if err != nil {
+		return "", err
+	}
+	if required {
+		return mfaStepEnroll, nil
+	}
+	return "", nil
+}
+
+// ----------------------------
+// POLICY (admin)
+// ----------------------------
+
+func (s *AuthService) GetMFARequiredRoles() ([]string, error) {
+	return s.mfaRepo.GetRequiredRoles()
+}
+
+func (s *AuthService) SetMFARequiredForRole(ctx context.Context, role string, required bool) error {
+	role = strings.TrimSpace(role)
+	if role == "" {
+		return errors.New("role is required")
+	}
+
+	was, err := s.mfaRepo.IsRequiredForRole(role)
+	if err != nil {
+		return err
+	}
+	if err := s.mfaRepo.SetRequiredForRole(role, required); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, "role.mfa_policy_changed", models.AuditTargetRole, role,
+		map[string]any{"mfa_required": was}, map[string]any{"mfa_required": required})
+	return nil
+}
+
+// ----------------------------
+// HELPERS
+// ----------------------------
+
+func (s *AuthService) beginMFASetup(user *models.User) (*models.MFASetup, error) {
+	m, err := s.mfaRepo.Get(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if m != nil && m.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.SavePending(user.ID, secret); err != nil {
+		return nil, err
+	}
+
+	return &models.MFASetup{
+		Secret: secret,
+		URI:    utils.TOTPURI(config.MFAIssuer, user.Email, secret),
+	}, nil
+}
+
+func (s *AuthService) confirmMFA(userID string, m *models.UserMFA, code string) ([]string, error) {
+	if m == nil {
+		return nil, ErrMFANotSetUp
+	}
+	if m.Enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if err := s.verifyTOTP(m, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepo.Enable(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// verifySecondFactor accepts a TOTP code or, failing that, a recovery code.
+func (s *AuthService) verifySecondFactor(m *models.UserMFA, code string) error {
+	if err := s.verifyTOTP(m, code); err == nil {
+		return nil
+	}
+
+	used, err := s.mfaRepo.UseRecoveryCode(m.UserID, hashToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrMFAInvalidCode
+	}
+	return nil
+}
+
+// verifyTOTP checks the code and burns its time step so it cannot be replayed.
+func (s *AuthService) verifyTOTP(m *models.UserMFA, code string) error {
+	step, ok := utils.VerifyTOTP(m.Secret, code, time.Now())
+	if !ok {
+		return ErrMFAInvalidCode
+	}
+
+	fresh, err := s.mfaRepo.UseStep(m.UserID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrMFAInvalidCode
+	}
+	return nil
+}
+
+// newRecoveryCodes returns codes for the user ("xxxxx-xxxxx") and the hashes
+// to store for them.
+func newRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(buf))[:10]
+		codes[i] = raw[:5] + "-" + raw[5:]
+		hashes[i] = hashToken(raw)
+	}
+	return codes, hashes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
+
+func generateMFAToken(userID, step string) (string, error) {
+	return tokens.Sign(jwt.MapClaims{
+		"user_id": userID,
+		"step":    step,
+	}, purposeAudience("mfa"), mfaTokenTTL)
+}
+
+func parseMFAToken(token string) (userID, step string, err error) {
+	claims := jwt.MapClaims{}
+	if err := tokens.Parse(token, claims, purposeAudience("mfa")); err != nil {
+		return "", "", ErrMFATokenInvalid
+	}
+
+	userID, _ = claims["user_id"].(string)
+	step, _ = claims["step"].(string)
+	if userID == "" || (step != mfaStepVerify && step != mfaStepEnroll) {
+		return "", "", ErrMFATokenInvalid
+	}
+	return userID, step, nil
+}
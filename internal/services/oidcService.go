@@ -0,0 +1,254 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/domain"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/oidc"
+	"github.com/FiraBro/local-go/internal/passwords"
+	"github.com/google/uuid"
+)
+
+// OIDC social login for AuthService: the authorization code flow with PKCE,
+// and linking provider accounts to local users.
+
+const oidcLoginTTL = 10 * time.Minute
+
+var (
+	ErrUnknownProvider    = errors.New("unknown login provider")
+	ErrOIDCStateInvalid   = errors.New("login expired or was already used, please start again")
+	ErrOIDCFailed         = errors.New("could not log in with the provider")
+	ErrOIDCNoEmail        = errors.New("the provider did not share an email address")
+	ErrOIDCEmailTaken     = errors.New("an account with this email already exists; log in with your password to use it")
+	ErrOIDCAccountDeleted = errors.New("this account has been deleted")
+	ErrIdentityNotFound   = errors.New("linked account not found")
+)
+
+// OIDCProviders lists the configured provider names.
+func (s *AuthService) OIDCProviders() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BeginOIDCLogin returns the provider URL to send the user to. The state,
+// nonce and PKCE verifier are kept server side until the callback.
+func (s *AuthService) BeginOIDCLogin(ctx context.Context, providerName string) (string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+
+	state, err := oidc.RandomString(24)
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.RandomString(24)
+	if err != nil {
+		return "", err
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := provider.AuthURL(ctx, state, nonce, challenge)
+	if err != nil {
+		log.Println("⚠️ OIDC:", err)
+		return "", ErrOIDCFailed
+	}
+
+	err = s.identityRepo.SaveLoginState(&models.OIDCLoginState{
+		State:        state,
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		ExpiresAt:    time.Now().Add(oidcLoginTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+	return authURL, nil
+}
+
+// CompleteOIDCLogin handles the provider's callback: it redeems the code,
+// finds or creates the local user, and logs them in like Login does
+// (including the MFA step).
+func (s *AuthService) CompleteOIDCLogin(ctx context.Context, providerName, state, code string, client models.ClientInfo) (*LoginResult, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	st, err := s.identityRepo.TakeLoginState(state)
+	if err != nil {
+		return nil, err
+	}
+	if st == nil || st.Provider != providerName || st.ExpiresAt.Before(time.Now()) {
+		return nil, ErrOIDCStateInvalid
+	}
+
+	claims, err := provider.Exchange(ctx, code, st.CodeVerifier, st.Nonce)
+	if err != nil {
+		log.Printf("⚠️ OIDC login with %s failed: %v", providerName, err)
+		return nil, ErrOIDCFailed
+	}
+
+	user, err := s.userForIdentity(ctx, providerName, claims, client)
+	if err != nil {
+		return nil, err
+	}
+	return s.finishLogin(user, client)
+}
+
+// userForIdentity resolves the provider account to a local user: the linked
+// one, else an existing account with the same (verified) email, else a new
+// account.
+func (s *AuthService) userForIdentity(ctx context.Context, provider string, claims *oidc.Claims, client models.ClientInfo) (*models.User, error) {
+	now := time.Now()
+
+	identity, err := s.identityRepo.Get(provider, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if identity != nil {
+		user, err := s.userRepo.GetActiveByID(identity.UserID)
+		if err != nil {
+			return nil, ErrOIDCAccountDeleted
+		}
+		if err := s.identityRepo.Touch(identity.ID, now); err != nil {
+			log.Println("⚠️ Failed to record identity login:", err)
+		}
+		return user, nil
+	}
+
+	email := normalizeEmail(claims.Email)
+	if email == "" {
+		return nil, ErrOIDCNoEmail
+	}
+
+	user, err := s.userRepo.GetByEmail(email)
+	switch {
+	case err == nil:
+		// Only link when both sides have proven the address. Otherwise whoever
+		// registered it first (possibly an attacker, before the real owner
+		// ever signed up) would end up sharing an account with them.
+		if !claims.EmailVerified || user.VerifiedAt == nil {
+			return nil, ErrOIDCEmailTaken
+		}
+		log.Printf("🔗 Linking %s account to existing user %s", provider, user.ID)
+
+	case errors.Is(err, sql.ErrNoRows):
+		if user, err = s.createOIDCUser(ctx, email, claims); err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, err
+	}
+
+	err = s.identityRepo.Create(&models.Identity{
+		UserID:      user.ID,
+		Provider:    provider,
+		Subject:     claims.Subject,
+		Email:       email,
+		LastLoginAt: &now,
+		CreatedAt:   now,
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(userActor(user.ID, client), "user.identity_linked", models.AuditTargetUser, user.ID,
+		nil, map[string]any{"provider": provider, "subject": claims.Subject, "email": email})
+	return user, nil
+}
+
+// createOIDCUser signs up someone who arrived through a provider. They get an
+// unguessable password; "forgot password" sets a real one if they want it.
+func (s *AuthService) createOIDCUser(ctx context.Context, email string, claims *oidc.Claims) (*models.User, error) {
+	// A soft-deleted account still holds the address
+	exists, err := s.userRepo.ExistsByEmail(email)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrOIDCAccountDeleted
+	}
+
+	username := strings.TrimSpace(claims.Name)
+	if username == "" {
+		username, _, _ = strings.Cut(email, "@")
+	}
+
+	password, err := passwords.Hash(uuid.New().String() + uuid.New().String())
+	if err != nil {
+		return nil, err
+	}
+
+	user := &models.User{Username: username, Email: email, Password: password, Role: models.DefaultRole}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.CreateUser(ctx, user); err != nil {
+			return fmt.Errorf("create user: %w", err)
+		}
+		return s.events.Publish(ctx, domain.UserCreated{User: domain.UserOf(user)})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.EmailVerified {
+		if _, err := s.userRepo.MarkEmailVerified(user.ID, email); err != nil {
+			return nil, err
+		}
+		now := time.Now()
+		user.VerifiedAt = &now
+	} else {
+		s.sendVerification(user.ID, email)
+	}
+	return user, nil
+}
+
+// ListIdentities returns the provider accounts linked to the user.
+func (s *AuthService) ListIdentities(userID string) ([]models.Identity, error) {
+	return s.identityRepo.ListByUser(userID)
+}
+
+// UnlinkIdentity removes a linked provider account. The user keeps their
+// password login (or can set one with "forgot password").
+func (s *AuthService) UnlinkIdentity(ctx context.Context, userID, id string) error {
+	if _, err := uuid.Parse(id); err != nil {
+		return ErrIdentityNotFound
+	}
+
+	identities, err := s.identityRepo.ListByUser(userID)
+	if err != nil {
+		return err
+	}
+	i := slices.IndexFunc(identities, func(identity models.Identity) bool { return identity.ID == id })
+	if i < 0 {
+		return ErrIdentityNotFound
+	}
+
+	ok, err := s.identityRepo.Delete(userID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrIdentityNotFound
+	}
+	s.audit.Record(ctx, "user.identity_unlinked", models.AuditTargetUser, userID,
+		map[string]any{"provider": identities[i].Provider, "subject": identities[i].Subject, "email": identities[i].Email}, nil)
+	return nil
+}
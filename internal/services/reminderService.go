@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/mailer"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/repositories"
+)
+
+// reminderRetention is how long sent reminders are remembered; it must
+// exceed the largest REMINDER_OFFSETS.
+const reminderRetention = 60 * 24 * time.Hour
+
+var ErrUnknownChannel = errors.New("unknown notification channel")
+
+// ReminderService reminds users of upcoming bookings and event tickets and
+// manages which channels they want reminders on.
+type ReminderService struct {
+	repo    repositories.NotificationRepository
+	mail    *MailService
+	audit   *AuditService
+	offsets []time.Duration // largest first
+}
+
+func NewReminderService(repo repositories.NotificationRepository, mail *MailService, audit *AuditService, offsets []time.Duration) *ReminderService {
+	offsets = slices.Clone(offsets)
+	slices.Sort(offsets)
+	slices.Reverse(offsets)
+	return &ReminderService{repo: repo, mail: mail, audit: audit, offsets: slices.Compact(offsets)}
+}
+
+// ----------------------------
+// SEND REMINDERS
+// ----------------------------
+
+// SendDue sends the reminders that are due. An item gets the reminder for
+// an offset once its start is closer than the offset, unless it was booked
+// after that point. If the job fell behind and several offsets are due at
+// once, only the closest one is sent. Sent reminders are recorded, so
+// restarts and other instances do not send them again.
+func (s *ReminderService) SendDue(ctx context.Context) error {
+	if len(s.offsets) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	horizon := now.Add(s.offsets[0])
+	bookings, err := s.repo.ListUpcomingBookings(ctx, now, horizon, models.NotificationChannelEmail)
+	if err != nil {
+		return fmt.Errorf("list upcoming bookings: %w", err)
+	}
+	tickets, err := s.repo.ListUpcomingTickets(ctx, now, horizon, models.NotificationChannelEmail)
+	if err != nil {
+		return fmt.Errorf("list upcoming tickets: %w", err)
+	}
+
+	for _, item := range append(bookings, tickets...) {
+		if err := s.remind(ctx, &item, now); err != nil {
+			log.Printf("⚠️ Failed to send %s reminder for %s: %v", item.Kind, item.ID, err)
+		}
+	}
+	return nil
+}
+
+func (s *ReminderService) remind(ctx context.Context, item *models.UpcomingItem, now time.Time) error {
+	sendNow := false
+	for _, offset := range s.offsets {
+		remindAt := item.StartsAt.Add(-offset)
+		if now.Before(remindAt) || !item.CreatedAt.Before(remindAt) {
+			continue
+		}
+		// Claim every due offset, so a skipped one is not sent later
+		claimed, err := s.repo.ClaimReminder(ctx, item.Kind, item.ID, offset, models.NotificationChannelEmail, now)
+		if err != nil {
+			return err
+		}
+		sendNow = sendNow || claimed
+	}
+	if !sendNow {
+		return nil
+	}
+
+	template := mailer.TemplateBookingReminder
+	if item.Kind == models.ReminderKindTicket {
+		template = mailer.TemplateEventReminder
+	}
+	return s.mail.Send(template, item.Email, map[string]string{
+		"Username": item.Username,
+		"Title":    item.Title,
+		"With":     item.With,
+		"Location": item.Location,
+		"When":     item.StartsAt.Format("Monday, 2 January 2006 at 15:04"),
+		"In":       humanizeIn(item.StartsAt.Sub(now)),
+	})
+}
+
+// humanizeIn describes a time until something, e.g. "in 24 hours".
+func humanizeIn(d time.Duration) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("in 1 %s", unit)
+		}
+		return fmt.Sprintf("in %d %ss", n, unit)
+	}
+
+	switch {
+	case d < 50*time.Minute:
+		return plural(max(int(d.Round(time.Minute)/time.Minute), 1), "minute")
+	case d < 72*time.Hour:
+		return plural(int(d.Round(time.Hour)/time.Hour), "hour")
+	default:
+		return plural(int(d.Round(24*time.Hour)/(24*time.Hour)), "day")
+	}
+}
+
+// CleanupSent forgets reminders for items long past.
+func (s *ReminderService) CleanupSent(ctx context.Context) error {
+	_, err := s.repo.DeleteRemindersBefore(ctx, time.Now().Add(-reminderRetention))
+	return err
+}
+
+// ----------------------------
+// PREFERENCES
+// ----------------------------
+
+// Preferences reports, for every channel, whether the user gets reminders
+// on it.
+func (s *ReminderService) Preferences(ctx context.Context, userID string) (map[string]bool, error) {
+	optOuts, err := s.repo.ListOptOuts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefs := make(map[string]bool, len(models.NotificationChannels))
+	for _, channel := range models.NotificationChannels {
+		prefs[channel] = !slices.Contains(optOuts, channel)
+	}
+	return prefs, nil
+}
+
+// UpdatePreferences turns reminders on or off per channel; channels not in
+// prefs keep their setting.
+func (s *ReminderService) UpdatePreferences(ctx context.Context, userID string, prefs map[string]bool) (map[string]bool, error) {
+	for channel := range prefs {
+		if !slices.Contains(models.NotificationChannels, channel) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownChannel, channel)
+		}
+	}
+
+	before, err := s.Preferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for channel, enabled := range prefs {
+		if err := s.repo.SetOptOut(ctx, userID, channel, !enabled, now); err != nil {
+			return nil, err
+		}
+	}
+
+	after, err := s.Preferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, "user.notifications_updated", models.AuditTargetUser, userID, before, after)
+	return after, nil
+}
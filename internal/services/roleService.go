@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/repositories"
+)
+
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrUnknownPermission = errors.New("unknown permission")
+	ErrProtectedRole     = errors.New("the admin role always has every permission")
+	ErrInvalidRoleName   = errors.New("role name must be 2-50 lowercase letters, digits, '-' or '_'")
+	validRoleName        = regexp.MustCompile(`^[a-z0-9_-]{2,50}$`)
+)
+
+type RoleService struct {
+	repo  repositories.RoleRepository
+	audit *AuditService
+}
+
+func NewRoleService(repo repositories.RoleRepository, auditService *AuditService) *RoleService {
+	return &RoleService{repo: repo, audit: auditService}
+}
+
+func (s *RoleService) ListRoles() ([]models.Role, error) {
+	return s.repo.ListRoles()
+}
+
+func (s *RoleService) ListPermissions() ([]models.Permission, error) {
+	return s.repo.ListPermissions()
+}
+
+// CreateRole adds a custom role with an optional initial set of permissions.
+func (s *RoleService) CreateRole(ctx context.Context, role *models.Role) error {
+	role.Name = strings.ToLower(strings.TrimSpace(role.Name))
+	if !validRoleName.MatchString(role.Name) {
+		return ErrInvalidRoleName
+	}
+
+	perms := []string{}
+	seen := map[string]bool{}
+	for _, p := range role.Permissions {
+		if seen[p] {
+			continue
+		}
+		if err := s.checkPermission(p); err != nil {
+			return err
+		}
+		seen[p] = true
+		perms = append(perms, p)
+	}
+	role.Permissions = perms
+	role.IsSystem = false
+
+	if err := s.repo.CreateRole(role); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, "role.created", models.AuditTargetRole, role.Name, nil,
+		map[string]any{"description": role.Description, "permissions": role.Permissions})
+	return nil
+}
+
+func (s *RoleService) Grant(ctx context.Context, role, permission string) error {
+	if _, err := s.role(role); err != nil {
+		return err
+	}
+	if err := s.checkPermission(permission); err != nil {
+		return err
+	}
+	if err := s.repo.Grant(role, permission); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, "role.permission_granted", models.AuditTargetRole, role, nil, map[string]any{"permission": permission})
+	return nil
+}
+
+// Revoke removes a permission from a role. Admin cannot lose permissions, so
+// there is always a role able to repair the configuration.
+func (s *RoleService) Revoke(ctx context.Context, role, permission string) error {
+	if role == models.RoleAdmin {
+		return ErrProtectedRole
+	}
+	if _, err := s.role(role); err != nil {
+		return err
+	}
+	if err := s.repo.Revoke(role, permission); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, "role.permission_revoked", models.AuditTargetRole, role, map[string]any{"permission": permission}, nil)
+	return nil
+}
+
+// ValidateRole returns ErrRoleNotFound unless name is a configured role.
+func (s *RoleService) ValidateRole(name string) error {
+	_, err := s.role(name)
+	return err
+}
+
+func (s *RoleService) role(name string) (*models.Role, error) {
+	role, err := s.repo.GetRole(name)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, ErrRoleNotFound
+	}
+	return role, nil
+}
+
+func (s *RoleService) checkPermission(name string) error {
+	exists, err := s.repo.PermissionExists(name)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrUnknownPermission, name)
+	}
+	return nil
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/FiraBro/local-go/internal/bus"
+	"github.com/FiraBro/local-go/internal/config"
+	"github.com/FiraBro/local-go/internal/domain"
 	"github.com/FiraBro/local-go/internal/models"
 	"github.com/FiraBro/local-go/internal/repositories"
 )
@@ -15,11 +18,14 @@ var (
 )
 
 type ServiceService struct {
-    repo *repositories.ServiceRepository
+    repo   repositories.ServiceRepository
+    tx     repositories.Transactor
+    audit  *AuditService
+    events *bus.Bus
 }
 
-func NewServiceService(repo *repositories.ServiceRepository) *ServiceService {
-    return &ServiceService{repo: repo}
+func NewServiceService(repo repositories.ServiceRepository, transactor repositories.Transactor, auditService *AuditService, eventBus *bus.Bus) *ServiceService {
+    return &ServiceService{repo: repo, tx: transactor, audit: auditService, events: eventBus}
 }
 
 func (s *ServiceService) Create(ctx context.Context, service *models.Service) error {
@@ -27,9 +33,25 @@ func (s *ServiceService) Create(ctx context.Context, service *models.Service) er
     if service.Name == "" || service.Price < 0 {
         return fmt.Errorf("%w: name is required and price must be positive", ErrInvalidInput)
     }
+    if service.DurationMinutes == 0 {
+        service.DurationMinutes = config.SlotIntervalMinutes
+    }
+    if err := validateTiming(service); err != nil {
+        return err
+    }
 
     // 2. Call Repo
-    return s.repo.Create(ctx, service)
+    err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+        if err := s.repo.Create(ctx, service); err != nil {
+            return err
+        }
+        return s.events.Publish(ctx, domain.ServiceCreated{Service: *service})
+    })
+    if err != nil {
+        return err
+    }
+    s.audit.Record(ctx, "service.created", models.AuditTargetService, service.ID, nil, service)
+    return nil
 }
 
 func (s *ServiceService) GetAll(ctx context.Context) ([]models.Service, error) {
@@ -58,6 +80,7 @@ func (s *ServiceService) Update(ctx context.Context, id string, req *models.Upda
     if existing == nil {
         return nil, errors.New("service not found")
     }
+    before := *existing
 
     // 2. Merge only the fields that were provided
     if req.Name != nil {
@@ -72,23 +95,71 @@ func (s *ServiceService) Update(ctx context.Context, id string, req *models.Upda
     if req.Price != nil {
         existing.Price = *req.Price
     }
+    if req.DurationMinutes != nil {
+        existing.DurationMinutes = *req.DurationMinutes
+    }
+    if req.BufferBeforeMinutes != nil {
+        existing.BufferBeforeMinutes = *req.BufferBeforeMinutes
+    }
+    if req.BufferAfterMinutes != nil {
+        existing.BufferAfterMinutes = *req.BufferAfterMinutes
+    }
+    if err := validateTiming(existing); err != nil {
+        return nil, err
+    }
 
     // 3. Save to database via REPO
-    if err := s.repo.Update(ctx, id, existing); err != nil {
+    err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+        if err := s.repo.Update(ctx, id, existing); err != nil {
+            return err
+        }
+        return s.events.Publish(ctx, domain.ServiceUpdated{Service: *existing})
+    })
+    if err != nil {
         return nil, err
     }
+    s.audit.Record(ctx, "service.updated", models.AuditTargetService, id, before, existing)
 
     return existing, nil
 }
 
 func (s *ServiceService) Delete(ctx context.Context, id string) error {
-    return s.repo.Delete(ctx, id)
+    existing, err := s.repo.GetByIDs(ctx, id)
+    if err != nil {
+        return err
+    }
+    if existing == nil {
+        return ErrServiceNotFound
+    }
+
+    err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+        if err := s.repo.Delete(ctx, id); err != nil {
+            return err
+        }
+        return s.events.Publish(ctx, domain.ServiceDeleted{Service: *existing})
+    })
+    if err != nil {
+        return err
+    }
+    s.audit.Record(ctx, "service.deleted", models.AuditTargetService, id, existing, nil)
+    return nil
 }
 
 func (s *ServiceService) GetCategories(ctx context.Context) ([]string, error) {
     return s.repo.GetCategories(ctx)
 }
 
+// validateTiming makes sure a service can actually be placed on a schedule.
+func validateTiming(service *models.Service) error {
+    if service.DurationMinutes <= 0 {
+        return fmt.Errorf("%w: duration_minutes must be positive", ErrInvalidInput)
+    }
+    if service.BufferBeforeMinutes < 0 || service.BufferAfterMinutes < 0 {
+        return fmt.Errorf("%w: buffer minutes cannot be negative", ErrInvalidInput)
+    }
+    return nil
+}
+
 // NEW: Search/Filter Logic
 func (s *ServiceService) GetByCategory(ctx context.Context, category string) ([]models.Service, error) {
     // You would need to add a Filter method to your repository to support this
@@ -4,24 +4,37 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
 	"time"
 
+	"github.com/FiraBro/local-go/internal/bus"
+	"github.com/FiraBro/local-go/internal/config"
+	"github.com/FiraBro/local-go/internal/domain"
 	"github.com/FiraBro/local-go/internal/models"
 	"github.com/FiraBro/local-go/internal/repositories"
 )
 
 type StaffService struct {
-	staffRepo   *repositories.StaffRepository
-	serviceRepo *repositories.ServiceRepository
+	staffRepo   repositories.StaffRepository
+	serviceRepo repositories.ServiceRepository
+	tx          repositories.Transactor
+	audit       *AuditService
+	events      *bus.Bus
 }
 
 func NewStaffService(
-	staffRepo *repositories.StaffRepository,
-	serviceRepo *repositories.ServiceRepository,
+	staffRepo repositories.StaffRepository,
+	serviceRepo repositories.ServiceRepository,
+	transactor repositories.Transactor,
+	auditService *AuditService,
+	eventBus *bus.Bus,
 ) *StaffService {
 	return &StaffService{
 		staffRepo:   staffRepo,
 		serviceRepo: serviceRepo,
+		tx:          transactor,
+		audit:       auditService,
+		events:      eventBus,
 	}
 }
 
@@ -31,7 +44,17 @@ func (s *StaffService) Create(ctx context.Context, staff *models.Staff) error {
 	if staff.Name == "" || staff.Email == "" {
 		return errors.New("staff name and email are required")
 	}
-	return s.staffRepo.Create(ctx, staff)
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.staffRepo.Create(ctx, staff); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.StaffCreated{Staff: *staff})
+	})
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, "staff.created", models.AuditTargetStaff, staff.ID, nil, staff)
+	return nil
 }
 
 func (s *StaffService) GetAll(ctx context.Context) ([]models.Staff, error) {
@@ -52,11 +75,40 @@ func (s *StaffService) Update(ctx context.Context, id string, staff *models.Staf
 	}
 
 	// Use the actual Update method in repo, not Create
-	return s.staffRepo.Update(ctx, id, staff)
+	staff.ID = id
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.staffRepo.Update(ctx, id, staff); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.StaffUpdated{Staff: *staff})
+	})
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, "staff.updated", models.AuditTargetStaff, id, existing, staff)
+	return nil
 }
 
 func (s *StaffService) Delete(ctx context.Context, id string) error {
-	return s.staffRepo.Delete(ctx, id)
+	existing, err := s.staffRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrStaffNotFound
+	}
+
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.staffRepo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.StaffDeleted{Staff: *existing})
+	})
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, "staff.deleted", models.AuditTargetStaff, id, existing, nil)
+	return nil
 }
 
 // ---------- SERVICES RELATIONSHIP ----------
@@ -82,6 +134,18 @@ func (s *StaffService) GetServices(ctx context.Context, staffID string) ([]model
 	return services, nil
 }
 
+// GetService looks up a service so availability can be sized by its duration.
+func (s *StaffService) GetService(ctx context.Context, serviceID string) (*models.Service, error) {
+	svc, err := s.serviceRepo.GetByIDs(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	if svc == nil {
+		return nil, ErrServiceNotFound
+	}
+	return svc, nil
+}
+
 func (s *StaffService) GetStaffByService(ctx context.Context, serviceID string) ([]models.Staff, error) {
 	return s.staffRepo.GetStaffByService(ctx, serviceID)
 }
@@ -98,7 +162,23 @@ func (s *StaffService) AssignServices(ctx context.Context, staffID string, servi
 			return fmt.Errorf("service ID %s does not exist", sID)
 		}
 	}
-	return s.staffRepo.AssignServices(ctx, staffID, serviceIDs)
+
+	before, err := s.staffRepo.GetServiceIDs(ctx, staffID)
+	if err != nil {
+		return err
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.staffRepo.AssignServices(ctx, staffID, serviceIDs); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.StaffServicesAssigned{StaffID: staffID, Services: serviceIDs})
+	})
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, "staff.services_assigned", models.AuditTargetStaff, staffID,
+		map[string]any{"services": before}, map[string]any{"services": serviceIDs})
+	return nil
 }
 
 // ---------- SCHEDULE & HOLIDAYS ----------
@@ -112,37 +192,68 @@ func (s *StaffService) GetSchedule(ctx context.Context, staffID string) ([]map[s
 
 func (s *StaffService) SetSchedule(ctx context.Context, id string, entries []map[string]string) error {
 
-// Business logic: Ensure hours are valid (e.g., 09:00 - 17:00)
+	// Business logic: Ensure hours are valid (e.g., 09:00 - 17:00)
 
-for _, entry := range entries {
+	for _, entry := range entries {
 
-if entry["day"] == "" || entry["start"] == "" || entry["end"] == "" {
+		if entry["day"] == "" || entry["start"] == "" || entry["end"] == "" {
 
-return errors.New("invalid schedule format: day, start, and end are required")
+			return errors.New("invalid schedule format: day, start, and end are required")
 
-}
+		}
 
-}
+	}
 
-return s.staffRepo.SetSchedule(ctx, id, entries)
+	before, _, err := s.staffRepo.GetAvailabilityData(ctx, id)
+	if err != nil {
+		return err
+	}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.staffRepo.SetSchedule(ctx, id, entries); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.StaffScheduleChanged{StaffID: id, Schedule: entries})
+	})
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, "staff.schedule_changed", models.AuditTargetStaff, id,
+		map[string]any{"schedule": before}, map[string]any{"schedule": entries})
+	return nil
 
 }
 
 func (s *StaffService) AddHoliday(ctx context.Context, id, date, reason string) error {
-	return s.staffRepo.AddHoliday(ctx, id, date, reason)
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.staffRepo.AddHoliday(ctx, id, date, reason); err != nil {
+			return err
+		}
+		return s.events.Publish(ctx, domain.StaffHolidayAdded{StaffID: id, Date: date, Reason: reason})
+	})
+	if err != nil {
+		return err
+	}
+	s.audit.Record(ctx, "staff.holiday_added", models.AuditTargetStaff, id,
+		nil, map[string]any{"holiday": date, "reason": reason})
+	return nil
 }
 
 // ---------- AVAILABILITY SERVICE ----------
 
 type AvailabilityService struct {
-	repo *repositories.StaffRepository
+	repo        repositories.StaffRepository
+	bookingRepo repositories.BookingRepository
 }
 
-func NewAvailabilityService(repo *repositories.StaffRepository) *AvailabilityService {
-	return &AvailabilityService{repo: repo}
+func NewAvailabilityService(repo repositories.StaffRepository, bookingRepo repositories.BookingRepository) *AvailabilityService {
+	return &AvailabilityService{repo: repo, bookingRepo: bookingRepo}
 }
 
-func (s *AvailabilityService) GetStaffSlots(ctx context.Context, staffID, date string) ([]string, error) {
+// GetStaffSlots returns the start times on date at which service fits entirely
+// (buffers included) inside one of the staff member's working windows without
+// overlapping an existing booking. A nil service means a plain slot of
+// config.SlotIntervalMinutes with no buffers.
+func (s *AvailabilityService) GetStaffSlots(ctx context.Context, staffID, date string, service *models.Service) ([]string, error) {
 	schedule, holidays, err := s.repo.GetAvailabilityData(ctx, staffID)
 	if err != nil {
 		return nil, err
@@ -160,27 +271,120 @@ func (s *AvailabilityService) GetStaffSlots(ctx context.Context, staffID, date s
 	}
 	dayName := t.Weekday().String()
 
-	var slots []string
+	booked, err := s.bookingRepo.GetBookedIntervals(ctx, staffID, date)
+	if err != nil {
+		return nil, err
+	}
+
+	before, duration, after := slotLength(service)
+
+	slots := []string{}
 	for _, sch := range schedule {
 		if sch["day"] == dayName {
-			slots = s.generateTimeSlots(sch["start"], sch["end"], 30)
+			slots = append(slots, s.generateTimeSlots(sch["start"], sch["end"], before, duration, after, booked)...)
 		}
 	}
+	sort.Strings(slots)
 	return slots, nil
 }
 
-func (s *AvailabilityService) generateTimeSlots(start, end string, interval int) []string {
+// GetServiceSlots returns the start times on date at which at least one staff
+// member offering service has room for it.
+func (s *AvailabilityService) GetServiceSlots(ctx context.Context, service *models.Service, date string) ([]string, error) {
+	staffList, err := s.repo.GetStaffByService(ctx, service.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	uniqueSlots := make(map[string]bool)
+	for _, staff := range staffList {
+		slots, err := s.GetStaffSlots(ctx, staff.ID, date, service)
+		if err != nil {
+			continue // Skip individual staff errors to provide partial results if possible
+		}
+		for _, slot := range slots {
+			uniqueSlots[slot] = true
+		}
+	}
+
+	result := []string{}
+	for slot := range uniqueSlots {
+		result = append(result, slot)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+// generateTimeSlots walks the working window in config.SlotIntervalMinutes
+// steps and keeps every start time whose blocked interval
+// [start-before, start+duration+after) stays inside the window and clear of
+// the booked intervals.
+func (s *AvailabilityService) generateTimeSlots(start, end string, before, duration, after int, booked []models.BookedInterval) []string {
 	slots := []string{}
-	curr, errStart := time.Parse("15:04", start)
-	finish, errEnd := time.Parse("15:04", end)
+	windowStart, errStart := ParseClock(start)
+	windowEnd, errEnd := ParseClock(end)
 
 	if errStart != nil || errEnd != nil {
 		return slots
 	}
 
-	for curr.Before(finish) {
-		slots = append(slots, curr.Format("15:04"))
-		curr = curr.Add(time.Minute * time.Duration(interval))
+	step := config.SlotIntervalMinutes
+	for curr := windowStart + before; curr+duration+after <= windowEnd; curr += step {
+		block := models.BookedInterval{StartMinute: curr - before, EndMinute: curr + duration + after}
+		if !overlapsAny(block, booked) {
+			slots = append(slots, FormatClock(curr))
+		}
 	}
 	return slots
-}
\ No newline at end of file
+}
+
+// BookingInterval returns the appointment end time and the interval the
+// booking blocks on the staff member's day for service starting at start.
+func BookingInterval(service *models.Service, start string) (string, models.BookedInterval, error) {
+	startMinute, err := ParseClock(start)
+	if err != nil {
+		return "", models.BookedInterval{}, err
+	}
+
+	before, duration, after := slotLength(service)
+	interval := models.BookedInterval{
+		StartMinute: startMinute - before,
+		EndMinute:   startMinute + duration + after,
+	}
+	return FormatClock(startMinute + duration), interval, nil
+}
+
+func slotLength(service *models.Service) (before, duration, after int) {
+	if service == nil {
+		return 0, config.SlotIntervalMinutes, 0
+	}
+
+	duration = service.DurationMinutes
+	if duration <= 0 {
+		duration = config.SlotIntervalMinutes
+	}
+	return service.BufferBeforeMinutes, duration, service.BufferAfterMinutes
+}
+
+func overlapsAny(block models.BookedInterval, booked []models.BookedInterval) bool {
+	for _, b := range booked {
+		if block.StartMinute < b.EndMinute && b.StartMinute < block.EndMinute {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseClock converts "HH:MM" into minutes since midnight.
+func ParseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// FormatClock converts minutes since midnight back into "HH:MM".
+func FormatClock(minutes int) string {
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
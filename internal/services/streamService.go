@@ -0,0 +1,361 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/bus"
+	"github.com/FiraBro/local-go/internal/domain"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/repositories"
+)
+
+const (
+	maxStreamTopics    = 20 // per connection
+	streamClientBuffer = 32 // messages a client may fall behind before it is dropped
+)
+
+var ErrInvalidStreamTopic = errors.New("invalid stream topic")
+
+// StreamService pushes the state of availability, staff days and users'
+// bookings to connected clients. It keeps the latest message of every topic
+// someone follows, recomputes topics when domain events may have changed
+// them, and sends those that did change.
+//
+// Message IDs are "<epoch>-<seq>", epoch being when this instance started.
+// A client reconnecting with the ID of the last message it saw gets the
+// topics that changed since; after a restart, or from another instance, it
+// gets all of them again.
+type StreamService struct {
+	availability *AvailabilityService
+	serviceRepo  repositories.ServiceRepository
+	bookingRepo  repositories.BookingRepository
+	epoch        string
+
+	refreshMu sync.Mutex // one refresh at a time, so a stale result never overtakes a fresh one
+
+	mu      sync.Mutex
+	seq     uint64
+	topics  map[string]*streamTopic // by key
+	clients map[*StreamClient]bool
+}
+
+type streamTopic struct {
+	topic       models.StreamTopic
+	latest      *models.StreamMessage
+	raw         []byte // latest data, to tell whether a refresh changed anything
+	seq         uint64
+	subscribers int
+}
+
+// StreamClient is one connection's subscription.
+type StreamClient struct {
+	keys     []string
+	messages chan models.StreamMessage
+	dropped  chan struct{}
+}
+
+// Messages delivers the client's updates.
+func (c *StreamClient) Messages() <-chan models.StreamMessage { return c.messages }
+
+// Dropped is closed if the client fell too far behind; it should reconnect
+// and resume from the last message it received.
+func (c *StreamClient) Dropped() <-chan struct{} { return c.dropped }
+
+func NewStreamService(availability *AvailabilityService, serviceRepo repositories.ServiceRepository, bookingRepo repositories.BookingRepository) *StreamService {
+	return &StreamService{
+		availability: availability,
+		serviceRepo:  serviceRepo,
+		bookingRepo:  bookingRepo,
+		epoch:        strconv.FormatInt(time.Now().UnixMilli(), 36),
+		topics:       make(map[string]*streamTopic),
+		clients:      make(map[*StreamClient]bool),
+	}
+}
+
+// ----------------------------
+// TOPICS
+// ----------------------------
+
+// ParseTopics reads the topics a client asks for: "availability:<serviceId>:<date>",
+// "staff:<staffId>:<date>" and "bookings" (the user's own).
+func (s *StreamService) ParseTopics(raw []string, userID string) ([]models.StreamTopic, error) {
+	topics := []models.StreamTopic{}
+	seen := map[models.StreamTopic]bool{}
+	for _, name := range raw {
+		parts := strings.Split(strings.TrimSpace(name), ":")
+		var t models.StreamTopic
+		switch {
+		case len(parts) == 1 && parts[0] == models.StreamTopicBookings:
+			t = models.StreamTopic{Kind: models.StreamTopicBookings, ID: userID}
+		case len(parts) == 3 && (parts[0] == models.StreamTopicAvailability || parts[0] == models.StreamTopicStaff):
+			if parts[1] == "" {
+				return nil, fmt.Errorf("%w: %q has no ID", ErrInvalidStreamTopic, name)
+			}
+			if _, err := time.Parse("2006-01-02", parts[2]); err != nil {
+				return nil, fmt.Errorf("%w: %q needs a YYYY-MM-DD date", ErrInvalidStreamTopic, name)
+			}
+			t = models.StreamTopic{Kind: parts[0], ID: parts[1], Date: parts[2]}
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrInvalidStreamTopic, name)
+		}
+
+		if !seen[t] {
+			seen[t] = true
+			topics = append(topics, t)
+		}
+	}
+
+	if len(topics) == 0 {
+		return nil, fmt.Errorf("%w: at least one topic is required", ErrInvalidStreamTopic)
+	}
+	if len(topics) > maxStreamTopics {
+		return nil, fmt.Errorf("%w: at most %d topics per connection", ErrInvalidStreamTopic, maxStreamTopics)
+	}
+	return topics, nil
+}
+
+// topicKey tells topics apart across users: every user has their own
+// "bookings".
+func topicKey(t models.StreamTopic) string {
+	if t.Kind == models.StreamTopicBookings {
+		return t.Kind + ":" + t.ID
+	}
+	return t.String()
+}
+
+// snapshot computes the current state of a topic.
+func (s *StreamService) snapshot(ctx context.Context, t models.StreamTopic) (any, error) {
+	switch t.Kind {
+	case models.StreamTopicAvailability:
+		slots := []string{}
+		service, err := s.serviceRepo.GetByIDs(ctx, t.ID)
+		if err != nil {
+			return nil, err
+		}
+		if service != nil {
+			if slots, err = s.availability.GetServiceSlots(ctx, service, t.Date); err != nil {
+				return nil, err
+			}
+		}
+		return map[string]any{"service_id": t.ID, "date": t.Date, "available_slots": slots}, nil
+	case models.StreamTopicStaff:
+		slots, err := s.availability.GetStaffSlots(ctx, t.ID, t.Date, nil)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"staff_id": t.ID, "date": t.Date, "available_slots": slots}, nil
+	default:
+		bookings, err := s.bookingRepo.GetByUser(ctx, t.ID)
+		if err != nil {
+			return nil, err
+		}
+		if bookings == nil {
+			bookings = []models.Booking{}
+		}
+		return map[string]any{"bookings": bookings}, nil
+	}
+}
+
+// ----------------------------
+// CLIENTS
+// ----------------------------
+
+// Subscribe registers a client for topics and queues what it has to catch
+// up on: everything, or with lastEventID only the topics that changed since.
+func (s *StreamService) Subscribe(ctx context.Context, topics []models.StreamTopic, lastEventID string) *StreamClient {
+	client := &StreamClient{
+		messages: make(chan models.StreamMessage, max(streamClientBuffer, len(topics))),
+		dropped:  make(chan struct{}),
+	}
+	lastSeq, resuming := s.parseEventID(lastEventID)
+
+	var stale []string
+	s.mu.Lock()
+	for _, t := range topics {
+		key := topicKey(t)
+		client.keys = append(client.keys, key)
+
+		st := s.topics[key]
+		if st == nil {
+			st = &streamTopic{topic: t}
+			s.topics[key] = st
+		}
+		st.subscribers++
+
+		switch {
+		case st.latest == nil:
+			stale = append(stale, key)
+		case !resuming || st.seq > lastSeq:
+			client.messages <- *st.latest
+		}
+	}
+	s.clients[client] = true
+	s.mu.Unlock()
+
+	// Nobody followed these, so they are computed now; the client gets the
+	// result like any other subscriber
+	for _, key := range stale {
+		s.refresh(ctx, key)
+	}
+	return client
+}
+
+// Unsubscribe forgets the client, and topics nobody follows any more.
+func (s *StreamService) Unsubscribe(client *StreamClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.clients, client)
+	for _, key := range client.keys {
+		if st := s.topics[key]; st != nil {
+			st.subscribers--
+			if st.subscribers <= 0 {
+				delete(s.topics, key)
+			}
+		}
+	}
+}
+
+// parseEventID returns the sequence number of a message ID from this
+// instance's current run.
+func (s *StreamService) parseEventID(id string) (uint64, bool) {
+	epoch, seq, ok := strings.Cut(id, "-")
+	if !ok || epoch != s.epoch {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(seq, 10, 64)
+	return n, err == nil
+}
+
+// ----------------------------
+// UPDATES
+// ----------------------------
+
+// refresh recomputes the topic under key and, if it changed, sends it to
+// the topic's subscribers.
+func (s *StreamService) refresh(ctx context.Context, key string) {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	s.mu.Lock()
+	st := s.topics[key]
+	s.mu.Unlock()
+	if st == nil {
+		return
+	}
+
+	data, err := s.snapshot(ctx, st.topic)
+	if err != nil {
+		log.Printf("⚠️ Failed to refresh stream topic %s: %v", st.topic, err)
+		return
+	}
+	raw, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("⚠️ Failed to refresh stream topic %s: %v", st.topic, err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.topics[key] != st || (st.latest != nil && bytes.Equal(st.raw, raw)) {
+		return
+	}
+
+	s.seq++
+	st.seq = s.seq
+	st.raw = raw
+	st.latest = &models.StreamMessage{
+		ID:    s.epoch + "-" + strconv.FormatUint(s.seq, 10),
+		Event: st.topic.Kind,
+		Topic: st.topic.String(),
+		Data:  json.RawMessage(raw),
+	}
+	for client := range s.clients {
+		if !slices.Contains(client.keys, key) {
+			continue
+		}
+		select {
+		case client.messages <- *st.latest:
+		default:
+			// Too far behind; it resumes from its last message on reconnect
+			delete(s.clients, client)
+			close(client.dropped)
+		}
+	}
+}
+
+// streamChange describes what a domain event may have changed. Empty fields
+// match anything, e.g. a schedule change touches every date.
+type streamChange struct {
+	staffID   string
+	serviceID string
+	date      string
+	userID    string
+}
+
+// affects reports whether c may have changed topic t. Availability depends
+// on every staff member offering the service; rather than looking up who
+// does, any staff change refreshes it, and unchanged results are not sent.
+func (c streamChange) affects(t models.StreamTopic) bool {
+	sameDate := c.date == "" || c.date == t.Date
+	switch t.Kind {
+	case models.StreamTopicAvailability:
+		return (c.serviceID != "" && c.serviceID == t.ID) || (c.staffID != "" && sameDate)
+	case models.StreamTopicStaff:
+		return c.staffID != "" && c.staffID == t.ID && sameDate
+	default:
+		return c.userID != "" && c.userID == t.ID
+	}
+}
+
+// Listen follows the bus in the background, so requests that change
+// schedules, holidays or bookings do not wait for topics to be recomputed.
+func (s *StreamService) Listen(b *bus.Bus) {
+	b.SubscribeAsync(bus.AllEvents, func(ctx context.Context, e bus.Event) error {
+		var change streamChange
+		switch e := e.(type) {
+		case domain.BookingCreated:
+			change = streamChange{staffID: e.StaffID, date: e.Date, userID: e.UserID}
+		case domain.BookingCancelled:
+			change = streamChange{staffID: e.StaffID, date: e.Date, userID: e.UserID}
+		case domain.StaffHolidayAdded:
+			change = streamChange{staffID: e.StaffID, date: e.Date}
+		case domain.StaffScheduleChanged:
+			change = streamChange{staffID: e.StaffID}
+		case domain.StaffServicesAssigned:
+			change = streamChange{staffID: e.StaffID}
+		case domain.StaffDeleted:
+			change = streamChange{staffID: e.ID}
+		case domain.ServiceUpdated:
+			change = streamChange{serviceID: e.ID}
+		case domain.ServiceDeleted:
+			change = streamChange{serviceID: e.ID}
+		default:
+			return nil
+		}
+
+		s.mu.Lock()
+		var keys []string
+		for key, st := range s.topics {
+			if change.affects(st.topic) {
+				keys = append(keys, key)
+			}
+		}
+		s.mu.Unlock()
+
+		for _, key := range keys {
+			s.refresh(ctx, key)
+		}
+		return nil
+	})
+}
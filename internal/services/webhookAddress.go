@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+
+	"github.com/FiraBro/local-go/internal/config"
+)
+
+// webhookAddressAllowed reports whether webhooks may be sent to addr. Anyone
+// with webhooks:manage picks the URL, so loopback, private and link-local
+// addresses (the database, the cloud metadata service, ...) are refused
+// unless they are in WEBHOOK_ALLOWED_NETWORKS.
+func webhookAddressAllowed(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, network := range config.WebhookAllowedNetworks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
+
+// checkWebhookHost resolves the host of a webhook URL and rejects it if any
+// of its addresses is internal.
+func checkWebhookHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("%w: cannot resolve %s", ErrInvalidWebhook, host)
+	}
+	for _, addr := range addrs {
+		if !webhookAddressAllowed(addr) {
+			return fmt.Errorf("%w: %s resolves to the internal address %s", ErrInvalidWebhook, host, addr.Unmap())
+		}
+	}
+	return nil
+}
+
+// newWebhookTransport dials only addresses webhookAddressAllowed accepts. The
+// check runs on the address actually connected to, so a host that resolved
+// to a public address at registration and an internal one now is still
+// refused.
+func newWebhookTransport() *http.Transport {
+	dialer := &net.Dialer{
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !webhookAddressAllowed(addrPort.Addr()) {
+				return fmt.Errorf("webhook to internal address %s refused", addrPort.Addr().Unmap())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Through a proxy the dialled address would be the proxy's
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return transport
+}
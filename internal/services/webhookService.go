@@ -0,0 +1,486 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/bus"
+	"github.com/FiraBro/local-go/internal/config"
+	"github.com/FiraBro/local-go/internal/models"
+	"github.com/FiraBro/local-go/internal/repositories"
+	"github.com/google/uuid"
+)
+
+const (
+	webhookBatchSize     = 20
+	webhookRetryBase     = 30 * time.Second
+	webhookRetryMax      = 6 * time.Hour
+	webhookRetention     = 30 * 24 * time.Hour // delivered and dead deliveries are deleted after this
+	webhookResponseLimit = 1024                // bytes of the response body kept in the log
+	webhookSecretPrefix  = "whsec_"
+
+	defaultWebhookPageSize = 50
+	maxWebhookPageSize     = 500
+)
+
+var (
+	ErrWebhookNotFound         = errors.New("webhook endpoint not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrInvalidWebhook          = errors.New("invalid webhook endpoint")
+)
+
+// WebhookService sends domain events to admin-registered endpoints. Each
+// event from the bus queues one delivery per subscribed endpoint; a
+// background worker POSTs
+// them, signed with the endpoint's secret, and retries failures with
+// exponential backoff until WEBHOOK_MAX_ATTEMPTS, after which they are
+// dead-lettered for manual redelivery.
+type WebhookService struct {
+	repo   repositories.WebhookRepository
+	audit  *AuditService
+	client *http.Client
+	wake   chan struct{}
+}
+
+func NewWebhookService(repo repositories.WebhookRepository, auditService *AuditService) *WebhookService {
+	return &WebhookService{
+		repo:  repo,
+		audit: auditService,
+		client: &http.Client{
+			Timeout:   config.WebhookTimeout,
+			Transport: newWebhookTransport(),
+			// A redirect is a failed delivery, not an invitation to POST elsewhere
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		wake: make(chan struct{}, 1),
+	}
+}
+
+// ----------------------------
+// PUBLISH
+// ----------------------------
+
+// webhookAliases names the webhook event that domain events of finer grain
+// go out as.
+var webhookAliases = map[string]string{
+	"staff.services_assigned": models.WebhookStaffScheduleUpdated,
+	"staff.schedule_changed":  models.WebhookStaffScheduleUpdated,
+	"staff.holiday_added":     models.WebhookStaffScheduleUpdated,
+}
+
+// Subscribe queues webhooks for the bus's events. It subscribes
+// synchronously: queueing is a database write, and once queued a delivery
+// survives restarts.
+func (s *WebhookService) Subscribe(b *bus.Bus) {
+	b.Subscribe(bus.AllEvents, func(ctx context.Context, e bus.Event) error {
+		eventType := e.EventName()
+		if alias, ok := webhookAliases[eventType]; ok {
+			eventType = alias
+		}
+		if !slices.Contains(models.WebhookEventTypes, eventType) {
+			return nil
+		}
+		return s.publish(eventType, e)
+	})
+}
+
+// publish queues an event of eventType carrying data (marshalled to JSON)
+// for every enabled endpoint subscribed to it.
+func (s *WebhookService) publish(eventType string, data any) error {
+	endpoints, err := s.repo.ListEnabledEndpoints()
+	if err != nil {
+		return err
+	}
+	endpoints = slices.DeleteFunc(endpoints, func(e models.WebhookEndpoint) bool { return !e.Subscribes(eventType) })
+	if len(endpoints) == 0 {
+		return nil
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	eventID := uuid.New().String()
+	payload, err := json.Marshal(models.WebhookPayload{
+		ID:        eventID,
+		Type:      eventType,
+		CreatedAt: now,
+		Data:      raw,
+	})
+	if err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, 0, len(endpoints))
+	for _, e := range endpoints {
+		deliveries = append(deliveries, models.WebhookDelivery{
+			ID:            uuid.New().String(),
+			EndpointID:    e.ID,
+			EventID:       eventID,
+			EventType:     eventType,
+			Payload:       payload,
+			Status:        models.WebhookDeliveryPending,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+	if err := s.repo.EnqueueDeliveries(deliveries); err != nil {
+		return err
+	}
+
+	s.notify()
+	return nil
+}
+
+// notify wakes the worker so it delivers now rather than at the next poll.
+func (s *WebhookService) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// ----------------------------
+// ENDPOINTS
+// ----------------------------
+
+func (s *WebhookService) ListEndpoints() ([]models.WebhookEndpoint, error) {
+	return s.repo.ListEndpoints()
+}
+
+func (s *WebhookService) GetEndpoint(id string) (*models.WebhookEndpoint, error) {
+	e, err := s.repo.GetEndpoint(id)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return e, nil
+}
+
+// CreateEndpoint registers an endpoint and returns it with its signing
+// secret, which is not shown again.
+func (s *WebhookService) CreateEndpoint(ctx context.Context, req *models.WebhookEndpointRequest, createdBy string) (*models.WebhookEndpoint, string, error) {
+	if req.URL == nil || req.EventTypes == nil {
+		return nil, "", fmt.Errorf("%w: url and event_types are required", ErrInvalidWebhook)
+	}
+
+	secret, err := newWebhookSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	now := time.Now()
+	e := &models.WebhookEndpoint{
+		ID:        uuid.New().String(),
+		Secret:    secret,
+		Enabled:   true,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := applyWebhookRequest(ctx, e, req); err != nil {
+		return nil, "", err
+	}
+
+	if err := s.repo.CreateEndpoint(e); err != nil {
+		return nil, "", err
+	}
+	s.audit.Record(ctx, "webhook.created", models.AuditTargetWebhook, e.ID, nil, e)
+	return e, secret, nil
+}
+
+// UpdateEndpoint changes the fields set in req.
+func (s *WebhookService) UpdateEndpoint(ctx context.Context, id string, req *models.WebhookEndpointRequest) (*models.WebhookEndpoint, error) {
+	e, err := s.GetEndpoint(id)
+	if err != nil {
+		return nil, err
+	}
+	before := *e
+
+	if err := applyWebhookRequest(ctx, e, req); err != nil {
+		return nil, err
+	}
+	e.UpdatedAt = time.Now()
+	if err := s.repo.UpdateEndpoint(e); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, "webhook.updated", models.AuditTargetWebhook, id, before, e)
+	return e, nil
+}
+
+// RotateSecret replaces the signing secret; deliveries from now on, retries
+// included, are signed with the new one.
+func (s *WebhookService) RotateSecret(ctx context.Context, id string) (string, error) {
+	e, err := s.GetEndpoint(id)
+	if err != nil {
+		return "", err
+	}
+	if e.Secret, err = newWebhookSecret(); err != nil {
+		return "", err
+	}
+	e.UpdatedAt = time.Now()
+	if err := s.repo.UpdateEndpoint(e); err != nil {
+		return "", err
+	}
+	s.audit.Record(ctx, "webhook.secret_rotated", models.AuditTargetWebhook, id, nil, nil)
+	return e.Secret, nil
+}
+
+// DeleteEndpoint removes the endpoint along with its delivery log.
+func (s *WebhookService) DeleteEndpoint(ctx context.Context, id string) error {
+	e, err := s.GetEndpoint(id)
+	if err != nil {
+		return err
+	}
+	deleted, err := s.repo.DeleteEndpoint(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrWebhookNotFound
+	}
+	s.audit.Record(ctx, "webhook.deleted", models.AuditTargetWebhook, id, e, nil)
+	return nil
+}
+
+// applyWebhookRequest validates and copies the fields set in req.
+func applyWebhookRequest(ctx context.Context, e *models.WebhookEndpoint, req *models.WebhookEndpointRequest) error {
+	if req.URL != nil {
+		u, err := url.Parse(strings.TrimSpace(*req.URL))
+		if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidWebhook)
+		}
+		if err := checkWebhookHost(ctx, u.Hostname()); err != nil {
+			return err
+		}
+		e.URL = u.String()
+	}
+	if req.Description != nil {
+		e.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.EventTypes != nil {
+		types := []string{}
+		for _, t := range *req.EventTypes {
+			if t != models.WebhookAllEvents && !slices.Contains(models.WebhookEventTypes, t) {
+				return fmt.Errorf("%w: unknown event type %q", ErrInvalidWebhook, t)
+			}
+			if !slices.Contains(types, t) {
+				types = append(types, t)
+			}
+		}
+		if len(types) == 0 {
+			return fmt.Errorf("%w: subscribe to at least one event type", ErrInvalidWebhook)
+		}
+		e.EventTypes = types
+	}
+	if req.Enabled != nil {
+		e.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return webhookSecretPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// ----------------------------
+// DELIVERIES
+// ----------------------------
+
+// ListDeliveries returns an endpoint's delivery log, newest first, at most
+// 500 at a time.
+func (s *WebhookService) ListDeliveries(filter models.WebhookDeliveryFilter) ([]models.WebhookDelivery, error) {
+	if _, err := s.GetEndpoint(filter.EndpointID); err != nil {
+		return nil, err
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultWebhookPageSize
+	}
+	filter.Limit = min(filter.Limit, maxWebhookPageSize)
+	filter.Offset = max(filter.Offset, 0)
+	return s.repo.ListDeliveries(filter)
+}
+
+// Redeliver queues the payload of a past delivery again, as a new delivery
+// with the same event ID, whatever became of the original.
+func (s *WebhookService) Redeliver(ctx context.Context, endpointID, deliveryID string) (*models.WebhookDelivery, error) {
+	if _, err := s.GetEndpoint(endpointID); err != nil {
+		return nil, err
+	}
+	original, err := s.repo.GetDelivery(endpointID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if original == nil {
+		return nil, ErrWebhookDeliveryNotFound
+	}
+
+	now := time.Now().UTC()
+	d := models.WebhookDelivery{
+		ID:            uuid.New().String(),
+		EndpointID:    endpointID,
+		EventID:       original.EventID,
+		EventType:     original.EventType,
+		Payload:       original.Payload,
+		Status:        models.WebhookDeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	if err := s.repo.EnqueueDeliveries([]models.WebhookDelivery{d}); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, "webhook.redelivered", models.AuditTargetWebhook, endpointID, nil,
+		map[string]any{"delivery_id": deliveryID, "redelivery_id": d.ID})
+
+	s.notify()
+	return &d, nil
+}
+
+// StartWorker delivers queued events whenever some are published and every
+// interval, to pick up retries. Several instances may run workers at once.
+func (s *WebhookService) StartWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			s.deliverDue()
+
+			select {
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+}
+
+// deliverDue sends every due delivery, a batch at a time.
+func (s *WebhookService) deliverDue() {
+	for {
+		now := time.Now()
+		due, err := s.repo.ListDueDeliveries(now, webhookBatchSize)
+		if err != nil {
+			log.Println("⚠️ Failed to read webhook deliveries:", err)
+			return
+		}
+
+		for i := range due {
+			s.deliver(&due[i], now)
+		}
+		if len(due) < webhookBatchSize {
+			return
+		}
+	}
+}
+
+func (s *WebhookService) deliver(d *models.WebhookDelivery, now time.Time) {
+	// The lease outlasts the request, so a live worker is never raced
+	claimed, err := s.repo.ClaimDelivery(d.ID, now, now.Add(2*config.WebhookTimeout))
+	if err != nil || !claimed {
+		return
+	}
+	attempt := d.Attempts + 1
+
+	endpoint, err := s.repo.GetEndpoint(d.EndpointID)
+	if err != nil {
+		log.Println("⚠️ Failed to load webhook endpoint:", err)
+		return // retried once the lease runs out
+	}
+	if endpoint == nil || !endpoint.Enabled {
+		err = s.repo.MarkDead(d.ID, 0, "", "endpoint disabled")
+		if err != nil {
+			log.Println("⚠️ Failed to update webhook delivery:", err)
+		}
+		return
+	}
+
+	status, body, err := s.post(endpoint, d)
+	switch {
+	case err == nil:
+		err = s.repo.MarkDelivered(d.ID, time.Now(), status, body)
+	case attempt >= config.WebhookMaxAttempts:
+		log.Printf("⚠️ Giving up on %s webhook %s to %s after %d attempts: %v", d.EventType, d.ID, endpoint.URL, attempt, err)
+		err = s.repo.MarkDead(d.ID, status, body, err.Error())
+	default:
+		err = s.repo.MarkRetry(d.ID, time.Now().Add(webhookRetryDelay(attempt)), status, body, err.Error())
+	}
+	if err != nil {
+		log.Println("⚠️ Failed to update webhook delivery:", err)
+	}
+}
+
+// post sends the payload and returns the response status and the start of
+// its body. Anything but a 2xx answer is an error.
+func (s *WebhookService) post(endpoint *models.WebhookEndpoint, d *models.WebhookDelivery) (int, string, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "local-go-webhooks/1")
+	req.Header.Set("X-Webhook-ID", d.EventID)
+	req.Header.Set("X-Webhook-Event", d.EventType)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", "sha256="+SignWebhook(endpoint.Secret, timestamp, d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseLimit))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(body), fmt.Errorf("endpoint answered %s", resp.Status)
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// SignWebhook is the hex HMAC-SHA256, keyed with the endpoint secret, of
+// "<timestamp>.<body>". Receivers recompute it from the X-Webhook-Timestamp
+// header and the raw body, and should reject old timestamps.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookRetryDelay doubles from webhookRetryBase after each failed
+// attempt, up to webhookRetryMax.
+func webhookRetryDelay(attempt int) time.Duration {
+	delay := webhookRetryBase
+	for i := 1; i < attempt && delay < webhookRetryMax; i++ {
+		delay *= 2
+	}
+	return min(delay, webhookRetryMax)
+}
+
+// CleanupDeliveries forgets delivered and dead deliveries older than 30 days.
+func (s *WebhookService) CleanupDeliveries(context.Context) error {
+	_, err := s.repo.DeleteDeliveriesBefore(time.Now().Add(-webhookRetention))
+	return err
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/FiraBro/local-go/internal/config"
+)
+
+// Expected signatures computed independently (Python hmac/hashlib)
+func TestSignWebhook(t *testing.T) {
+	body := []byte(`{"event":"booking.created"}`)
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		want      string
+	}{
+		{"payload", "whsec_test", "1700000000", body, "612003e72749f743f6381c0dbfd68c80a4636ee4e67201b170529734fb032ab2"},
+		{"other timestamp", "whsec_test", "1700000001", body, "5c5b9c439c6e8ca827adfba9134f174a57e140a5285c0f47af8f75ceb7751c0d"},
+		{"other secret", "other", "1700000000", body, "8942d127ec31b2a93bae13a44608670c7695a8e9bc24a950b56b1caf4fe62ddb"},
+		{"empty body", "whsec_test", "1700000000", nil, "5967f3c560522fa40cf2876ebc3c3a08551dd6959aaade3b413460591895bdcc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SignWebhook(tt.secret, tt.timestamp, tt.body); got != tt.want {
+				t.Errorf("SignWebhook = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebhookAddressAllowed(t *testing.T) {
+	allowed := config.WebhookAllowedNetworks
+	t.Cleanup(func() { config.WebhookAllowedNetworks = allowed })
+	config.WebhookAllowedNetworks = []netip.Prefix{netip.MustParsePrefix("10.1.2.0/24")}
+
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1::", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"0.0.0.0", false},
+		{"10.0.0.1", false},
+		{"172.16.5.4", false},
+		{"192.168.1.1", false},
+		{"fd00::1", false},
+		{"169.254.169.254", false},
+		{"fe80::1", false},
+		{"10.1.2.3", true},
+		{"::ffff:10.1.2.3", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := webhookAddressAllowed(netip.MustParseAddr(tt.addr)); got != tt.want {
+				t.Errorf("webhookAddressAllowed(%s) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package tokens
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// createdHeader is the PEM header recording when a key was (or will be)
+// put into service. Keys without it fall back to the file's mtime.
+const createdHeader = "Created"
+
+// signingKey is one private key from the key directory; its kid is the file
+// name without ".pem".
+type signingKey struct {
+	id      string
+	created time.Time
+	private crypto.Signer
+	method  jwt.SigningMethod
+}
+
+func (k *signingKey) public() crypto.PublicKey {
+	return k.private.Public()
+}
+
+// loadKeys reads every *.pem private key in dir, oldest first. Unreadable
+// files are logged and skipped so one bad file cannot take the API down.
+func loadKeys(dir string) ([]*signingKey, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []*signingKey
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".pem") {
+			continue
+		}
+
+		key, err := loadKey(filepath.Join(dir, e.Name()))
+		if err != nil {
+			log.Printf("⚠ Skipping signing key %s: %v", e.Name(), err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].created.Before(keys[j].created) })
+	return keys, nil
+}
+
+func loadKey(path string) (*signingKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM type %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &signingKey{id: strings.TrimSuffix(filepath.Base(path), ".pem")}
+	switch k := parsed.(type) {
+	case ed25519.PrivateKey:
+		key.private, key.method = k, jwt.SigningMethodEdDSA
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must be at least 2048 bits")
+		}
+		key.private, key.method = k, jwt.SigningMethodRS256
+	default:
+		return nil, fmt.Errorf("unsupported key type %T (use Ed25519 or RSA)", parsed)
+	}
+
+	if created, ok := block.Headers[createdHeader]; ok {
+		if key.created, err = time.Parse(time.RFC3339, created); err != nil {
+			return nil, fmt.Errorf("invalid %s header: %w", createdHeader, err)
+		}
+	} else {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		key.created = info.ModTime()
+	}
+	return key, nil
+}
+
+// generateKey writes a new Ed25519 key into dir, effective from now.
+func generateKey(dir string, now time.Time) (*signingKey, error) {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, err
+	}
+
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	id := now.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	data := pem.EncodeToMemory(&pem.Block{
+		Type:    "PRIVATE KEY",
+		Headers: map[string]string{createdHeader: now.UTC().Format(time.RFC3339)},
+		Bytes:   der,
+	})
+	if err := os.WriteFile(filepath.Join(dir, id+".pem"), data, 0o600); err != nil {
+		return nil, err
+	}
+
+	return &signingKey{id: id, created: now, private: private, method: jwt.SigningMethodEdDSA}, nil
+}
@@ -0,0 +1,236 @@
+// Package tokens signs and verifies the API's JWTs with asymmetric keys.
+//
+// Keys live as PEM files in config.JWTKeyDir. The newest key whose Created
+// time has passed signs new tokens; older keys keep verifying until every
+// token they could have signed has expired, and keys dated in the future are
+// published in the JWKS ahead of time so clients can cache them before use.
+package tokens
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/FiraBro/local-go/internal/config"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// MaxTTL is the longest lifetime Sign accepts. A retired key is kept for this
+// long after its successor takes over.
+const MaxTTL = 72 * time.Hour
+
+var (
+	ErrNoSigningKey = errors.New("no active signing key")
+	ErrInvalidToken = errors.New("invalid or expired token")
+
+	mu   sync.RWMutex
+	keys []*signingKey // oldest first
+)
+
+// Init loads the key directory, creating or rotating the signing key when
+// due. It must run before Sign or Parse.
+func Init() error {
+	if err := refresh(time.Now()); err != nil {
+		return err
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	active := activeKey(time.Now())
+	if active == nil {
+		return ErrNoSigningKey
+	}
+	log.Printf("🔑 Loaded %d signing key(s) from %s, signing with %s", len(keys), config.JWTKeyDir, active.id)
+	return nil
+}
+
+// StartRotation re-reads the key directory every interval so that keys added
+// by an operator (or another instance) are picked up and rotation happens
+// without a restart.
+func StartRotation(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			if err := refresh(now); err != nil {
+				log.Println("⚠ Signing key refresh failed:", err)
+			}
+		}
+	}()
+}
+
+func refresh(now time.Time) error {
+	loaded, err := loadKeys(config.JWTKeyDir)
+	if err != nil {
+		return err
+	}
+
+	if due(loaded, now) {
+		key, err := generateKey(config.JWTKeyDir, now)
+		if err != nil {
+			return fmt.Errorf("generate signing key: %w", err)
+		}
+		log.Println("🔑 Generated signing key", key.id)
+		loaded = append(loaded, key)
+	}
+
+	mu.Lock()
+	keys = retained(loaded, now)
+	mu.Unlock()
+	return nil
+}
+
+// due reports whether a new key should be generated: there is no key usable
+// now, or the active one is older than the rotation period and no successor
+// has been scheduled.
+func due(keys []*signingKey, now time.Time) bool {
+	if len(keys) == 0 {
+		return true
+	}
+
+	newest := keys[len(keys)-1]
+	if newest.created.After(now) {
+		return false // a successor is already scheduled
+	}
+	return config.JWTKeyRotation > 0 && now.Sub(newest.created) >= config.JWTKeyRotation
+}
+
+// retained drops keys retired for longer than MaxTTL: nothing they signed can
+// still be valid.
+func retained(keys []*signingKey, now time.Time) []*signingKey {
+	var out []*signingKey
+	for i, k := range keys {
+		if i+1 < len(keys) {
+			successor := keys[i+1]
+			if !successor.created.After(now) && now.Sub(successor.created) > MaxTTL {
+				continue
+			}
+		}
+		out = append(out, k)
+	}
+	return out
+}
+
+// activeKey is the newest key already in service. Callers hold mu.
+func activeKey(now time.Time) *signingKey {
+	for i := len(keys) - 1; i >= 0; i-- {
+		if !keys[i].created.After(now) {
+			return keys[i]
+		}
+	}
+	return nil
+}
+
+func findKey(id string) *signingKey {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, k := range keys {
+		if k.id == id {
+			return k
+		}
+	}
+	return nil
+}
+
+// Sign issues a token for audience that expires after ttl (at most MaxTTL).
+// iss, aud, iat and exp are set here; claims carries the rest.
+func Sign(claims jwt.MapClaims, audience string, ttl time.Duration) (string, error) {
+	if ttl <= 0 || ttl > MaxTTL {
+		return "", fmt.Errorf("token lifetime %s outside (0, %s]", ttl, MaxTTL)
+	}
+
+	now := time.Now()
+	mu.RLock()
+	key := activeKey(now)
+	mu.RUnlock()
+	if key == nil {
+		return "", ErrNoSigningKey
+	}
+
+	claims["iss"] = config.JWTIssuer
+	claims["aud"] = audience
+	claims["iat"] = now.Unix()
+	claims["exp"] = now.Add(ttl).Unix()
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.id
+	return token.SignedString(key.private)
+}
+
+// audienceIssuer is implemented by jwt.MapClaims and *jwt.RegisteredClaims
+// (and so by structs embedding it).
+type audienceIssuer interface {
+	VerifyAudience(cmp string, req bool) bool
+	VerifyIssuer(cmp string, req bool) bool
+}
+
+// Parse verifies the signature, expiry, issuer and audience of token and
+// fills claims.
+func Parse(token string, claims jwt.Claims, audience string) error {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{
+		jwt.SigningMethodEdDSA.Alg(),
+		jwt.SigningMethodRS256.Alg(),
+	}))
+
+	parsed, err := parser.ParseWithClaims(token, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key := findKey(kid)
+		if key == nil {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		if t.Method.Alg() != key.method.Alg() {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return key.public(), nil
+	})
+	if err != nil || !parsed.Valid {
+		return ErrInvalidToken
+	}
+
+	ai, ok := claims.(audienceIssuer)
+	if !ok || !ai.VerifyIssuer(config.JWTIssuer, true) || !ai.VerifyAudience(audience, true) {
+		return ErrInvalidToken
+	}
+	return nil
+}
+
+// JWK is one public key in JSON Web Key format (RFC 7517).
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKS returns the public half of every key that may sign or has signed a
+// still-valid token.
+func JWKS() []JWK {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	enc := base64.RawURLEncoding
+	set := make([]JWK, 0, len(keys))
+	for _, k := range keys {
+		jwk := JWK{Kid: k.id, Use: "sig", Alg: k.method.Alg()}
+		switch pub := k.public().(type) {
+		case ed25519.PublicKey:
+			jwk.Kty, jwk.Crv, jwk.X = "OKP", "Ed25519", enc.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = enc.EncodeToString(pub.N.Bytes())
+			jwk.E = enc.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		}
+		set = append(set, jwk)
+	}
+	return set
+}
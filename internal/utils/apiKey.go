@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// GenerateAPIKey returns a new random key starting with prefix, and the
+// short display prefix that identifies it in listings.
+func GenerateAPIKey(prefix string) (key, display string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+
+	key = prefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:len(prefix)+8], nil
+}
+
+// HashAPIKey is how API keys are stored and looked up. Keys are random and
+// long, so a fast hash is enough.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}